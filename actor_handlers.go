@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"mongdbs/resolvers"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// curl -X GET "http://localhost:8085/api/actors/compare?id=apt28&id=apt29" 比较多个威胁组织的技战术重叠
+func compareThreatActorsHandler(c *gin.Context) {
+	ids := c.QueryArray("id")
+	if len(ids) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least two id parameters must be provided"})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	results, err := resolver.Query().CompareThreatActors(ctx, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
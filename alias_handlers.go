@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"mongdbs/model"
+	"mongdbs/resolvers"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// curl -X GET "http://localhost:8085/api/aliases/resolve?name=APT29" 将任意名称解析为规范的知识条目
+func resolveAliasHandler(c *gin.Context) {
+	name := c.Query("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must be provided"})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	results, err := resolver.Query().ResolveAlias(ctx, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// curl -X GET "http://localhost:8085/api/aliases?knowledgeId=5"
+func listAliasesHandler(c *gin.Context) {
+	knowledgeID := c.Query("knowledgeId")
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	results, err := resolver.Query().ListAliases(ctx, knowledgeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// curl -X POST http://localhost:8085/api/aliases -H "Content-Type: application/json" -d '{"name": "The Dukes", "knowledgeId": "5"}'
+func createAliasHandler(c *gin.Context) {
+	var alias model.NewAlias
+	if err := c.BindJSON(&alias); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	createdAlias, err := resolver.Mutation().CreateAlias(ctx, alias)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, createdAlias)
+}
+
+func deleteAliasHandler(c *gin.Context) {
+	id := c.Param("id")
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	deletionStatus, err := resolver.Mutation().DeleteAlias(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deletionStatus)
+}
+
+// 根据全部知识条目的 alias 字段重建别名注册表
+func rebuildAliasRegistryHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	status, err := resolver.Mutation().RebuildAliasRegistry(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"mongdbs/model"
+	"mongdbs/resolvers"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// curl -X POST "http://localhost:8085/api/knowledge/batch?dryRun=true" -H "Content-Type: application/json" -d '{"filter": {"knowledgeType": ["漏洞"]}, "operations": [{"op": "add", "field": "tags", "values": ["log4j"]}, {"op": "confidentiality", "value": "内部"}]}'
+// op 可以是 set、unset、add、remove、delete、confidentiality；按 ids 或 filter 选择条目
+// "atomic": true 时任一条目失败则全部回滚，需要 MongoDB 以副本集方式运行
+func batchKnowledgeHandler(c *gin.Context) {
+	var req model.BatchRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("dryRun") == "true" {
+		req.DryRun = true
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	report, err := resolver.Mutation().BatchKnowledge(ctx, req)
+	if err != nil {
+		schemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
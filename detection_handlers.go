@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mongdbs/model"
+	"mongdbs/resolvers"
+	"mongdbs/util"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// detectionError 规则语法错误返回 400 和全部问题，其他错误返回 500
+func detectionError(c *gin.Context, err error) {
+	var invalid *model.RuleValidationError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "type": invalid.Type, "errors": invalid.Errors})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// curl -X GET "http://localhost:8085/api/detections?knowledgeId=5&type=sigma"
+func listDetectionRulesHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	results, err := resolver.Query().ListDetectionRules(ctx, c.Query("knowledgeId"), c.Query("type"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// curl -X GET "http://localhost:8085/api/detections/export?type=yara" -o rules.yar
+// 导出某一类型的全部规则用于部署，Sigma 以 YAML 多文档形式拼接，其余类型逐条拼接
+func exportDetectionRulesHandler(c *gin.Context) {
+	ruleType := util.NormalizeRuleType(c.Query("type"))
+	if ruleType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be one of sigma, yara, snort, suricata, kql, spl"})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	results, err := resolver.Query().ListDetectionRules(ctx, "", ruleType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sep := "\n\n"
+	if ruleType == util.RuleSigma {
+		sep = "\n---\n"
+	}
+	rules := make([]string, 0, len(results))
+	for _, rule := range results {
+		rules = append(rules, strings.TrimSpace(rule.Rule))
+	}
+	c.String(http.StatusOK, strings.Join(rules, sep)+"\n")
+}
+
+// curl -X POST http://localhost:8085/api/detections -H "Content-Type: application/json" -d '{"knowledgeId": "5", "type": "sigma", "rule": "title: ..."}'
+func createDetectionRuleHandler(c *gin.Context) {
+	var input model.NewDetectionRule
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	rule, err := resolver.Mutation().CreateDetectionRule(ctx, input)
+	if err != nil {
+		detectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// 只校验规则语法和技术对照，不保存
+func validateDetectionRuleHandler(c *gin.Context) {
+	var input model.NewDetectionRule
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	rule, err := resolver.Query().ValidateDetectionRule(ctx, input)
+	if err != nil {
+		detectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func updateDetectionRuleHandler(c *gin.Context) {
+	var input model.UpdateDetectionRule
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	rule, err := resolver.Mutation().UpdateDetectionRule(ctx, c.Param("id"), input)
+	if err != nil {
+		detectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func deleteDetectionRuleHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	deletionStatus, err := resolver.Mutation().DeleteDetectionRule(ctx, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deletionStatus)
+}
+
+var SIGMA_TEST_MAX_SIZE int64 = 20 << 20 // 样本事件的大小上限
+
+// curl -X POST "http://localhost:8085/api/detections/test?knowledgeId=5" -F "file=@events.jsonl"
+// 也可以指定规则：curl -X POST "http://localhost:8085/api/detections/test?id=<rule id>" --data-binary @events.jsonl
+func testSigmaRulesHandler(c *gin.Context) {
+	if c.Request.ContentLength > SIGMA_TEST_MAX_SIZE {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "submitted events are too large"})
+		return
+	}
+
+	var reader io.Reader = c.Request.Body
+	if file, _, err := c.Request.FormFile("file"); err == nil {
+		defer file.Close()
+		reader = file
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	report, err := resolver.Query().TestSigmaRules(ctx, io.LimitReader(reader, SIGMA_TEST_MAX_SIZE), c.QueryArray("id"), c.Query("knowledgeId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
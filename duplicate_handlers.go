@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"mongdbs/model"
+	"mongdbs/resolvers"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// curl -X POST "http://localhost:8085/api/duplicates/detect?threshold=0.8"
+// 按 cve、cnvd、techniquesId 和标题加正文的相似度检测疑似重复，结果写入审核队列
+// threshold 在 0.7 到 1 之间，不传时为 0.8
+func detectDuplicatesHandler(c *gin.Context) {
+	threshold, err := strconv.ParseFloat(c.DefaultQuery("threshold", "0"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid threshold parameter"})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	status, err := resolver.Mutation().DetectDuplicates(ctx, threshold)
+	if err != nil {
+		kindError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// curl -X GET "http://localhost:8085/api/duplicates?status=pending&nums=50"
+func duplicateCandidatesHandler(c *gin.Context) {
+	nums, err := strconv.Atoi(c.DefaultQuery("nums", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid nums parameter"})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	candidates, err := resolver.Query().DuplicateCandidates(ctx, c.Query("status"), nums)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, candidates)
+}
+
+// curl -X POST "http://localhost:8085/api/duplicates/<id1>|<id2>/dismiss"
+func dismissDuplicateHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	candidate, err := resolver.Mutation().DismissDuplicate(ctx, c.Param("id"))
+	if err != nil {
+		kindError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, candidate)
+}
+
+// curl -X POST http://localhost:8085/api/knowledge/merge -H "Content-Type: application/json" -d '{"winner": "<id>", "loser": "<id>", "fields": {"content": "loser", "tags": "union"}}'
+// fields 中每个字段可以取 winner、loser、union；被合并条目的 ID 之后通过 /api/knowledge/id 查询会返回合并后的条目
+func mergeKnowledgeHandler(c *gin.Context) {
+	var req model.MergeRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	result, err := resolver.Mutation().MergeKnowledge(ctx, req)
+	if err != nil {
+		kindError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// curl -X GET "http://localhost:8085/api/knowledge/merges?id=<id>"
+func knowledgeMergesHandler(c *gin.Context) {
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be provided"})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	merges, err := resolver.Query().KnowledgeMerges(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, merges)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"mongdbs/model"
+	"mongdbs/resolvers"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+)
+
+var IMPORT_FOLDER = "/var/data/imports" // NVD 等本地数据文件的存放路径
+
+// Atomic 为 true 时整个文件在一个事务中导入，任一条目失败则全部回滚。
+// 事务默认最长 60 秒，较大的 NVD 年度数据文件不适合整体导入
+type importBody struct {
+	Path   string `json:"path" form:"path"`
+	Atomic bool   `json:"atomic" form:"atomic"`
+}
+
+// importFilePath 将请求中的相对路径限制在 IMPORT_FOLDER 下
+func importFilePath(name string) (string, error) {
+	if name == "" {
+		return "", errors.New("path must be provided")
+	}
+	return filepath.Join(IMPORT_FOLDER, filepath.Clean("/"+name)), nil
+}
+
+// curl -X POST http://localhost:8085/api/import/nvd -H "Content-Type: application/json" -d '{"path": "nvdcve-2.0-2024.json.gz"}'
+func importNVDFeedHandler(c *gin.Context) {
+	var body importBody
+	if err := c.Bind(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	path, err := importFilePath(body.Path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	report, err := resolver.Mutation().RunImport(ctx, body.Atomic, func(ctx context.Context) (*model.ImportReport, error) {
+		return resolver.Mutation().ImportNVDFeed(ctx, path)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// curl -X POST http://localhost:8085/api/import/cwe -H "Content-Type: application/json" -d '{"path": "cwec_v4.14.xml"}'
+func importCWECatalogHandler(c *gin.Context) {
+	var body importBody
+	if err := c.Bind(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	path, err := importFilePath(body.Path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	report, err := resolver.Mutation().RunImport(ctx, body.Atomic, func(ctx context.Context) (*model.ImportReport, error) {
+		return resolver.Mutation().ImportCWECatalog(ctx, path)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// curl -X POST http://localhost:8085/api/import/capec -H "Content-Type: application/json" -d '{"path": "capec_v3.9.xml"}'
+func importCAPECCatalogHandler(c *gin.Context) {
+	var body importBody
+	if err := c.Bind(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	path, err := importFilePath(body.Path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	report, err := resolver.Mutation().RunImport(ctx, body.Atomic, func(ctx context.Context) (*model.ImportReport, error) {
+		return resolver.Mutation().ImportCAPECCatalog(ctx, path)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// curl -X POST http://localhost:8085/api/import/kev -H "Content-Type: application/json" -d '{"path": "known_exploited_vulnerabilities.json", "atomic": true}'
+func importKEVCatalogHandler(c *gin.Context) {
+	var body importBody
+	if err := c.Bind(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	path, err := importFilePath(body.Path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	report, err := resolver.Mutation().RunImport(ctx, body.Atomic, func(ctx context.Context) (*model.ImportReport, error) {
+		return resolver.Mutation().ImportKEVCatalog(ctx, path)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// curl -X POST http://localhost:8085/api/import/epss -H "Content-Type: application/json" -d '{"path": "epss_scores-2024-06-01.csv.gz"}'
+func importEPSSScoresHandler(c *gin.Context) {
+	var body importBody
+	if err := c.Bind(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	path, err := importFilePath(body.Path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	report, err := resolver.Mutation().RunImport(ctx, body.Atomic, func(ctx context.Context) (*model.ImportReport, error) {
+		return resolver.Mutation().ImportEPSSScores(ctx, path)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// curl -X POST http://localhost:8085/api/import/misp -H "Content-Type: application/json" -d '{"path": "misp.event.json"}'
+func importMISPEventsHandler(c *gin.Context) {
+	var body importBody
+	if err := c.Bind(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	path, err := importFilePath(body.Path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	report, err := resolver.Mutation().RunImport(ctx, body.Atomic, func(ctx context.Context) (*model.ImportReport, error) {
+		return resolver.Mutation().ImportMISPEvents(ctx, path)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"io"
+	"mongdbs/resolvers"
+	"mongdbs/util"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// curl -X GET "http://localhost:8085/api/indicators?value=hxxp://evil[.]com/gate.php" 查找提及该指标的全部知识条目
+func searchIndicatorHandler(c *gin.Context) {
+	value := c.Query("value")
+	if value == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "value must be provided"})
+		return
+	}
+	if _, ok := util.NormalizeIndicator(value); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "value is not a recognized indicator"})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	result, err := resolver.Query().SearchIndicator(ctx, value)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// 根据全部知识条目的 IoC 字段重建 indicators 集合
+func rebuildIndicatorsHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	status, err := resolver.Mutation().RebuildIndicators(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+var INDICATOR_MATCH_MAX_SIZE int64 = 100 << 20 // 批量匹配上传文本的大小上限
+
+// curl -X POST "http://localhost:8085/api/indicators/match?type=威胁组织" -F "file=@access.log"
+// 也可以直接粘贴文本：curl -X POST http://localhost:8085/api/indicators/match -H "Content-Type: text/plain" --data-binary @iocs.txt
+func matchIndicatorsHandler(c *gin.Context) {
+	typeArg := c.QueryArray("type")
+	if c.Request.ContentLength > INDICATOR_MATCH_MAX_SIZE {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "submitted text is too large"})
+		return
+	}
+
+	var reader io.Reader = c.Request.Body
+	if file, _, err := c.Request.FormFile("file"); err == nil {
+		defer file.Close()
+		reader = file
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	report, err := resolver.Query().MatchIndicators(ctx, io.LimitReader(reader, INDICATOR_MATCH_MAX_SIZE), typeArg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mongdbs/model"
+	"mongdbs/resolvers"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// kindError 提交内容不符合种类结构、schema 或词表返回 400，条目不存在返回 404，其他错误返回 500
+func kindError(c *gin.Context, err error) {
+	var invalid *model.SchemaValidationError
+	var outside *model.TaxonomyViolationError
+	switch {
+	case errors.As(err, &invalid), errors.As(err, &outside):
+		schemaError(c, err)
+	case errors.Is(err, resolvers.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err.Error() == "No document found with that ID":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// curl -X GET http://localhost:8085/api/kinds
+func listKnowledgeKindsHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	kinds, err := resolver.Query().KnowledgeKinds(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, kinds)
+}
+
+// curl -X GET "http://localhost:8085/api/kinds/vulnerability?nums=10"
+func listTypedKnowledgeHandler(c *gin.Context) {
+	nums, err := strconv.Atoi(c.DefaultQuery("nums", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid nums parameter"})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	results, err := resolver.Query().ListTypedKnowledge(ctx, c.Param("kind"), nums)
+	if err != nil {
+		kindError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// curl -X GET http://localhost:8085/api/kinds/threatActor/5
+// 条目不属于该种类时返回 404
+func typedKnowledgeHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	result, err := resolver.Query().TypedKnowledgeByID(ctx, c.Param("id"))
+	if err != nil {
+		kindError(c, err)
+		return
+	}
+	if result.KnowledgeKind() != c.Param("kind") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No document found with that ID"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// curl -X GET "http://localhost:8085/api/knowledge/typed?id=5"
+// 按 knowledgeType 判别种类返回，无法判别的条目返回原有的扁平结构
+func typedKnowledgeByIDHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	result, err := resolver.Query().TypedKnowledgeByID(ctx, c.Query("id"))
+	if err != nil {
+		kindError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// curl -X POST http://localhost:8085/api/kinds/vulnerability -H "Content-Type: application/json" -d '{"title": "...", "cve": "CVE-2021-44228", "cvssStr": "CVSS:3.1/..."}'
+// 只接受该种类的字段，knowledgeType 为空时使用种类的默认类型
+func createTypedKnowledgeHandler(c *gin.Context) {
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	result, err := resolver.Mutation().CreateTypedKnowledge(ctx, c.Param("kind"), data)
+	if err != nil {
+		kindError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// curl -X PUT http://localhost:8085/api/kinds/threatActor/5 -H "Content-Type: application/json" -d '{"alias": "APT28, Fancy Bear"}'
+// 只修改提交的字段
+func updateTypedKnowledgeHandler(c *gin.Context) {
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	result, err := resolver.Mutation().UpdateTypedKnowledge(ctx, c.Param("kind"), c.Param("id"), data)
+	if err != nil {
+		kindError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
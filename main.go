@@ -2,12 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"mongdbs/database"
 	"mongdbs/model"
 	"mongdbs/resolvers"
+	"mongdbs/util"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -33,6 +35,14 @@ var (
 func main() {
 	database.InitDB_docker()
 	// database.InitDB()
+
+	// ./api migrate status|up|down [version]
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrateCommand(os.Args[2:])
+		return
+	}
+	migrateOnStart()
+
 	r := gin.Default()
 
 	// 定义各个路由和对应的处理函数
@@ -51,6 +61,121 @@ func main() {
 	r.GET("/api/knowledge/keyword", searchByKeywordHandler)
 	r.GET("/api/knowledge/id", searchByIDHandler) // 新添加的通过ID查询路由
 	r.POST("/api/knowledge/batchEdit", batchEditKnowledgeTypeHandler)
+	r.POST("/api/knowledge/batch", batchKnowledgeHandler)        // 通用批量操作，支持 dryRun 预览
+	r.POST("/api/knowledge/derive", refreshDerivedFieldsHandler) // 重新计算 cvssInfo 等派生字段
+
+	// 威胁组织相关路由
+	r.GET("/api/actors/compare", compareThreatActorsHandler)
+
+	// 别名注册表相关路由
+	r.GET("/api/aliases", listAliasesHandler)
+	r.GET("/api/aliases/resolve", resolveAliasHandler)
+	r.POST("/api/aliases", createAliasHandler)
+	r.POST("/api/aliases/rebuild", rebuildAliasRegistryHandler)
+	r.DELETE("/api/aliases/:id", deleteAliasHandler)
+
+	// IoC 指标相关路由
+	r.GET("/api/indicators", searchIndicatorHandler)
+	r.POST("/api/indicators/match", matchIndicatorsHandler) // 上传日志或指标列表批量匹配
+	r.POST("/api/indicators/rebuild", rebuildIndicatorsHandler)
+
+	// 本地数据文件导入相关路由，文件需放在 IMPORT_FOLDER 下
+	r.POST("/api/import/nvd", importNVDFeedHandler)
+	r.POST("/api/import/cwe", importCWECatalogHandler)
+	r.POST("/api/import/capec", importCAPECCatalogHandler)
+	r.POST("/api/import/kev", importKEVCatalogHandler)
+	r.POST("/api/import/epss", importEPSSScoresHandler)
+	r.POST("/api/import/misp", importMISPEventsHandler)
+
+	// MISP 导出
+	r.GET("/api/misp/export", exportMISPHandler)
+
+	// 检测规则
+	r.GET("/api/detections", listDetectionRulesHandler)
+	r.GET("/api/detections/export", exportDetectionRulesHandler)
+	r.POST("/api/detections", createDetectionRuleHandler)
+	r.POST("/api/detections/validate", validateDetectionRuleHandler)
+	r.POST("/api/detections/test", testSigmaRulesHandler)
+	r.PUT("/api/detections/:id", updateDetectionRuleHandler)
+	r.DELETE("/api/detections/:id", deleteDetectionRuleHandler)
+
+	// TAXII 2.1 服务，客户端和集合定义通过 /api/taxii 管理
+	r.GET("/api/taxii/clients", listTAXIIClientsHandler)
+	r.POST("/api/taxii/clients", createTAXIIClientHandler)
+	r.DELETE("/api/taxii/clients/:id", deleteTAXIIClientHandler)
+	r.GET("/api/taxii/collections", listTAXIICollectionsHandler)
+	r.POST("/api/taxii/collections", createTAXIICollectionHandler)
+	r.DELETE("/api/taxii/collections/:id", deleteTAXIICollectionHandler)
+
+	taxii := r.Group("/taxii2", taxiiAuth)
+	taxii.GET("/", taxiiDiscoveryHandler)
+	taxii.GET("/api/", taxiiAPIRootHandler)
+	taxii.GET("/api/collections/", taxiiCollectionsHandler)
+	taxii.GET("/api/collections/:id/", taxiiCollectionHandler)
+	taxii.GET("/api/collections/:id/objects/", taxiiObjectsHandler)
+
+	// CWE/CAPEC 关联查询
+	r.GET("/api/knowledge/weakness", knowledgeWeaknessHandler)
+	r.GET("/api/cwe/knowledge", searchByCWEHandler)
+
+	// 受影响产品(CPE)查询
+	r.GET("/api/knowledge/affected", searchByAffectedProductHandler)
+	r.POST("/api/knowledge/affected/migrate", migrateAffectedProductsHandler) // 从 vendor/products/affectedVerison 文本生成结构化范围
+	r.POST("/api/sbom/match", matchSBOMHandler)                               // 上传 CycloneDX/SPDX JSON，返回组件命中的漏洞
+
+	// 活跃时间和时间线，日期由 firstActivity、latestActivity、timeLine 解析
+	r.GET("/api/knowledge/active", searchByActivityHandler)
+	r.GET("/api/knowledge/timeline", knowledgeTimelineHandler)
+
+	// 按种类(漏洞、威胁组织、攻击技术等)录入和查询，种类由 knowledgeType 判别
+	r.GET("/api/kinds", listKnowledgeKindsHandler)
+	r.GET("/api/kinds/:kind", listTypedKnowledgeHandler)
+	r.POST("/api/kinds/:kind", createTypedKnowledgeHandler)
+	r.GET("/api/kinds/:kind/:id", typedKnowledgeHandler)
+	r.PUT("/api/kinds/:kind/:id", updateTypedKnowledgeHandler)
+	r.GET("/api/knowledge/typed", typedKnowledgeByIDHandler)
+
+	// 按 knowledgeType 定义的 JSON Schema，创建、修改、批量修改类型时校验
+	r.GET("/api/schemas", listKnowledgeSchemasHandler)
+	r.GET("/api/schemas/:type", knowledgeSchemaHandler)
+	r.PUT("/api/schemas/:type", saveKnowledgeSchemaHandler)
+	r.DELETE("/api/schemas/:type", deleteKnowledgeSchemaHandler)
+	r.POST("/api/schemas/:type/dryrun", dryRunKnowledgeSchemaHandler)
+
+	// knowledgeType、tags、knowledgeSource 的受控词表，写入时规范化为词条名称
+	r.GET("/api/taxonomy", taxonomyHandler)
+	r.PUT("/api/taxonomy/settings", setTaxonomyStrictHandler)
+	r.POST("/api/taxonomy/normalize", normalizeTaxonomyHandler)
+	r.POST("/api/taxonomy/terms", createTaxonomyTermHandler)
+	r.PUT("/api/taxonomy/terms/:id", updateTaxonomyTermHandler)
+	r.DELETE("/api/taxonomy/terms/:id", deleteTaxonomyTermHandler)
+	r.POST("/api/taxonomy/terms/:id/rename", renameTaxonomyTermHandler)
+	r.POST("/api/taxonomy/terms/:id/merge", mergeTaxonomyTermHandler)
+
+	// 疑似重复条目的检测、审核和合并
+	r.POST("/api/duplicates/detect", detectDuplicatesHandler)
+	r.GET("/api/duplicates", duplicateCandidatesHandler)
+	r.POST("/api/duplicates/:id/dismiss", dismissDuplicateHandler)
+	r.POST("/api/knowledge/merge", mergeKnowledgeHandler)
+	r.GET("/api/knowledge/merges", knowledgeMergesHandler)
+
+	// 知识条目之间的关系
+	r.GET("/api/relationships", listRelationshipsHandler)
+	r.POST("/api/relationships", createRelationshipHandler)
+	r.PUT("/api/relationships/:id", updateRelationshipHandler)
+	r.DELETE("/api/relationships/:id", deleteRelationshipHandler)
+	r.GET("/api/knowledge/related", relatedKnowledgeHandler)
+	r.GET("/api/knowledge/graph", knowledgeGraphHandler)
+	r.POST("/api/knowledge/graph", traverseKnowledgeGraphHandler)
+
+	// 数据质量检查
+	r.GET("/api/quality/rules", qualityRulesHandler)
+	r.POST("/api/quality/scan", scanKnowledgeQualityHandler)
+
+	// 数据结构迁移，执行记录保存在 _migrations 集合
+	r.GET("/api/migrations", migrationStatusHandler)
+	r.POST("/api/migrations/up", migrateUpHandler)
+	r.POST("/api/migrations/down", migrateDownHandler)
 
 	// 图片处理相关路由
 	r.POST("/api/images", UploadImageHandler)
@@ -310,11 +435,26 @@ func copyFile(src, dst string) error {
 	return err
 }
 
+// 对已有知识回填派生字段
+func refreshDerivedFieldsHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	status, err := resolver.Mutation().RefreshDerivedFields(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// atomic 为 true 时任一条目失败则全部回滚，需要 MongoDB 以副本集方式运行
 func batchEditKnowledgeTypeHandler(c *gin.Context) {
 	var req struct {
 		IDList   []string `json:"idList"`
 		PrevType string   `json:"prevType"`
 		RepType  string   `json:"repType"`
+		Atomic   bool     `json:"atomic"`
 	}
 
 	if err := c.BindJSON(&req); err != nil {
@@ -324,7 +464,7 @@ func batchEditKnowledgeTypeHandler(c *gin.Context) {
 
 	ctx := context.Background()
 	resolver := resolvers.Resolver{}
-	status, err := resolver.Mutation().BatchEditKnowledgeType(ctx, req.IDList, req.PrevType, req.RepType)
+	status, err := resolver.Mutation().BatchEditKnowledgeType(ctx, req.IDList, req.PrevType, req.RepType, req.Atomic)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -345,7 +485,7 @@ func createKnowledgeHandler(c *gin.Context) {
 	resolver := resolvers.Resolver{}
 	createdKnowledge, err := resolver.Mutation().CreateKnowledge(ctx, knowledge)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		schemaError(c, err)
 		return
 	}
 	fmt.Println("success")
@@ -367,7 +507,7 @@ func updateKnowledgeHandler(c *gin.Context) {
 	resolver := resolvers.Resolver{}
 	updatedKnowledge, err := resolver.Mutation().UpdateKnowledge(ctx, id, knowledge)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		schemaError(c, err)
 		return
 	}
 
@@ -450,7 +590,7 @@ func mitreByTechniquesIDHandler(c *gin.Context) {
 	cleanedTechniquesID := make([]string, len(techniquesID))
 
 	for i, id := range techniquesID {
-		cleanedTechniquesID[i] = cleanString(id)
+		cleanedTechniquesID[i] = util.CleanString(id)
 		fmt.Printf("Cleaned TechniquesId: '%s'\n", cleanedTechniquesID[i])
 	}
 
@@ -488,8 +628,17 @@ func mitreBySubTechniquesIDHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, results)
 }
 
-// 组合查询方法 目前没问题
-func searchHandler(c *gin.Context) {
+// searchQuery 组合查询的参数，searchHandler 和 MISP 导出共用
+type searchQuery struct {
+	where    model.KnowledgeFilter
+	keyword  []string
+	authors  []string
+	nums     int
+	nodedict string
+}
+
+// parseSearchQuery 解析组合查询的参数
+func parseSearchQuery(c *gin.Context) (*searchQuery, error) {
 	var where model.KnowledgeFilter
 	where.Tags = c.QueryArray("tags") // 预过滤在这些匹配项中寻找 keyword相匹配的关键项目
 
@@ -507,21 +656,86 @@ func searchHandler(c *gin.Context) {
 	where.TechniquesID = c.QueryArray("techniquesId")
 	where.SubTechniquesID = c.QueryArray("subTechniquesId")
 
-	authors := c.QueryArray("author")
-	keyword := c.QueryArray("keyword")
-	nodedict := c.Query("nodedict")
-	fmt.Printf("nodedict: %v\n", nodedict)
+	// CVSS 数值范围过滤，例如 cvssMin=9 查询 CVSS ≥ 9 的条目
+	if v := c.Query("cvssMin"); v != "" {
+		score, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, errors.New("invalid cvssMin parameter")
+		}
+		where.CvssMin = &score
+	}
+	if v := c.Query("cvssMax"); v != "" {
+		score, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, errors.New("invalid cvssMax parameter")
+		}
+		where.CvssMax = &score
+	}
+	for _, severity := range c.QueryArray("severity") {
+		if severity != "" {
+			where.Severity = append(where.Severity, strings.ToUpper(severity[:1])+strings.ToLower(severity[1:]))
+		}
+	}
+	if v := c.Query("cvssMismatch"); v != "" {
+		mismatch, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, errors.New("invalid cvssMismatch parameter")
+		}
+		where.CvssMismatch = &mismatch
+	}
+
+	// 利用情况过滤，例如 exploited=true&sort=epss 按 EPSS 概率列出在野利用的漏洞
+	if v := c.Query("exploited"); v != "" {
+		exploited, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, errors.New("invalid exploited parameter")
+		}
+		where.KnownExploited = &exploited
+	}
+	if v := c.Query("exploitAvailable"); v != "" {
+		available, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, errors.New("invalid exploitAvailable parameter")
+		}
+		where.ExploitAvailable = &available
+	}
+	if v := c.Query("epssMin"); v != "" {
+		score, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, errors.New("invalid epssMin parameter")
+		}
+		where.EpssMin = &score
+	}
+	where.SortBy = c.Query("sort")
+
 	numsStr := c.DefaultQuery("nums", "0")
 	nums, err := strconv.Atoi(numsStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid nums parameter"})
+		return nil, errors.New("invalid nums parameter")
+	}
+
+	return &searchQuery{
+		where:    where,
+		keyword:  c.QueryArray("keyword"),
+		authors:  c.QueryArray("author"),
+		nums:     nums,
+		nodedict: c.Query("nodedict"),
+	}, nil
+}
+
+// 组合查询方法 目前没问题
+func searchHandler(c *gin.Context) {
+	q, err := parseSearchQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	log.Printf("Searching with tags: %v, keyword: %v, nums: %d", where.Tags, keyword, nums)
+	fmt.Printf("nodedict: %v\n", q.nodedict)
+	log.Printf("Searching with tags: %v, keyword: %v, nums: %d", q.where.Tags, q.keyword, q.nums)
 
 	ctx := context.Background()
 	resolver := resolvers.Resolver{}
-	results, err := resolver.Query().Search(ctx, &where, keyword, authors, nums, nodedict)
+	results, err := resolver.Query().Search(ctx, &q.where, q.keyword, q.authors, q.nums, q.nodedict)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -637,12 +851,3 @@ func searchByIDHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, results)
 }
-
-func cleanString(input string) string {
-	// 去除所有不可见字符
-	cleaned := strings.ReplaceAll(input, "\u200B", "")
-	cleaned = strings.ReplaceAll(cleaned, "\u200C", "")
-	cleaned = strings.ReplaceAll(cleaned, "\u200D", "")
-	cleaned = strings.ReplaceAll(cleaned, "\uFEFF", "")
-	return cleaned
-}
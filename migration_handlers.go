@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mongdbs/model"
+	"mongdbs/resolvers"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// migrateOnStart 启动时执行到最新版本，设置 MIGRATE_ON_START=false 时跳过，由部署流程通过命令行执行
+func migrateOnStart() {
+	if os.Getenv("MIGRATE_ON_START") == "false" {
+		return
+	}
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	if _, err := resolver.Mutation().MigrateUp(ctx, 0); err != nil {
+		if errors.Is(err, resolvers.ErrMigrationLocked) {
+			log.Println("Skipping migrations:", err)
+			return
+		}
+		log.Fatal("Failed to run migrations: ", err)
+	}
+}
+
+// migrateCommand 命令行执行迁移：
+//
+//	./api migrate status
+//	./api migrate up [version]    执行到指定版本，默认最新
+//	./api migrate down [version]  撤销到指定版本，默认只撤销最后一个
+func migrateCommand(args []string) {
+	to := -1
+	if len(args) > 1 {
+		var err error
+		if to, err = strconv.Atoi(args[1]); err != nil || to < 0 {
+			log.Fatalf("invalid version %q", args[1])
+		}
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	var result interface{}
+	var err error
+	switch {
+	case len(args) == 0 || args[0] == "status":
+		result, err = resolver.Query().MigrationStatus(ctx)
+	case args[0] == "up":
+		if to < 0 {
+			to = 0
+		}
+		result, err = resolver.Mutation().MigrateUp(ctx, to)
+	case args[0] == "down":
+		result, err = resolver.Mutation().MigrateDown(ctx, to)
+	default:
+		log.Fatalf("unknown migrate command %q, expected status, up or down", args[0])
+	}
+
+	if out, jsonErr := json.MarshalIndent(result, "", "  "); jsonErr == nil && string(out) != "null" {
+		fmt.Println(string(out))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// curl -X GET http://localhost:8085/api/migrations
+func migrationStatusHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	status, err := resolver.Query().MigrationStatus(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// curl -X POST "http://localhost:8085/api/migrations/up?to=1"
+// 不指定 to 时执行到最新版本
+func migrateUpHandler(c *gin.Context) {
+	to, err := strconv.Atoi(c.DefaultQuery("to", "0"))
+	if err != nil || to < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to parameter"})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	run, err := resolver.Mutation().MigrateUp(ctx, to)
+	migrationResponse(c, run, err)
+}
+
+// curl -X POST "http://localhost:8085/api/migrations/down?to=0"
+// 不指定 to 时只撤销最后一个迁移
+func migrateDownHandler(c *gin.Context) {
+	to, err := strconv.Atoi(c.DefaultQuery("to", "-1"))
+	if err != nil || to < -1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to parameter"})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	run, err := resolver.Mutation().MigrateDown(ctx, to)
+	migrationResponse(c, run, err)
+}
+
+// migrationResponse 迁移中途出错时同时返回已执行的部分
+func migrationResponse(c *gin.Context, run *model.MigrationRun, err error) {
+	switch {
+	case errors.Is(err, resolvers.ErrMigrationLocked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil && run != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "run": run})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, run)
+	}
+}
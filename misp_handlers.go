@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"mongdbs/resolvers"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// curl -X GET "http://localhost:8085/api/misp/export?id=5" 导出单个知识条目为 MISP 事件
+// curl -X GET "http://localhost:8085/api/misp/export?keyword=APT29&knowledgeType=威胁组织" 参数与 /api/knowledge/search 相同，导出为 restSearch 格式
+func exportMISPHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+
+	if id := c.Query("id"); id != "" {
+		event, err := resolver.Query().ExportMISPEvent(ctx, id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, event)
+		return
+	}
+
+	q, err := parseSearchQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	response, err := resolver.Query().ExportMISPSearch(ctx, &q.where, q.keyword, q.authors, q.nums, q.nodedict)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
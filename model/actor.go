@@ -0,0 +1,38 @@
+package model
+
+// ActorAttributes 威胁组织可比较的属性集合
+type ActorAttributes struct {
+	Techniques  []string `json:"techniques"`
+	Tools       []string `json:"tools"`
+	Industries  []string `json:"industries"`
+	Geographies []string `json:"geographies"`
+}
+
+// ActorProfile 参与比较的威胁组织
+type ActorProfile struct {
+	ID          string          `json:"id"`
+	Title       string          `json:"title"`
+	Alias       string          `json:"alias"`
+	Affiliation string          `json:"affiliation"`
+	Attributes  ActorAttributes `json:"attributes"`
+}
+
+// ActorSimilarity 两个威胁组织之间的 Jaccard 相似度
+type ActorSimilarity struct {
+	Source      string  `json:"source"`
+	Target      string  `json:"target"`
+	Techniques  float64 `json:"techniques"`
+	Tools       float64 `json:"tools"`
+	Industries  float64 `json:"industries"`
+	Geographies float64 `json:"geographies"`
+	Overall     float64 `json:"overall"`
+}
+
+// ActorComparison 多个威胁组织的技战术重叠比较结果
+type ActorComparison struct {
+	Actors     []*ActorProfile            `json:"actors"`
+	Shared     ActorAttributes            `json:"shared"`
+	Unique     map[string]ActorAttributes `json:"unique"`
+	Similarity []*ActorSimilarity         `json:"similarity"`
+	Overall    float64                    `json:"overall"`
+}
@@ -0,0 +1,35 @@
+package model
+
+// 别名来源
+const (
+	AliasSourceField  = "field"  // 来自知识条目的 alias 字段
+	AliasSourceTitle  = "title"  // 来自知识条目的标题
+	AliasSourceManual = "manual" // 通过接口手动维护
+)
+
+// Alias 别名注册表中的一条记录，Key 为规范化后的名称用于匹配
+type Alias struct {
+	ID          string `bson:"_id,omitempty" json:"id"`
+	Key         string `bson:"key" json:"key"`
+	Name        string `bson:"name" json:"name"`
+	KnowledgeID string `bson:"knowledgeId" json:"knowledgeId"`
+	Source      string `bson:"source" json:"source"`
+}
+
+type NewAlias struct {
+	Name        string `json:"name"`
+	KnowledgeID string `json:"knowledgeId"`
+}
+
+// AliasResolution 名称解析结果，指向规范的知识条目
+type AliasResolution struct {
+	Name         string     `json:"name"`
+	MatchedAlias string     `json:"matchedAlias"`
+	Knowledge    *Knowledge `json:"knowledge"`
+	Aliases      []string   `json:"aliases"`
+}
+
+type AliasRebuildStatus struct {
+	Knowledge int `json:"knowledge"`
+	Aliases   int `json:"aliases"`
+}
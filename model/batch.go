@@ -0,0 +1,66 @@
+package model
+
+// 批量操作类型
+const (
+	BatchSet             = "set"             // 设置字段值
+	BatchUnset           = "unset"           // 清空字段
+	BatchAdd             = "add"             // 向数组字段添加值
+	BatchRemove          = "remove"          // 从数组字段删除值
+	BatchDelete          = "delete"          // 删除知识条目
+	BatchConfidentiality = "confidentiality" // 修改密级
+)
+
+// 单个条目的处理结果
+const (
+	BatchUpdated   = "updated"
+	BatchDeleted   = "deleted"
+	BatchUnchanged = "unchanged"
+	BatchFailed    = "failed"
+)
+
+// BatchOperation 一项批量操作，Value 用于 set、confidentiality，Values 用于 add、remove
+type BatchOperation struct {
+	Op     string      `json:"op"`
+	Field  string      `json:"field,omitempty"`
+	Value  interface{} `json:"value,omitempty"`
+	Values []string    `json:"values,omitempty"`
+}
+
+// BatchRequest 按 ID 列表或查询条件选择知识条目，依次应用全部操作。DryRun 时只返回预览，不写入；
+// Atomic 时在事务中执行，任一条目失败则全部回滚
+type BatchRequest struct {
+	IDs        []string          `json:"ids"`
+	Filter     *KnowledgeFilter  `json:"filter"`
+	Operations []*BatchOperation `json:"operations"`
+	DryRun     bool              `json:"dryRun"`
+	Atomic     bool              `json:"atomic"`
+}
+
+// BatchChange 字段修改前后的值
+type BatchChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// BatchResult 单个知识条目的处理结果
+type BatchResult struct {
+	ID      string         `json:"id"`
+	Title   string         `json:"title,omitempty"`
+	Status  string         `json:"status"`
+	Changes []*BatchChange `json:"changes,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// BatchReport 批量操作的结果汇总
+type BatchReport struct {
+	DryRun    bool           `json:"dryRun"`
+	Matched   int            `json:"matched"`
+	Updated   int            `json:"updated"`
+	Deleted   int            `json:"deleted"`
+	Unchanged int            `json:"unchanged"`
+	Failed    int            `json:"failed"`
+	Results   []*BatchResult `json:"results"`
+	// RolledBack 有条目失败，全部修改已回滚，updated、deleted 表示回滚前的结果
+	RolledBack bool `json:"rolledBack,omitempty"`
+}
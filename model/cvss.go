@@ -0,0 +1,14 @@
+package model
+
+// CvssInfo 由 cvssStr 解析出的结构化 CVSS 信息，创建和更新知识时自动计算
+type CvssInfo struct {
+	Version       string   `bson:"version,omitempty" json:"version"`
+	Vector        string   `bson:"vector,omitempty" json:"vector"`
+	BaseScore     float64  `bson:"baseScore" json:"baseScore"`
+	Severity      string   `bson:"severity,omitempty" json:"severity"`
+	Computed      bool     `bson:"computed" json:"computed"`                               // 分值是否由向量计算得出，否则取自录入的 cvss
+	MacroVector   string   `bson:"macroVector,omitempty" json:"macroVector,omitempty"`     // 仅 CVSS 4.0
+	SuppliedScore *float64 `bson:"suppliedScore,omitempty" json:"suppliedScore,omitempty"` // 录入的 cvss 分值
+	Mismatch      bool     `bson:"mismatch" json:"mismatch"`                               // 录入的分值与向量计算结果不一致
+	Error         string   `bson:"error,omitempty" json:"error,omitempty"`                 // 向量解析错误
+}
@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// DetectionRule detection_rules 集合中的一条检测规则，关联到知识条目
+type DetectionRule struct {
+	ID          string    `bson:"_id,omitempty" json:"id"`
+	KnowledgeID string    `bson:"knowledgeId" json:"knowledgeId"`
+	Type        string    `bson:"type" json:"type"`
+	Name        string    `bson:"name" json:"name"`
+	Rule        string    `bson:"rule" json:"rule"`
+	Techniques  []string  `bson:"techniques,omitempty" json:"techniques"`         // 规则中声明的 ATT&CK 技术
+	Unmatched   []string  `bson:"unmatched,omitempty" json:"unmatched,omitempty"` // 声明了但知识条目 techniquesId 中没有的技术
+	Created     time.Time `bson:"created" json:"created"`
+	Modified    time.Time `bson:"modified" json:"modified"`
+}
+
+type NewDetectionRule struct {
+	KnowledgeID string `json:"knowledgeId"`
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Rule        string `json:"rule"`
+}
+
+type UpdateDetectionRule struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	Rule string `json:"rule"`
+}
+
+// RuleValidationError 规则语法校验失败，Errors 为全部问题
+type RuleValidationError struct {
+	Type   string   `json:"type"`
+	Errors []string `json:"errors"`
+}
+
+func (e *RuleValidationError) Error() string {
+	return "invalid " + e.Type + " rule: " + e.Errors[0]
+}
+
+// SigmaRuleResult 一条 Sigma 规则对样本事件的测试结果
+type SigmaRuleResult struct {
+	RuleID      string `json:"ruleId"`
+	KnowledgeID string `json:"knowledgeId"`
+	Name        string `json:"name"`
+	Matched     int    `json:"matched"`
+	Lines       []int  `json:"lines"`           // 命中的事件所在行号
+	Error       string `json:"error,omitempty"` // 规则无法求值的原因
+}
+
+// SigmaTestReport 样本事件测试报告，InvalidLines 为无法解析为 JSON 对象的行
+type SigmaTestReport struct {
+	Events       int                `json:"events"`
+	InvalidLines []int              `json:"invalidLines"`
+	Rules        int                `json:"rules"`
+	MatchedRules int                `json:"matchedRules"`
+	Results      []*SigmaRuleResult `json:"results"`
+}
@@ -0,0 +1,85 @@
+package model
+
+import "time"
+
+// 疑似重复条目的审核状态
+const (
+	DuplicatePending   = "pending"
+	DuplicateMerged    = "merged"
+	DuplicateDismissed = "dismissed"
+)
+
+// 疑似重复的依据
+const (
+	DuplicateByCve        = "cve"
+	DuplicateByCnvd       = "cnvd"
+	DuplicateByTechniques = "techniquesId"
+	DuplicateByText       = "text"
+)
+
+// DuplicateReason 判断为疑似重复的一项依据，Value 为共同的编号，文本相似时为空
+type DuplicateReason struct {
+	Type       string  `bson:"type" json:"type"`
+	Value      string  `bson:"value,omitempty" json:"value,omitempty"`
+	Similarity float64 `bson:"similarity,omitempty" json:"similarity,omitempty"`
+}
+
+// DuplicateCandidate 审核队列中的一对疑似重复条目，ID 为两个条目 ID 按顺序用 | 连接
+type DuplicateCandidate struct {
+	ID         string             `bson:"_id" json:"id"`
+	IDs        []string           `bson:"ids" json:"ids"`
+	Titles     []string           `bson:"titles" json:"titles"`
+	Reasons    []*DuplicateReason `bson:"reasons" json:"reasons"`
+	Similarity float64            `bson:"similarity" json:"similarity"`
+	Status     string             `bson:"status" json:"status"`
+	Detected   time.Time          `bson:"detected" json:"detected"`
+	Reviewed   *time.Time         `bson:"reviewed,omitempty" json:"reviewed,omitempty"`
+}
+
+// DuplicateDetectStatus 一次重复检测的结果
+type DuplicateDetectStatus struct {
+	Scanned    int `json:"scanned"`
+	Candidates int `json:"candidates"` // 本次检测到的疑似重复对数
+	New        int `json:"new"`        // 其中首次出现的
+	Pending    int `json:"pending"`    // 检测后待审核的对数
+}
+
+// 合并时字段的取值方式
+const (
+	MergeWinner = "winner" // 使用保留条目的值
+	MergeLoser  = "loser"  // 使用被合并条目的值
+	MergeUnion  = "union"  // 列表字段取并集，文本字段保留条目的值在前，两者不同时拼接
+)
+
+// MergeRequest 将 Loser 合并到 Winner。Fields 指定字段的取值方式，未指定的字段取保留条目的值，
+// 保留条目的值为空时取被合并条目的值，列表字段取并集
+type MergeRequest struct {
+	Winner string            `json:"winner"`
+	Loser  string            `json:"loser"`
+	Fields map[string]string `json:"fields"`
+	Atomic bool              `json:"atomic"`
+}
+
+// KnowledgeMerge 合并记录，保存合并前两个条目的完整内容
+type KnowledgeMerge struct {
+	ID     string                 `bson:"_id" json:"id"`
+	Winner string                 `bson:"winner" json:"winner"`
+	Loser  string                 `bson:"loser" json:"loser"`
+	Fields map[string]string      `bson:"fields" json:"fields"` // 每个字段实际的取值方式
+	Before map[string]interface{} `bson:"before" json:"before"` // 合并前的保留条目
+	Merged map[string]interface{} `bson:"merged" json:"merged"` // 被合并的条目
+	Time   time.Time              `bson:"time" json:"time"`
+}
+
+// KnowledgeRedirect 被合并条目的 ID 指向保留条目
+type KnowledgeRedirect struct {
+	ID     string    `bson:"_id" json:"id"`
+	Target string    `bson:"target" json:"target"`
+	Merged time.Time `bson:"merged" json:"merged"`
+}
+
+// MergeResult 合并后的条目和合并记录
+type MergeResult struct {
+	Knowledge *Knowledge      `json:"knowledge"`
+	Merge     *KnowledgeMerge `json:"merge"`
+}
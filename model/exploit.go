@@ -0,0 +1,38 @@
+package model
+
+// ExploitInfo 由 isExp、msf、exploitdb 解析出的利用信息，创建和更新知识时自动计算
+type ExploitInfo struct {
+	Available    bool     `bson:"available" json:"available"` // isExp 为是，或记录了 MSF 模块、Exploit-DB 编号
+	MsfModules   []string `bson:"msfModules,omitempty" json:"msfModules,omitempty"`
+	ExploitDBIDs []string `bson:"exploitDbIds,omitempty" json:"exploitDbIds,omitempty"`
+}
+
+// KEVEntry CISA 已知被利用漏洞(KEV)目录中的记录
+type KEVEntry struct {
+	CveID                      string `bson:"cveId" json:"cveID"`
+	VendorProject              string `bson:"vendorProject,omitempty" json:"vendorProject"`
+	Product                    string `bson:"product,omitempty" json:"product"`
+	VulnerabilityName          string `bson:"vulnerabilityName,omitempty" json:"vulnerabilityName"`
+	DateAdded                  string `bson:"dateAdded,omitempty" json:"dateAdded"`
+	ShortDescription           string `bson:"shortDescription,omitempty" json:"shortDescription"`
+	RequiredAction             string `bson:"requiredAction,omitempty" json:"requiredAction"`
+	DueDate                    string `bson:"dueDate,omitempty" json:"dueDate"`
+	KnownRansomwareCampaignUse string `bson:"knownRansomwareCampaignUse,omitempty" json:"knownRansomwareCampaignUse"`
+	Notes                      string `bson:"notes,omitempty" json:"notes"`
+}
+
+// KEVCatalog CISA KEV JSON 文件(known_exploited_vulnerabilities.json)
+type KEVCatalog struct {
+	Title           string     `json:"title"`
+	CatalogVersion  string     `json:"catalogVersion"`
+	DateReleased    string     `json:"dateReleased"`
+	Count           int        `json:"count"`
+	Vulnerabilities []KEVEntry `json:"vulnerabilities"`
+}
+
+// EPSSScore FIRST EPSS 漏洞利用预测分值
+type EPSSScore struct {
+	Score      float64 `bson:"score" json:"score"`           // 30 天内被利用的概率
+	Percentile float64 `bson:"percentile" json:"percentile"` // 在全部 CVE 中的百分位
+	Date       string  `bson:"date,omitempty" json:"date,omitempty"`
+}
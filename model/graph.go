@@ -0,0 +1,51 @@
+package model
+
+// GraphHop 一跳扩展：沿指定关系从上一层节点走到下一层节点。Types 为空时不限类型，
+// 可以使用反向名称(如 used-by)；Direction 为 outgoing、incoming 或 both(默认)；
+// Filter 限定下一层节点，不满足条件的节点和对应的边不出现在结果中
+type GraphHop struct {
+	Types     []string         `json:"types"`
+	Direction string           `json:"direction"`
+	Filter    *KnowledgeFilter `json:"filter"`
+}
+
+// GraphQuery 从 StartIDs 或满足 Start 条件的节点出发依次按 Hops 扩展。
+// Hops 为空时按 Types、Direction、Filter 重复扩展 Depth 层。
+// 指定 Hops 时默认只保留走完全部 Hops 的路径，Partial 为 true 时保留中途停止的路径
+type GraphQuery struct {
+	StartIDs  []string         `json:"startIds"`
+	Start     *KnowledgeFilter `json:"start"`
+	Hops      []*GraphHop      `json:"hops"`
+	Types     []string         `json:"types"`
+	Direction string           `json:"direction"`
+	Filter    *KnowledgeFilter `json:"filter"`
+	Depth     int              `json:"depth"`
+	Partial   bool             `json:"partial"`
+	Limit     int              `json:"limit"` // 节点数上限，为 0 时使用默认值
+}
+
+// GraphNode 图中的一个知识条目，Depth 为首次到达时距起点的跳数，起点为 0
+type GraphNode struct {
+	ID            string   `json:"id"`
+	Label         string   `json:"label"`
+	KnowledgeType []string `json:"knowledgeType"`
+	Depth         int      `json:"depth"`
+}
+
+// GraphEdge 图中的一条关系，Source、Target 为关系本身的方向，与遍历方向无关
+type GraphEdge struct {
+	ID          string `json:"id"`
+	Source      string `json:"source"`
+	Target      string `json:"target"`
+	Type        string `json:"type"`
+	Label       string `json:"label"`
+	Description string `json:"description,omitempty"`
+	Hop         int    `json:"hop"` // 第几跳经过这条边，从 1 开始
+}
+
+// GraphResult 节点和边的列表，前端图组件可以直接使用。节点数达到上限时 Truncated 为 true
+type GraphResult struct {
+	Nodes     []*GraphNode `json:"nodes"`
+	Edges     []*GraphEdge `json:"edges"`
+	Truncated bool         `json:"truncated"`
+}
@@ -0,0 +1,53 @@
+package model
+
+// 导入结果中单条记录的处理动作
+const (
+	ImportCreated   = "created"
+	ImportUpdated   = "updated"
+	ImportUnchanged = "unchanged"
+	ImportSkipped   = "skipped"
+	ImportFailed    = "failed"
+)
+
+// ImportItem 导入文件中一条记录的处理结果
+type ImportItem struct {
+	Key         string   `json:"key"`
+	KnowledgeID string   `json:"knowledgeId,omitempty"`
+	Action      string   `json:"action"`
+	Changes     []string `json:"changes,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// ImportReport 本地数据文件导入报告
+type ImportReport struct {
+	Success   bool          `json:"success"`
+	Source    string        `json:"source"`
+	Total     int           `json:"total"`
+	Created   int           `json:"created"`
+	Updated   int           `json:"updated"`
+	Unchanged int           `json:"unchanged"`
+	Skipped   int           `json:"skipped"`
+	Failed    int           `json:"failed"`
+	Items     []*ImportItem `json:"items"`
+	// RolledBack 整体导入时有条目失败，全部修改已回滚，计数为回滚前的处理结果
+	RolledBack bool `json:"rolledBack,omitempty"`
+}
+
+// Add 记录一条处理结果并更新计数
+func (r *ImportReport) Add(item *ImportItem) {
+	r.Total++
+	switch item.Action {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	case ImportUnchanged:
+		r.Unchanged++
+	case ImportSkipped:
+		r.Skipped++
+	case ImportFailed:
+		r.Failed++
+	}
+	r.Items = append(r.Items, item)
+	r.Success = r.Failed == 0
+}
@@ -0,0 +1,51 @@
+package model
+
+// Indicator indicators 集合中的一条记录，由知识条目 IoC 字段提取并规范化
+type Indicator struct {
+	ID          string `bson:"_id,omitempty" json:"id"`
+	Type        string `bson:"type" json:"type"`
+	Value       string `bson:"value" json:"value"`
+	KnowledgeID string `bson:"knowledgeId" json:"knowledgeId"`
+}
+
+// IndicatorMatch 提及某个指标的知识条目，以及其中命中的指标(如包含查询 IP 的网段)
+type IndicatorMatch struct {
+	Knowledge  *Knowledge   `json:"knowledge"`
+	Indicators []*Indicator `json:"indicators"`
+}
+
+// IndicatorSearchResult 指标查询结果，Value 为规范化之后的值
+type IndicatorSearchResult struct {
+	Query   string            `json:"query"`
+	Type    string            `json:"type"`
+	Value   string            `json:"value"`
+	Matches []*IndicatorMatch `json:"matches"`
+}
+
+type IndicatorRebuildStatus struct {
+	Knowledge  int `json:"knowledge"`
+	Indicators int `json:"indicators"`
+}
+
+// IndicatorLine 提交的文本中命中指标的一行
+type IndicatorLine struct {
+	Number int    `json:"number"`
+	Text   string `json:"text"`
+}
+
+// BulkIndicatorMatch 批量匹配中命中同一知识条目的指标和所在行
+type BulkIndicatorMatch struct {
+	Knowledge  *Knowledge       `json:"knowledge"`
+	Count      int              `json:"count"` // 命中的不同指标数
+	Indicators []*Indicator     `json:"indicators"`
+	Lines      []*IndicatorLine `json:"lines"`
+	Truncated  bool             `json:"truncated,omitempty"` // 命中行过多，只返回了前面一部分
+}
+
+// BulkIndicatorReport 批量指标匹配报告，按命中指标数从多到少排列
+type BulkIndicatorReport struct {
+	Lines      int                   `json:"lines"`
+	Indicators int                   `json:"indicators"` // 提取出的不同指标数
+	Matched    int                   `json:"matched"`    // 在知识库中找到的不同指标数
+	Matches    []*BulkIndicatorMatch `json:"matches"`
+}
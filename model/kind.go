@@ -0,0 +1,243 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// 知识种类，由 knowledgeType 判别
+const (
+	KindVulnerability = "vulnerability"
+	KindThreatActor   = "threatActor"
+	KindTechnique     = "technique"
+	KindPlaybook      = "playbook"
+	KindTool          = "tool"
+	KindCase          = "case"
+	KindThreatIntel   = "threatIntel"
+)
+
+// KindInfo 知识种类的说明，KnowledgeType 为创建时默认写入的知识类型
+type KindInfo struct {
+	Kind          string   `json:"kind"`
+	KnowledgeType string   `json:"knowledgeType"`
+	Keywords      []string `json:"keywords"` // knowledgeType 中包含这些关键字时判别为该种类
+	Fields        []string `json:"fields,omitempty"`
+}
+
+// Kinds 按顺序匹配，"威胁情报" 先于 "威胁组织" 判断
+var Kinds = []*KindInfo{
+	{Kind: KindVulnerability, KnowledgeType: "漏洞", Keywords: []string{"漏洞", "vulnerability", "cve"}},
+	{Kind: KindThreatIntel, KnowledgeType: "威胁情报", Keywords: []string{"情报", "intel", "ioc"}},
+	{Kind: KindThreatActor, KnowledgeType: "威胁组织", Keywords: []string{"组织", "团伙", "actor", "apt"}},
+	{Kind: KindPlaybook, KnowledgeType: "处置预案", Keywords: []string{"预案", "处置", "应急", "响应", "playbook"}},
+	{Kind: KindCase, KnowledgeType: "案例", Keywords: []string{"案例", "事件", "case", "incident"}},
+	{Kind: KindTool, KnowledgeType: "工具", Keywords: []string{"工具", "tool"}},
+	{Kind: KindTechnique, KnowledgeType: "攻击技术", Keywords: []string{"技术", "战术", "technique", "tactic", "att&ck", "mitre"}},
+}
+
+// KindOf 根据 knowledgeType 判别知识种类，无法判别时返回空字符串
+func KindOf(knowledgeType []string) string {
+	for _, info := range Kinds {
+		for _, t := range knowledgeType {
+			t = strings.ToLower(t)
+			for _, keyword := range info.Keywords {
+				if strings.Contains(t, keyword) {
+					return info.Kind
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// TypedKnowledge 按种类解码的知识条目，未能判别种类的旧数据解码为 *Knowledge
+type TypedKnowledge interface {
+	KnowledgeKind() string
+}
+
+// KnowledgeKind 旧的扁平结构没有种类
+func (k *Knowledge) KnowledgeKind() string {
+	return ""
+}
+
+// KnowledgeBase 各种类共有的字段
+type KnowledgeBase struct {
+	ID              string    `bson:"_id,omitempty" json:"id"`
+	Kind            string    `bson:"-" json:"kind"`
+	Title           string    `bson:"title,omitempty" json:"title"`
+	Tags            []string  `bson:"tags,omitempty" json:"tags"`
+	KnowledgeType   []string  `bson:"knowledgeType,omitempty" json:"knowledgeType"`
+	KnowledgeSource []string  `bson:"knowledgeSource,omitempty" json:"knowledgeSource"`
+	Confidentiality string    `bson:"confidentiality,omitempty" json:"confidentiality"`
+	Abstract        string    `bson:"abstract,omitempty" json:"abstract"`
+	Content         string    `bson:"content,omitempty" json:"content"`
+	Directory       string    `bson:"directory,omitempty" json:"directory"`
+	Reference       string    `bson:"reference,omitempty" json:"reference"`
+	Author          []string  `bson:"author,omitempty" json:"author"`
+	RevisionDate    []string  `bson:"revisionDate,omitempty" json:"revisionDate"`
+	UID             string    `bson:"uid,omitempty" json:"uid"`
+	Created         time.Time `bson:"created,omitempty" json:"created,omitempty"`
+	Modified        time.Time `bson:"modified,omitempty" json:"modified,omitempty"`
+}
+
+func (b *KnowledgeBase) KnowledgeKind() string {
+	return b.Kind
+}
+
+// Base 返回共有字段，嵌入后各种类都可以通过它访问
+func (b *KnowledgeBase) Base() *KnowledgeBase {
+	return b
+}
+
+type Vulnerability struct {
+	KnowledgeBase    `bson:",inline"`
+	Cve              string            `bson:"cve,omitempty" json:"cve"`
+	Cnnvd            string            `bson:"cnnvd,omitempty" json:"cnnvd"`
+	Cnvd             string            `bson:"cnvd,omitempty" json:"cnvd"`
+	Cwe              string            `bson:"cwe,omitempty" json:"cwe"`
+	Cwd              string            `bson:"cwd,omitempty" json:"cwd"`
+	Bugtraq          string            `bson:"bugtraq,omitempty" json:"bugtraq"`
+	Cvss             string            `bson:"cvss,omitempty" json:"cvss"`
+	CvssStr          string            `bson:"cvssStr,omitempty" json:"cvssStr"`
+	VulType          string            `bson:"vulType,omitempty" json:"vulType"`
+	ThreatSeverity   string            `bson:"threatSeverity,omitempty" json:"threatSeverity"`
+	Vendor           string            `bson:"vendor,omitempty" json:"vendor"`
+	Products         string            `bson:"products,omitempty" json:"products"`
+	AppName          string            `bson:"appName,omitempty" json:"appName"`
+	AppType          string            `bson:"appType,omitempty" json:"appType"`
+	Platforms        []string          `bson:"platforms,omitempty" json:"platforms"`
+	AffectedVerison  string            `bson:"affectedVerison,omitempty" json:"affectedVerison"`
+	AffectedProducts []AffectedProduct `bson:"affectedProducts,omitempty" json:"affectedProducts,omitempty"`
+	Consequence      string            `bson:"consequence,omitempty" json:"consequence"`
+	FingerPrint      string            `bson:"fingerPrint,omitempty" json:"fingerPrint"`
+	Msf              string            `bson:"msf,omitempty" json:"msf"`
+	Exploitdb        string            `bson:"exploitdb,omitempty" json:"exploitdb"`
+	IsExp            string            `bson:"isExp,omitempty" json:"isExp"`
+	Detection        string            `bson:"detection,omitempty" json:"detection"`
+	Mitigations      string            `bson:"mitigations,omitempty" json:"mitigations"`
+	Solution         string            `bson:"solution,omitempty" json:"solution"`
+}
+
+type ThreatActor struct {
+	KnowledgeBase       `bson:",inline"`
+	Alias               string   `bson:"alias,omitempty" json:"alias"`
+	Affiliation         string   `bson:"affiliation,omitempty" json:"affiliation"`
+	Motivations         string   `bson:"motivations,omitempty" json:"motivations"`
+	StrategicCapability string   `bson:"strategicCapability,omitempty" json:"strategicCapability"`
+	FirstActivity       string   `bson:"firstActivity,omitempty" json:"firstActivity"`
+	LatestActivity      string   `bson:"latestActivity,omitempty" json:"latestActivity"`
+	TimeLine            string   `bson:"timeLine,omitempty" json:"timeLine"`
+	TargetedGeography   string   `bson:"targetedGeography,omitempty" json:"targetedGeography"`
+	TargetedIndustry    string   `bson:"targetedIndustry,omitempty" json:"targetedIndustry"`
+	UsedTools           string   `bson:"usedTools,omitempty" json:"usedTools"`
+	UsedExploits        string   `bson:"usedExploits,omitempty" json:"usedExploits"`
+	TechniquesID        []string `bson:"techniquesId,omitempty" json:"techniquesId"`
+	SubTechniquesID     []string `bson:"subTechniquesId,omitempty" json:"subTechniquesId"`
+	TacticsID           []string `bson:"tacticsId,omitempty" json:"tacticsId"`
+	Techniques          string   `bson:"techniques,omitempty" json:"techniques"`
+	Tactics             string   `bson:"tactics,omitempty" json:"tactics"`
+	OrganizationIds     string   `bson:"organizationIds,omitempty" json:"organizationIds"`
+	IoC                 string   `bson:"ioc,omitempty" json:"IoC"`
+}
+
+type Technique struct {
+	KnowledgeBase   `bson:",inline"`
+	TechniquesID    []string `bson:"techniquesId,omitempty" json:"techniquesId"`
+	SubTechniquesID []string `bson:"subTechniquesId,omitempty" json:"subTechniquesId"`
+	TacticsID       []string `bson:"tacticsId,omitempty" json:"tacticsId"`
+	Techniques      string   `bson:"techniques,omitempty" json:"techniques"`
+	Tactics         string   `bson:"tactics,omitempty" json:"tactics"`
+	Platforms       []string `bson:"platforms,omitempty" json:"platforms"`
+	Detection       string   `bson:"detection,omitempty" json:"detection"`
+	Mitigations     string   `bson:"mitigations,omitempty" json:"mitigations"`
+	Recommendations string   `bson:"recommendations,omitempty" json:"recommendations"`
+}
+
+// Playbook 应急处置预案，按准备、告警、分析、遏制、根除、恢复、总结的流程组织
+type Playbook struct {
+	KnowledgeBase   `bson:",inline"`
+	Scenario        string   `bson:"scenario,omitempty" json:"scenario"`
+	Preparation     string   `bson:"preparation,omitempty" json:"preparation"`
+	Alert           string   `bson:"alert,omitempty" json:"alert"`
+	Analysis        string   `bson:"analysis,omitempty" json:"analysis"`
+	Traces          string   `bson:"traces,omitempty" json:"traces"`
+	Containment     string   `bson:"containment,omitempty" json:"containment"`
+	Eradication     string   `bson:"eradication,omitempty" json:"eradication"`
+	Recovery        string   `bson:"recovery,omitempty" json:"recovery"`
+	FollowUp        string   `bson:"followUp,omitempty" json:"followUp"`
+	DisposalProcess string   `bson:"disposalProcess,omitempty" json:"disposalProcess"`
+	Recommendations string   `bson:"recommendations,omitempty" json:"recommendations"`
+	TechniquesID    []string `bson:"techniquesId,omitempty" json:"techniquesId"`
+	TacticsID       []string `bson:"tacticsId,omitempty" json:"tacticsId"`
+}
+
+type Tool struct {
+	KnowledgeBase    `bson:",inline"`
+	AppName          string   `bson:"appName,omitempty" json:"appName"`
+	AppType          string   `bson:"appType,omitempty" json:"appType"`
+	Vendor           string   `bson:"vendor,omitempty" json:"vendor"`
+	Platforms        []string `bson:"platforms,omitempty" json:"platforms"`
+	InputParameters  string   `bson:"inputParameters,omitempty" json:"inputParameters"`
+	OutputParameters string   `bson:"outputParameters,omitempty" json:"outputParameters"`
+	TechniquesID     []string `bson:"techniquesId,omitempty" json:"techniquesId"`
+}
+
+type Case struct {
+	KnowledgeBase     `bson:",inline"`
+	Cases             string   `bson:"cases,omitempty" json:"cases"`
+	Scenario          string   `bson:"scenario,omitempty" json:"scenario"`
+	Analysis          string   `bson:"analysis,omitempty" json:"analysis"`
+	Traces            string   `bson:"traces,omitempty" json:"traces"`
+	Consequence       string   `bson:"consequence,omitempty" json:"consequence"`
+	TimeLine          string   `bson:"timeLine,omitempty" json:"timeLine"`
+	TargetedIndustry  string   `bson:"targetedIndustry,omitempty" json:"targetedIndustry"`
+	TargetedGeography string   `bson:"targetedGeography,omitempty" json:"targetedGeography"`
+	TechniquesID      []string `bson:"techniquesId,omitempty" json:"techniquesId"`
+	IoC               string   `bson:"ioc,omitempty" json:"IoC"`
+}
+
+type ThreatIntel struct {
+	KnowledgeBase     `bson:",inline"`
+	TiName            string   `bson:"tiName,omitempty" json:"TiName"`
+	IoC               string   `bson:"ioc,omitempty" json:"IoC"`
+	ThreatSeverity    string   `bson:"threatSeverity,omitempty" json:"threatSeverity"`
+	OrganizationIds   string   `bson:"organizationIds,omitempty" json:"organizationIds"`
+	Alias             string   `bson:"alias,omitempty" json:"alias"`
+	Affiliation       string   `bson:"affiliation,omitempty" json:"affiliation"`
+	FirstActivity     string   `bson:"firstActivity,omitempty" json:"firstActivity"`
+	LatestActivity    string   `bson:"latestActivity,omitempty" json:"latestActivity"`
+	TargetedIndustry  string   `bson:"targetedIndustry,omitempty" json:"targetedIndustry"`
+	TargetedGeography string   `bson:"targetedGeography,omitempty" json:"targetedGeography"`
+	TechniquesID      []string `bson:"techniquesId,omitempty" json:"techniquesId"`
+}
+
+// NewTypedKnowledge 返回对应种类的空结构，种类未知时返回 nil
+func NewTypedKnowledge(kind string) TypedKnowledge {
+	switch kind {
+	case KindVulnerability:
+		return &Vulnerability{}
+	case KindThreatActor:
+		return &ThreatActor{}
+	case KindTechnique:
+		return &Technique{}
+	case KindPlaybook:
+		return &Playbook{}
+	case KindTool:
+		return &Tool{}
+	case KindCase:
+		return &Case{}
+	case KindThreatIntel:
+		return &ThreatIntel{}
+	}
+	return nil
+}
+
+// KindInfoOf 返回种类的说明，种类未知时返回 nil
+func KindInfoOf(kind string) *KindInfo {
+	for _, info := range Kinds {
+		if info.Kind == kind {
+			return info
+		}
+	}
+	return nil
+}
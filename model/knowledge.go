@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type Knowledge struct {
 	ID                  string   `bson:"_id,omitempty" json:"id"`
 	Title               string   `bson:"title,omitempty" json:"title"`
@@ -73,6 +75,26 @@ type Knowledge struct {
 	OutputParameters    string   `bson:"outputParameters,omitempty" json:"outputParameters"`
 	Success             bool     `bson:"success,omitempty" json:"success"`
 	Message             string   `bson:"message,omitempty" json:"message"`
+
+	AffectedProducts []AffectedProduct `bson:"affectedProducts,omitempty" json:"affectedProducts,omitempty"` // 结构化的受影响产品范围
+
+	// 记录时间，由服务端在创建和修改时维护
+	Created  time.Time `bson:"created,omitempty" json:"created,omitempty"`
+	Modified time.Time `bson:"modified,omitempty" json:"modified,omitempty"`
+
+	// 以下由 KEV、EPSS 数据导入标注，更新知识时保持不变
+	KnownExploited bool       `bson:"knownExploited,omitempty" json:"knownExploited"` // 已在野利用(收录于 CISA KEV)
+	Kev            *KEVEntry  `bson:"kev,omitempty" json:"kev,omitempty"`
+	Epss           *EPSSScore `bson:"epss,omitempty" json:"epss,omitempty"`
+
+	// 以下为派生字段，由上面的原始字段计算得出，不需要手动录入
+	MatchedAlias string       `bson:"-" json:"matchedAlias,omitempty"` // 通过别名扩展命中时记录匹配到的别名
+	CvssInfo     *CvssInfo    `bson:"cvssInfo,omitempty" json:"cvssInfo,omitempty"`
+	ExploitInfo  *ExploitInfo `bson:"exploitInfo,omitempty" json:"exploitInfo,omitempty"`
+
+	// 由 firstActivity、latestActivity、timeLine、revisionDate 解析出的日期
+	Activity       *ActivityRange   `bson:"activity,omitempty" json:"activity,omitempty"`
+	TimelineEvents []*TimelineEvent `bson:"timelineEvents,omitempty" json:"timelineEvents,omitempty"`
 }
 
 type KnowledgeFilter struct {
@@ -95,6 +117,18 @@ type KnowledgeFilter struct {
 	Tactics         string             `bson:"tactics,omitempty"`
 	SubTechniquesID []string           `bson:"subTechniquesId,omitempty"`
 	AND             []*KnowledgeFilter `bson:"AND,omitempty"`
+
+	// 以下字段不直接作为查询条件，由 Search 转换为对应的范围查询
+	CvssMin      *float64 `bson:"-"`
+	CvssMax      *float64 `bson:"-"`
+	Severity     []string `bson:"-"`
+	CvssMismatch *bool    `bson:"-"`
+
+	// 利用情况过滤和排序
+	KnownExploited   *bool    `bson:"-"`
+	ExploitAvailable *bool    `bson:"-"`
+	EpssMin          *float64 `bson:"-"`
+	SortBy           string   `bson:"-"` // epss、cvss、kev，均按降序
 }
 
 type NewKnowledge struct {
@@ -168,9 +202,18 @@ type NewKnowledge struct {
 	TiName              string   `bson:"tiName,omitempty" json:"TiName"`
 	InputParameters     string   `bson:"inputParameters,omitempty" json:"inputParameters"`
 	OutputParameters    string   `bson:"outputParameters,omitempty" json:"outputParameters"`
+
+	AffectedProducts []AffectedProduct `bson:"affectedProducts,omitempty" json:"affectedProducts,omitempty"`
 }
 
 type DeletionStatus struct {
 	Success bool   `bson:"success,omitempty" json:"success"`
 	Message string `bson:"message,omitempty" json:"message"`
 }
+
+type RefreshStatus struct {
+	Success bool     `json:"success"`
+	Scanned int      `json:"scanned"`
+	Updated int      `json:"updated"`
+	Failed  []string `json:"failed,omitempty"`
+}
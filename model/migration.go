@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// MigrationRecord _migrations 集合中一次已执行的迁移
+type MigrationRecord struct {
+	Version     int       `bson:"_id" json:"version"`
+	Description string    `bson:"description" json:"description"`
+	Applied     time.Time `bson:"applied" json:"applied"`
+}
+
+// MigrationState 一个已注册迁移的执行情况，未执行时 Applied 为空
+type MigrationState struct {
+	Version     int        `json:"version"`
+	Description string     `json:"description"`
+	Reversible  bool       `json:"reversible"`
+	Applied     *time.Time `json:"applied,omitempty"`
+}
+
+// MigrationStatus 当前数据库的迁移版本和全部已注册迁移
+type MigrationStatus struct {
+	Current    int               `json:"current"`
+	Latest     int               `json:"latest"`
+	Migrations []*MigrationState `json:"migrations"`
+}
+
+// MigrationRun 一次 up 或 down 执行的迁移，出错时 Error 记录停在哪个版本
+type MigrationRun struct {
+	Direction string             `json:"direction"`
+	From      int                `json:"from"`
+	To        int                `json:"to"`
+	Executed  []*MigrationRecord `json:"executed"`
+	Error     string             `json:"error,omitempty"`
+}
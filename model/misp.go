@@ -0,0 +1,65 @@
+package model
+
+import "encoding/json"
+
+// MISPEventWrapper MISP 事件 JSON 的外层结构 {"Event": {...}}
+type MISPEventWrapper struct {
+	Event MISPEvent `json:"Event"`
+}
+
+// MISPSearchResponse MISP restSearch 的返回格式，用于导出多个事件
+type MISPSearchResponse struct {
+	Response []*MISPEventWrapper `json:"response"`
+}
+
+type MISPEvent struct {
+	UUID          string          `json:"uuid"`
+	Info          string          `json:"info"`
+	Date          string          `json:"date"`
+	ThreatLevelID string          `json:"threat_level_id"`
+	Analysis      string          `json:"analysis"`
+	Distribution  string          `json:"distribution"`
+	Timestamp     string          `json:"timestamp"`
+	Published     bool            `json:"published"`
+	Orgc          *MISPOrg        `json:"Orgc,omitempty"`
+	Attribute     []MISPAttribute `json:"Attribute"`
+	Object        []MISPObject    `json:"Object,omitempty"`
+	Tag           []MISPTag       `json:"Tag,omitempty"`
+	Galaxy        []MISPGalaxy    `json:"Galaxy,omitempty"`
+}
+
+type MISPOrg struct {
+	Name string `json:"name"`
+}
+
+type MISPAttribute struct {
+	UUID     string    `json:"uuid,omitempty"`
+	Type     string    `json:"type"`
+	Category string    `json:"category"`
+	Value    string    `json:"value"`
+	ToIDs    bool      `json:"to_ids"`
+	Comment  string    `json:"comment,omitempty"`
+	Tag      []MISPTag `json:"Tag,omitempty"`
+}
+
+type MISPObject struct {
+	Name      string          `json:"name"`
+	Attribute []MISPAttribute `json:"Attribute"`
+}
+
+type MISPTag struct {
+	Name string `json:"name"`
+}
+
+type MISPGalaxy struct {
+	Name          string              `json:"name"`
+	Type          string              `json:"type"`
+	GalaxyCluster []MISPGalaxyCluster `json:"GalaxyCluster"`
+}
+
+type MISPGalaxyCluster struct {
+	Type    string                     `json:"type"`
+	Value   string                     `json:"value"`
+	TagName string                     `json:"tag_name"`
+	Meta    map[string]json.RawMessage `json:"meta,omitempty"`
+}
@@ -0,0 +1,84 @@
+package model
+
+// NVDFeed NVD CVE JSON 2.0 数据文件
+type NVDFeed struct {
+	Format          string             `json:"format"`
+	Version         string             `json:"version"`
+	Timestamp       string             `json:"timestamp"`
+	Vulnerabilities []NVDVulnerability `json:"vulnerabilities"`
+}
+
+type NVDVulnerability struct {
+	Cve NVDCve `json:"cve"`
+}
+
+type NVDCve struct {
+	ID             string             `json:"id"`
+	Published      string             `json:"published"`
+	LastModified   string             `json:"lastModified"`
+	VulnStatus     string             `json:"vulnStatus"`
+	Descriptions   []NVDLangString    `json:"descriptions"`
+	Metrics        NVDMetrics         `json:"metrics"`
+	Weaknesses     []NVDWeakness      `json:"weaknesses"`
+	Configurations []NVDConfiguration `json:"configurations"`
+	References     []NVDReference     `json:"references"`
+}
+
+type NVDLangString struct {
+	Lang  string `json:"lang"`
+	Value string `json:"value"`
+}
+
+type NVDMetrics struct {
+	CvssMetricV40 []NVDCvssMetric `json:"cvssMetricV40"`
+	CvssMetricV31 []NVDCvssMetric `json:"cvssMetricV31"`
+	CvssMetricV30 []NVDCvssMetric `json:"cvssMetricV30"`
+	CvssMetricV2  []NVDCvssMetric `json:"cvssMetricV2"`
+}
+
+type NVDCvssMetric struct {
+	Source   string      `json:"source"`
+	Type     string      `json:"type"`
+	CvssData NVDCvssData `json:"cvssData"`
+}
+
+type NVDCvssData struct {
+	Version      string  `json:"version"`
+	VectorString string  `json:"vectorString"`
+	BaseScore    float64 `json:"baseScore"`
+	BaseSeverity string  `json:"baseSeverity"`
+}
+
+type NVDWeakness struct {
+	Source      string          `json:"source"`
+	Type        string          `json:"type"`
+	Description []NVDLangString `json:"description"`
+}
+
+type NVDConfiguration struct {
+	Operator string    `json:"operator"`
+	Negate   bool      `json:"negate"`
+	Nodes    []NVDNode `json:"nodes"`
+}
+
+type NVDNode struct {
+	Operator string        `json:"operator"`
+	Negate   bool          `json:"negate"`
+	CpeMatch []NVDCpeMatch `json:"cpeMatch"`
+}
+
+type NVDCpeMatch struct {
+	Vulnerable            bool   `json:"vulnerable"`
+	Criteria              string `json:"criteria"`
+	MatchCriteriaID       string `json:"matchCriteriaId"`
+	VersionStartIncluding string `json:"versionStartIncluding"`
+	VersionStartExcluding string `json:"versionStartExcluding"`
+	VersionEndIncluding   string `json:"versionEndIncluding"`
+	VersionEndExcluding   string `json:"versionEndExcluding"`
+}
+
+type NVDReference struct {
+	URL    string   `json:"url"`
+	Source string   `json:"source"`
+	Tags   []string `json:"tags"`
+}
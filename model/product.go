@@ -0,0 +1,28 @@
+package model
+
+// AffectedProduct 结构化的受影响产品范围，vendor/product 按 CPE 的写法统一为小写、空格替换为下划线
+type AffectedProduct struct {
+	CPE                   string `bson:"cpe,omitempty" json:"cpe,omitempty"`
+	Vendor                string `bson:"vendor,omitempty" json:"vendor,omitempty"`
+	Product               string `bson:"product" json:"product"`
+	Version               string `bson:"version,omitempty" json:"version,omitempty"` // 单个版本，"*" 或空表示不限
+	VersionStartIncluding string `bson:"versionStartIncluding,omitempty" json:"versionStartIncluding,omitempty"`
+	VersionStartExcluding string `bson:"versionStartExcluding,omitempty" json:"versionStartExcluding,omitempty"`
+	VersionEndIncluding   string `bson:"versionEndIncluding,omitempty" json:"versionEndIncluding,omitempty"`
+	VersionEndExcluding   string `bson:"versionEndExcluding,omitempty" json:"versionEndExcluding,omitempty"`
+	Source                string `bson:"source,omitempty" json:"source,omitempty"` // NVD、migrated 或手动录入时为空
+}
+
+// ProductQuery 受影响产品查询条件，传 CPE 时忽略其他字段
+type ProductQuery struct {
+	CPE     string `json:"cpe" form:"cpe"`
+	Vendor  string `json:"vendor" form:"vendor"`
+	Product string `json:"product" form:"product"`
+	Version string `json:"version" form:"version"`
+}
+
+// ProductMatch 命中的漏洞知识以及命中的受影响范围
+type ProductMatch struct {
+	Knowledge *Knowledge        `json:"knowledge"`
+	Matched   []AffectedProduct `json:"matched"`
+}
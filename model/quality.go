@@ -0,0 +1,59 @@
+package model
+
+// 数据质量检查规则
+const (
+	QualityEmptyTitle     = "empty-title"     // 标题为空
+	QualityDuplicateTitle = "duplicate-title" // 标题与其他条目重复(忽略大小写和首尾空白)
+	QualityInvalidAttack  = "invalid-attack"  // tacticsId、techniquesId、subTechniquesId 不是合法的 ATT&CK 编号
+	QualityOrphanedImage  = "orphaned-image"  // content 中引用的图片不存在或仍在临时目录
+	QualityArrayAsString  = "array-as-string" // 列表字段存成了字符串
+	QualityZeroWidth      = "zero-width"      // 字段中含有零宽字符
+)
+
+// QualityRule 一条检查规则，Fixable 的规则可以自动修复
+type QualityRule struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Fixable     bool   `json:"fixable"`
+}
+
+// QualityScanRequest 要执行的规则，为空时执行全部规则；Fix 时自动修复可修复的问题。
+// Filter 限定检查范围，ImageFolder 为图片存储路径，由调用方设置
+type QualityScanRequest struct {
+	Rules       []string         `json:"rules"`
+	Filter      *KnowledgeFilter `json:"filter"`
+	Fix         bool             `json:"fix"`
+	ImageFolder string           `json:"-"`
+}
+
+// QualityIssue 一个条目中发现的一个问题
+type QualityIssue struct {
+	Rule    string `json:"rule"`
+	Field   string `json:"field,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message"`
+	Fixed   bool   `json:"fixed,omitempty"`
+}
+
+// QualityDocument 有问题的知识条目
+type QualityDocument struct {
+	ID     string          `json:"id"`
+	Title  string          `json:"title"`
+	Issues []*QualityIssue `json:"issues"`
+}
+
+// QualityRuleSummary 按规则统计的问题数
+type QualityRuleSummary struct {
+	*QualityRule
+	Issues    int `json:"issues"`
+	Documents int `json:"documents"`
+	Fixed     int `json:"fixed"`
+}
+
+// QualityReport 数据质量检查报告
+type QualityReport struct {
+	Scanned   int                   `json:"scanned"`
+	Fixed     int                   `json:"fixed"` // 修复后写回的条目数
+	Rules     []*QualityRuleSummary `json:"rules"`
+	Documents []*QualityDocument    `json:"documents"`
+}
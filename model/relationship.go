@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// 知识条目之间的关系类型，方向为 Source 指向 Target
+const (
+	RelationshipUses      = "uses"       // 组织使用工具、技术
+	RelationshipMitigates = "mitigates"  // 处置预案、缓解措施缓解漏洞、技术
+	RelationshipDetects   = "detects"    // 检测方法检测技术、工具
+	RelationshipExploits  = "exploits"   // 工具、组织利用漏洞
+	RelationshipRelatedTo = "related-to" // 无方向的一般关联
+)
+
+var RelationshipTypes = []string{RelationshipUses, RelationshipMitigates, RelationshipDetects, RelationshipExploits, RelationshipRelatedTo}
+
+// RelationshipInverse 从 Target 看的关系名称，related-to 没有方向
+var RelationshipInverse = map[string]string{
+	RelationshipUses:      "used-by",
+	RelationshipMitigates: "mitigated-by",
+	RelationshipDetects:   "detected-by",
+	RelationshipExploits:  "exploited-by",
+	RelationshipRelatedTo: RelationshipRelatedTo,
+}
+
+// 关系相对于查询条目的方向
+const (
+	RelationshipOutgoing = "outgoing"
+	RelationshipIncoming = "incoming"
+)
+
+// Relationship relationships 集合中的一条关系。related-to 没有方向，保存时 Source 为较小的 ID
+type Relationship struct {
+	ID          string    `bson:"_id,omitempty" json:"id"`
+	Source      string    `bson:"source" json:"source"`
+	Target      string    `bson:"target" json:"target"`
+	Type        string    `bson:"type" json:"type"`
+	Description string    `bson:"description,omitempty" json:"description,omitempty"`
+	Created     time.Time `bson:"created" json:"created"`
+	Modified    time.Time `bson:"modified" json:"modified"`
+}
+
+type NewRelationship struct {
+	Source      string `json:"source"`
+	Target      string `json:"target"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+type UpdateRelationship struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// RelatedKnowledge 与查询条目有关系的一个条目。Type 为从查询条目看的关系名称，
+// 例如组织 uses 工具时，查询工具得到 used-by
+type RelatedKnowledge struct {
+	ID            string        `json:"id"`
+	Title         string        `json:"title"`
+	KnowledgeType []string      `json:"knowledgeType"`
+	Type          string        `json:"type"`
+	Direction     string        `json:"direction"`
+	Relationship  *Relationship `json:"relationship"`
+}
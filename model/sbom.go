@@ -0,0 +1,93 @@
+package model
+
+// CycloneDXBOM CycloneDX JSON 格式的 SBOM，只包含匹配需要的字段
+type CycloneDXBOM struct {
+	BOMFormat       string                   `json:"bomFormat"`
+	SpecVersion     string                   `json:"specVersion"`
+	Components      []CycloneDXComponent     `json:"components"`
+	Vulnerabilities []CycloneDXVulnerability `json:"vulnerabilities"`
+}
+
+type CycloneDXComponent struct {
+	BOMRef     string               `json:"bom-ref"`
+	Type       string               `json:"type"`
+	Group      string               `json:"group"`
+	Name       string               `json:"name"`
+	Version    string               `json:"version"`
+	Purl       string               `json:"purl"`
+	Cpe        string               `json:"cpe"`
+	Components []CycloneDXComponent `json:"components"` // 嵌套的子组件
+}
+
+type CycloneDXVulnerability struct {
+	ID      string `json:"id"`
+	Affects []struct {
+		Ref string `json:"ref"`
+	} `json:"affects"`
+}
+
+// SPDXDocument SPDX JSON 格式的 SBOM
+type SPDXDocument struct {
+	SPDXVersion string        `json:"spdxVersion"`
+	Packages    []SPDXPackage `json:"packages"`
+}
+
+type SPDXPackage struct {
+	SPDXID       string            `json:"SPDXID"`
+	Name         string            `json:"name"`
+	VersionInfo  string            `json:"versionInfo"`
+	Supplier     string            `json:"supplier"`
+	ExternalRefs []SPDXExternalRef `json:"externalRefs"`
+}
+
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+// SBOMComponent 从 SBOM 中提取出的组件
+type SBOMComponent struct {
+	Ref     string   `json:"ref,omitempty"`
+	Name    string   `json:"name"`
+	Vendor  string   `json:"vendor,omitempty"`
+	Version string   `json:"version,omitempty"`
+	PURL    string   `json:"purl,omitempty"`
+	CPE     string   `json:"cpe,omitempty"`
+	Cves    []string `json:"cves,omitempty"` // SBOM 自带的漏洞声明(CycloneDX vulnerabilities)
+}
+
+// SBOM 组件与漏洞知识的匹配方式
+const (
+	SBOMMatchCPE     = "cpe"      // 结构化的受影响产品范围
+	SBOMMatchProduct = "products" // vendor/products/appName 自由文本
+	SBOMMatchCVE     = "cve"      // SBOM 中声明的 CVE 编号
+)
+
+// SBOMFinding 一个组件命中的一条漏洞知识
+type SBOMFinding struct {
+	Component        *SBOMComponent `json:"component"`
+	KnowledgeID      string         `json:"knowledgeId"`
+	Title            string         `json:"title"`
+	Cve              string         `json:"cve"`
+	Cvss             string         `json:"cvss"`
+	Severity         string         `json:"severity,omitempty"`
+	MatchedBy        string         `json:"matchedBy"`
+	VersionVerified  bool           `json:"versionVerified"` // false 表示组件或知识条目缺少可比较的版本信息
+	ExploitAvailable bool           `json:"exploitAvailable"`
+	KnownExploited   bool           `json:"knownExploited"`
+	IsExp            string         `json:"isExp"`
+	Msf              string         `json:"msf"`
+	Exploitdb        string         `json:"exploitdb"`
+	Solution         string         `json:"solution"`
+}
+
+// SBOMReport SBOM 漏洞匹配报告
+type SBOMReport struct {
+	Format      string         `json:"format"`
+	SpecVersion string         `json:"specVersion"`
+	Components  int            `json:"components"`
+	Affected    int            `json:"affected"` // 至少命中一条漏洞的组件数
+	Severity    map[string]int `json:"severity"`
+	Findings    []*SBOMFinding `json:"findings"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// KnowledgeSchema knowledge_schemas 集合中某一 knowledgeType 的 JSON Schema，
+// 以文本形式保存，避免 "$schema" 之类的键写入文档
+type KnowledgeSchema struct {
+	KnowledgeType string          `bson:"_id" json:"knowledgeType"`
+	Source        string          `bson:"schema" json:"-"`
+	Schema        json.RawMessage `bson:"-" json:"schema"`
+	Modified      time.Time       `bson:"modified" json:"modified"`
+}
+
+// SchemaValidationError 知识条目不符合 knowledgeType 的 schema，Errors 为全部问题
+type SchemaValidationError struct {
+	KnowledgeType string   `json:"knowledgeType"`
+	Errors        []string `json:"errors"`
+}
+
+func (e *SchemaValidationError) Error() string {
+	return "knowledge does not match the " + e.KnowledgeType + " schema: " + e.Errors[0]
+}
+
+// SchemaViolation 试运行中不符合 schema 的已有条目
+type SchemaViolation struct {
+	ID     string   `json:"id"`
+	Title  string   `json:"title"`
+	Errors []string `json:"errors"`
+}
+
+// SchemaDryRunReport 用 schema 校验某一 knowledgeType 的已有条目，不保存 schema
+type SchemaDryRunReport struct {
+	KnowledgeType string             `json:"knowledgeType"`
+	Scanned       int                `json:"scanned"`
+	Violating     int                `json:"violating"`
+	Documents     []*SchemaViolation `json:"documents"` // 最多返回 nums 条
+}
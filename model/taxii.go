@@ -0,0 +1,118 @@
+package model
+
+import "time"
+
+// TAXII 2.1 协议的媒体类型
+const (
+	TAXIIMediaType = "application/taxii+json;version=2.1"
+	STIXMediaType  = "application/stix+json;version=2.1"
+)
+
+type TAXIIDiscovery struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Default     string   `json:"default,omitempty"`
+	APIRoots    []string `json:"api_roots"`
+}
+
+type TAXIIAPIRoot struct {
+	Title            string   `json:"title"`
+	Description      string   `json:"description,omitempty"`
+	Versions         []string `json:"versions"`
+	MaxContentLength int      `json:"max_content_length"`
+}
+
+// TAXIICollection 返回给客户端的集合信息
+type TAXIICollection struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	CanRead     bool     `json:"can_read"`
+	CanWrite    bool     `json:"can_write"`
+	MediaTypes  []string `json:"media_types"`
+}
+
+type TAXIICollections struct {
+	Collections []*TAXIICollection `json:"collections"`
+}
+
+// TAXIIEnvelope objects 接口的返回，more 为 true 时用 next 继续获取
+type TAXIIEnvelope struct {
+	More    bool          `json:"more"`
+	Next    string        `json:"next,omitempty"`
+	Objects []*STIXObject `json:"objects"`
+
+	DateAddedFirst time.Time `json:"-"` // 写入 X-TAXII-Date-Added-First 响应头
+	DateAddedLast  time.Time `json:"-"`
+}
+
+// TAXIICollectionDef taxii_collections 集合中保存的集合定义：按知识类型或保存的过滤条件选取知识条目，
+// TLP 为集合允许包含的最高密级，也是客户端读取该集合所需的最低授权
+type TAXIICollectionDef struct {
+	ID            string   `bson:"_id" json:"id"`
+	Title         string   `bson:"title" json:"title"`
+	Description   string   `bson:"description,omitempty" json:"description"`
+	KnowledgeType []string `bson:"knowledgeType,omitempty" json:"knowledgeType"`
+	Tags          []string `bson:"tags,omitempty" json:"tags"`
+	Keyword       []string `bson:"keyword,omitempty" json:"keyword"`
+	TLP           string   `bson:"tlp" json:"tlp"`
+}
+
+type NewTAXIICollection struct {
+	Title         string   `json:"title"`
+	Description   string   `json:"description"`
+	KnowledgeType []string `json:"knowledgeType"`
+	Tags          []string `json:"tags"`
+	Keyword       []string `json:"keyword"`
+	TLP           string   `json:"tlp"`
+}
+
+// TAXIIClient taxii_clients 集合中的客户端，通过 Basic 认证(用户名为 id，密码为 key)访问，只保存 key 的哈希
+type TAXIIClient struct {
+	ID        string    `bson:"_id" json:"id"`
+	Name      string    `bson:"name" json:"name"`
+	KeyHash   string    `bson:"keyHash" json:"-"`
+	Clearance string    `bson:"clearance" json:"clearance"` // 客户端可读取的最高 TLP 等级
+	Created   time.Time `bson:"created" json:"created"`
+}
+
+type NewTAXIIClient struct {
+	Name      string `json:"name"`
+	Clearance string `json:"clearance"`
+}
+
+// TAXIIClientKey 创建客户端时返回，key 只在此时返回一次
+type TAXIIClientKey struct {
+	*TAXIIClient
+	Key string `json:"key"`
+}
+
+// STIXObject STIX 2.1 对象，只包含从知识条目转换时用到的属性
+type STIXObject struct {
+	Type               string                  `json:"type"`
+	SpecVersion        string                  `json:"spec_version"`
+	ID                 string                  `json:"id"`
+	Created            string                  `json:"created"`
+	Modified           string                  `json:"modified"`
+	Name               string                  `json:"name,omitempty"`
+	Description        string                  `json:"description,omitempty"`
+	Aliases            []string                `json:"aliases,omitempty"`
+	IsFamily           *bool                   `json:"is_family,omitempty"`
+	Published          string                  `json:"published,omitempty"`
+	ObjectRefs         []string                `json:"object_refs,omitempty"`
+	Pattern            string                  `json:"pattern,omitempty"`
+	PatternType        string                  `json:"pattern_type,omitempty"`
+	ValidFrom          string                  `json:"valid_from,omitempty"`
+	RelationshipType   string                  `json:"relationship_type,omitempty"`
+	SourceRef          string                  `json:"source_ref,omitempty"`
+	TargetRef          string                  `json:"target_ref,omitempty"`
+	Labels             []string                `json:"labels,omitempty"`
+	ExternalReferences []STIXExternalReference `json:"external_references,omitempty"`
+	ObjectMarkingRefs  []string                `json:"object_marking_refs,omitempty"`
+}
+
+type STIXExternalReference struct {
+	SourceName string `json:"source_name"`
+	ExternalID string `json:"external_id,omitempty"`
+	URL        string `json:"url,omitempty"`
+}
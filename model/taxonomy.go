@@ -0,0 +1,82 @@
+package model
+
+import "time"
+
+// 受控词表管理的字段
+const (
+	TaxonomyKnowledgeType   = "knowledgeType"
+	TaxonomyTags            = "tags"
+	TaxonomyKnowledgeSource = "knowledgeSource"
+)
+
+var TaxonomyFields = []string{TaxonomyKnowledgeType, TaxonomyTags, TaxonomyKnowledgeSource}
+
+// TaxonomyTerm taxonomy_terms 集合中的词条。写入知识时 name、nameEn、synonyms 都规范化为 name
+type TaxonomyTerm struct {
+	ID          string    `bson:"_id,omitempty" json:"id"`
+	Field       string    `bson:"field" json:"field"`
+	Name        string    `bson:"name" json:"name"`
+	NameEn      string    `bson:"nameEn,omitempty" json:"nameEn"`
+	Parent      string    `bson:"parent,omitempty" json:"parent"` // 上级词条 ID
+	Synonyms    []string  `bson:"synonyms,omitempty" json:"synonyms"`
+	Description string    `bson:"description,omitempty" json:"description"`
+	Keys        []string  `bson:"keys" json:"-"` // name、nameEn、synonyms 的小写形式，用于匹配
+	Created     time.Time `bson:"created" json:"created"`
+	Modified    time.Time `bson:"modified" json:"modified"`
+}
+
+type NewTaxonomyTerm struct {
+	Field       string   `json:"field"`
+	Name        string   `json:"name"`
+	NameEn      string   `json:"nameEn"`
+	Parent      string   `json:"parent"`
+	Synonyms    []string `json:"synonyms"`
+	Description string   `json:"description"`
+}
+
+// UpdateTaxonomyTerm 修改词条的说明信息，修改 name 请使用重命名，以便同步改写知识条目
+type UpdateTaxonomyTerm struct {
+	NameEn      string   `json:"nameEn"`
+	Parent      string   `json:"parent"`
+	Synonyms    []string `json:"synonyms"`
+	Description string   `json:"description"`
+}
+
+// TaxonomyNode 词表的树形结构
+type TaxonomyNode struct {
+	*TaxonomyTerm
+	Children []*TaxonomyNode `json:"children,omitempty"`
+}
+
+// TaxonomySetting taxonomy_settings 集合中字段的约束方式。Strict 为 true 时拒绝词表之外的值，
+// 否则只规范化已知的值
+type TaxonomySetting struct {
+	Field  string `bson:"_id" json:"field"`
+	Strict bool   `bson:"strict" json:"strict"`
+}
+
+// Taxonomy 某一字段的词表
+type Taxonomy struct {
+	Field  string          `json:"field"`
+	Strict bool            `json:"strict"`
+	Terms  []*TaxonomyNode `json:"terms"`
+}
+
+// TaxonomyRewriteStatus 重命名、合并或规范化后改写知识条目的结果
+type TaxonomyRewriteStatus struct {
+	Field    string         `json:"field"`
+	Term     *TaxonomyTerm  `json:"term,omitempty"`
+	Scanned  int            `json:"scanned"`
+	Modified int            `json:"modified"`
+	Unknown  map[string]int `json:"unknown,omitempty"` // 规范化时遇到的词表之外的值及出现次数
+}
+
+// TaxonomyViolationError 严格模式下写入了词表之外的值
+type TaxonomyViolationError struct {
+	Field  string   `json:"field"`
+	Values []string `json:"values"`
+}
+
+func (e *TaxonomyViolationError) Error() string {
+	return e.Field + " contains values outside the taxonomy: " + e.Values[0]
+}
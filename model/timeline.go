@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// 时间线事件来源字段
+const (
+	TimelineFirstActivity  = "firstActivity"
+	TimelineLatestActivity = "latestActivity"
+	TimelineEntry          = "timeLine"
+	TimelineRevision       = "revisionDate"
+)
+
+// TimelineEvent 从日期字段和 timeLine 文本解析出的事件。部分日期(如 "2019"、"2019-Q3")
+// 用起止日期表示，End 为范围内的最后一天
+type TimelineEvent struct {
+	Start       time.Time `bson:"start" json:"start"`
+	End         time.Time `bson:"end" json:"end"`
+	Precision   string    `bson:"precision" json:"precision"`
+	Date        string    `bson:"date" json:"date"` // 原文中的日期写法
+	Description string    `bson:"description,omitempty" json:"description,omitempty"`
+	Source      string    `bson:"source" json:"source"`
+}
+
+// ActivityRange 活跃时间范围，由首次活动、最近活动和时间线事件得出。
+// 最近活动为 "至今"、"present" 时 Ongoing 为 true，End 为计算时的日期，查询时视为持续到现在
+type ActivityRange struct {
+	Start   time.Time `bson:"start" json:"start"`
+	End     time.Time `bson:"end" json:"end"`
+	Ongoing bool      `bson:"ongoing,omitempty" json:"ongoing,omitempty"`
+}
+
+// TimelineItem 时间线接口返回的一条事件
+type TimelineItem struct {
+	KnowledgeID string `json:"knowledgeId"`
+	Title       string `json:"title"`
+	*TimelineEvent
+}
+
+type Timeline struct {
+	Events []*TimelineItem `json:"events"`
+}
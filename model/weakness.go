@@ -0,0 +1,75 @@
+package model
+
+// Weakness CWE 弱点，ID 形如 "CWE-79"
+type Weakness struct {
+	ID                  string   `bson:"_id" json:"id"`
+	Name                string   `bson:"name" json:"name"`
+	Abstraction         string   `bson:"abstraction,omitempty" json:"abstraction"`
+	Status              string   `bson:"status,omitempty" json:"status"`
+	Description         string   `bson:"description,omitempty" json:"description"`
+	ExtendedDescription string   `bson:"extendedDescription,omitempty" json:"extendedDescription"`
+	Parents             []string `bson:"parents,omitempty" json:"parents"`               // ChildOf 关系指向的 CWE
+	AttackPatterns      []string `bson:"attackPatterns,omitempty" json:"attackPatterns"` // 相关的 CAPEC
+}
+
+// AttackPattern CAPEC 攻击模式，ID 形如 "CAPEC-63"
+type AttackPattern struct {
+	ID          string   `bson:"_id" json:"id"`
+	Name        string   `bson:"name" json:"name"`
+	Abstraction string   `bson:"abstraction,omitempty" json:"abstraction"`
+	Status      string   `bson:"status,omitempty" json:"status"`
+	Description string   `bson:"description,omitempty" json:"description"`
+	Likelihood  string   `bson:"likelihood,omitempty" json:"likelihood"`
+	Severity    string   `bson:"severity,omitempty" json:"severity"`
+	Weaknesses  []string `bson:"weaknesses,omitempty" json:"weaknesses"` // 相关的 CWE
+	Techniques  []string `bson:"techniques,omitempty" json:"techniques"` // 映射到的 ATT&CK 技术，如 "T1574.010"
+}
+
+// WeaknessReport 知识条目的 CWE -> CAPEC -> ATT&CK 关联信息
+type WeaknessReport struct {
+	KnowledgeID        string           `json:"knowledgeId"`
+	Title              string           `json:"title"`
+	Weaknesses         []*Weakness      `json:"weaknesses"`
+	Missing            []string         `json:"missing,omitempty"` // 目录中不存在的 CWE
+	AttackPatterns     []*AttackPattern `json:"attackPatterns"`
+	Techniques         []string         `json:"techniques"`
+	TechniqueKnowledge []*Knowledge     `json:"techniqueKnowledge"` // techniquesId 命中这些技术的知识条目
+}
+
+// CWE/CAPEC XML 目录文件的解析结构，标签不带命名空间以兼容不同版本
+type CWEXMLWeakness struct {
+	ID                  string `xml:"ID,attr"`
+	Name                string `xml:"Name,attr"`
+	Abstraction         string `xml:"Abstraction,attr"`
+	Status              string `xml:"Status,attr"`
+	Description         string `xml:"Description"`
+	ExtendedDescription struct {
+		Inner string `xml:",innerxml"`
+	} `xml:"Extended_Description"`
+	RelatedWeaknesses []struct {
+		Nature string `xml:"Nature,attr"`
+		CWEID  string `xml:"CWE_ID,attr"`
+	} `xml:"Related_Weaknesses>Related_Weakness"`
+	RelatedAttackPatterns []struct {
+		CAPECID string `xml:"CAPEC_ID,attr"`
+	} `xml:"Related_Attack_Patterns>Related_Attack_Pattern"`
+}
+
+type CAPECXMLAttackPattern struct {
+	ID          string `xml:"ID,attr"`
+	Name        string `xml:"Name,attr"`
+	Abstraction string `xml:"Abstraction,attr"`
+	Status      string `xml:"Status,attr"`
+	Description struct {
+		Inner string `xml:",innerxml"`
+	} `xml:"Description"`
+	Likelihood        string `xml:"Likelihood_Of_Attack"`
+	Severity          string `xml:"Typical_Severity"`
+	RelatedWeaknesses []struct {
+		CWEID string `xml:"CWE_ID,attr"`
+	} `xml:"Related_Weaknesses>Related_Weakness"`
+	TaxonomyMappings []struct {
+		TaxonomyName string `xml:"Taxonomy_Name,attr"`
+		EntryID      string `xml:"Entry_ID"`
+	} `xml:"Taxonomy_Mappings>Taxonomy_Mapping"`
+}
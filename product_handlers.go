@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"mongdbs/model"
+	"mongdbs/resolvers"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// curl -X GET "http://localhost:8085/api/knowledge/affected?vendor=f5&product=nginx&version=1.18.0&nums=10"
+// curl -X GET "http://localhost:8085/api/knowledge/affected?cpe=cpe:2.3:a:f5:nginx:1.18.0:*:*:*:*:*:*:*"
+func searchByAffectedProductHandler(c *gin.Context) {
+	var query model.ProductQuery
+	if err := c.BindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	numsStr := c.DefaultQuery("nums", "0")
+	nums, err := strconv.Atoi(numsStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid nums parameter"})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	results, err := resolver.Query().SearchByAffectedProduct(ctx, query, nums)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// curl -X POST http://localhost:8085/api/knowledge/affected/migrate
+func migrateAffectedProductsHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	report, err := resolver.Mutation().MigrateAffectedProducts(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
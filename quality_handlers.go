@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"mongdbs/model"
+	"mongdbs/resolvers"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// curl -X GET http://localhost:8085/api/quality/rules
+func qualityRulesHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	rules, err := resolver.Query().QualityRules(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// curl -X POST "http://localhost:8085/api/quality/scan?fix=true" -H "Content-Type: application/json" -d '{"rules": ["zero-width", "array-as-string"], "filter": {"knowledgeType": ["攻击技术"]}}'
+// 不指定 rules 时执行全部规则；fix 只修复 fixable 的规则，建议先不带 fix 查看报告
+func scanKnowledgeQualityHandler(c *gin.Context) {
+	var req model.QualityScanRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if c.Query("fix") == "true" {
+		req.Fix = true
+	}
+	req.ImageFolder = IMAGE_FOLDER
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	report, err := resolver.Mutation().ScanKnowledgeQuality(ctx, req)
+	if err != nil {
+		schemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
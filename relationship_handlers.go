@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"mongdbs/model"
+	"mongdbs/resolvers"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// curl -X GET "http://localhost:8085/api/relationships?source=<id>&target=<id>&type=uses"
+func listRelationshipsHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	relationships, err := resolver.Query().ListRelationships(ctx, c.Query("source"), c.Query("target"), c.Query("type"))
+	if err != nil {
+		kindError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, relationships)
+}
+
+// curl -X POST http://localhost:8085/api/relationships -H "Content-Type: application/json" -d '{"source": "<id>", "target": "<id>", "type": "uses", "description": "..."}'
+// type 可以是 uses、mitigates、detects、exploits、related-to
+func createRelationshipHandler(c *gin.Context) {
+	var input model.NewRelationship
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	relationship, err := resolver.Mutation().CreateRelationship(ctx, input)
+	if err != nil {
+		kindError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, relationship)
+}
+
+// curl -X PUT http://localhost:8085/api/relationships/<id> -H "Content-Type: application/json" -d '{"type": "exploits", "description": "..."}'
+func updateRelationshipHandler(c *gin.Context) {
+	var input model.UpdateRelationship
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	relationship, err := resolver.Mutation().UpdateRelationship(ctx, c.Param("id"), input)
+	if err != nil {
+		kindError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, relationship)
+}
+
+// curl -X DELETE http://localhost:8085/api/relationships/<id>
+func deleteRelationshipHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	deletionStatus, err := resolver.Mutation().DeleteRelationship(ctx, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deletionStatus)
+}
+
+// curl -X GET "http://localhost:8085/api/knowledge/related?id=<id>&type=used-by&direction=incoming"
+// 同时返回以条目为终点的关系，type 可以用反向名称 used-by、mitigated-by、detected-by、exploited-by
+func relatedKnowledgeHandler(c *gin.Context) {
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be provided"})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	related, err := resolver.Query().RelatedKnowledge(ctx, id, c.Query("type"), c.Query("direction"))
+	if err != nil {
+		kindError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, related)
+}
+
+// curl -X GET "http://localhost:8085/api/knowledge/graph?id=<id>&type=uses&type=exploits&direction=outgoing&depth=2&limit=500"
+// 从一个或多个条目出发按深度扩展，返回 nodes 和 edges
+func knowledgeGraphHandler(c *gin.Context) {
+	query := model.GraphQuery{
+		StartIDs:  c.QueryArray("id"),
+		Types:     c.QueryArray("type"),
+		Direction: c.Query("direction"),
+	}
+	var err error
+	if query.Depth, err = strconv.Atoi(c.DefaultQuery("depth", "1")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid depth parameter"})
+		return
+	}
+	if query.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "0")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	graph, err := resolver.Query().KnowledgeGraph(ctx, query)
+	if err != nil {
+		kindError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, graph)
+}
+
+// 例如查询针对金融行业的组织使用的工具所利用的漏洞：
+// curl -X POST http://localhost:8085/api/knowledge/graph -H "Content-Type: application/json" -d '{"start": {"knowledgeType": ["威胁组织"], "tags": ["金融"]}, "hops": [{"types": ["uses"], "direction": "outgoing", "filter": {"knowledgeType": ["工具"]}}, {"types": ["exploits"], "direction": "outgoing", "filter": {"knowledgeType": ["漏洞"]}}]}'
+// 默认只返回走完全部 hops 的路径，"partial": true 时保留中途停止的路径
+func traverseKnowledgeGraphHandler(c *gin.Context) {
+	var query model.GraphQuery
+	if err := c.BindJSON(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	graph, err := resolver.Query().KnowledgeGraph(ctx, query)
+	if err != nil {
+		kindError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, graph)
+}
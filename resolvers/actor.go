@@ -0,0 +1,258 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// valueSet 按规范化 key 去重，同时保留首次出现时的原始写法用于展示
+type valueSet struct {
+	keys    []string
+	display map[string]string
+}
+
+func newValueSet(values []string, upper bool) *valueSet {
+	s := &valueSet{display: make(map[string]string)}
+	for _, v := range values {
+		v = strings.TrimSpace(util.CleanString(v))
+		if v == "" {
+			continue
+		}
+		key := strings.ToLower(v)
+		if upper {
+			v = strings.ToUpper(v)
+			key = v
+		}
+		if _, ok := s.display[key]; ok {
+			continue
+		}
+		s.display[key] = v
+		s.keys = append(s.keys, key)
+	}
+	return s
+}
+
+func (s *valueSet) has(key string) bool {
+	_, ok := s.display[key]
+	return ok
+}
+
+func (s *valueSet) values(keys []string) []string {
+	result := make([]string, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, s.display[k])
+	}
+	sort.Strings(result)
+	return result
+}
+
+// jaccard 计算 |A∩B| / |A∪B|，两个集合都为空时返回 0
+func jaccard(sets ...*valueSet) float64 {
+	if len(sets) == 0 {
+		return 0
+	}
+	union := make(map[string]int)
+	for _, s := range sets {
+		for _, k := range s.keys {
+			union[k]++
+		}
+	}
+	if len(union) == 0 {
+		return 0
+	}
+	shared := 0
+	for _, n := range union {
+		if n == len(sets) {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(union))
+}
+
+// intersect 返回在所有集合中都出现的 key
+func intersect(sets []*valueSet) []string {
+	var result []string
+	for _, k := range sets[0].keys {
+		all := true
+		for _, s := range sets[1:] {
+			if !s.has(k) {
+				all = false
+				break
+			}
+		}
+		if all {
+			result = append(result, k)
+		}
+	}
+	return result
+}
+
+// exclusive 返回只在 sets[i] 中出现的 key
+func exclusive(sets []*valueSet, i int) []string {
+	var result []string
+	for _, k := range sets[i].keys {
+		found := false
+		for j, s := range sets {
+			if j != i && s.has(k) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, k)
+		}
+	}
+	return result
+}
+
+// actorSets 威胁组织四类属性对应的集合，顺序为技术、工具、行业、地区
+type actorSets [4]*valueSet
+
+func newActorSets(k *model.Knowledge) actorSets {
+	techniques := append(append([]string{}, k.TechniquesID...), k.SubTechniquesID...)
+	return actorSets{
+		newValueSet(techniques, true),
+		newValueSet(util.SplitList(k.UsedTools), false),
+		newValueSet(util.SplitList(k.TargetedIndustry), false),
+		newValueSet(util.SplitList(k.TargetedGeography), false),
+	}
+}
+
+// combined 将四类属性合并为一个集合，key 带上类别前缀避免不同类别的同名值相互匹配
+func (a actorSets) combined() *valueSet {
+	s := &valueSet{display: make(map[string]string)}
+	for i, set := range a {
+		for _, k := range set.keys {
+			key := fmt.Sprintf("%d:%s", i, k)
+			s.display[key] = set.display[k]
+			s.keys = append(s.keys, key)
+		}
+	}
+	return s
+}
+
+func attributesOf(sets []*valueSet, keys [4][]string) model.ActorAttributes {
+	return model.ActorAttributes{
+		Techniques:  sets[0].values(keys[0]),
+		Tools:       sets[1].values(keys[1]),
+		Industries:  sets[2].values(keys[2]),
+		Geographies: sets[3].values(keys[3]),
+	}
+}
+
+// CompareThreatActors 比较两个及以上威胁组织的技术、工具、目标行业和地区的重叠情况
+func (r *queryResolver) CompareThreatActors(ctx context.Context, ids []string) (*model.ActorComparison, error) {
+	collection := database.GetCollection("knowledge")
+
+	var idList []string
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			idList = append(idList, id)
+		}
+	}
+	// 已被合并的 ID 换成保留条目，合并到同一条目的 ID 只算一个
+	idList, err := resolveKnowledgeIDs(ctx, idList)
+	if err != nil {
+		return nil, err
+	}
+	if len(idList) < 2 {
+		return nil, errors.New("at least two threat actor ids must be provided")
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": idList}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	found := make(map[string]*model.Knowledge)
+	for cursor.Next(ctx) {
+		var result model.Knowledge
+		if err := cursor.Decode(&result); err != nil {
+			return nil, err
+		}
+		found[result.ID] = &result
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, id := range idList {
+		if found[id] == nil {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("threat actors not found: %s", strings.Join(missing, ", "))
+	}
+
+	sets := make([]actorSets, len(idList))
+	comparison := &model.ActorComparison{Unique: make(map[string]model.ActorAttributes)}
+	for i, id := range idList {
+		k := found[id]
+		sets[i] = newActorSets(k)
+		comparison.Actors = append(comparison.Actors, &model.ActorProfile{
+			ID:          k.ID,
+			Title:       k.Title,
+			Alias:       k.Alias,
+			Affiliation: k.Affiliation,
+			Attributes:  attributesOf(sets[i][:], [4][]string{sets[i][0].keys, sets[i][1].keys, sets[i][2].keys, sets[i][3].keys}),
+		})
+	}
+
+	// 按类别转置，便于逐类计算交集和差集
+	var byCategory [4][]*valueSet
+	for c := 0; c < 4; c++ {
+		for i := range sets {
+			byCategory[c] = append(byCategory[c], sets[i][c])
+		}
+	}
+
+	var shared [4][]string
+	for c := 0; c < 4; c++ {
+		shared[c] = intersect(byCategory[c])
+	}
+	comparison.Shared = attributesOf([]*valueSet{byCategory[0][0], byCategory[1][0], byCategory[2][0], byCategory[3][0]}, shared)
+
+	for i, id := range idList {
+		var unique [4][]string
+		for c := 0; c < 4; c++ {
+			unique[c] = exclusive(byCategory[c], i)
+		}
+		comparison.Unique[id] = attributesOf(sets[i][:], unique)
+	}
+
+	for i := 0; i < len(idList); i++ {
+		for j := i + 1; j < len(idList); j++ {
+			comparison.Similarity = append(comparison.Similarity, &model.ActorSimilarity{
+				Source:      idList[i],
+				Target:      idList[j],
+				Techniques:  jaccard(sets[i][0], sets[j][0]),
+				Tools:       jaccard(sets[i][1], sets[j][1]),
+				Industries:  jaccard(sets[i][2], sets[j][2]),
+				Geographies: jaccard(sets[i][3], sets[j][3]),
+				Overall:     jaccard(sets[i].combined(), sets[j].combined()),
+			})
+		}
+	}
+
+	all := make([]*valueSet, len(sets))
+	for i := range sets {
+		all[i] = sets[i].combined()
+	}
+	comparison.Overall = jaccard(all...)
+
+	return comparison, nil
+}
@@ -0,0 +1,374 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// normalizeAliasKey 规范化别名用于匹配："APT 29"、"apt-29"、"APT29" 视为同一个名称
+func normalizeAliasKey(name string) string {
+	name = strings.ToLower(util.CleanString(name))
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '-', '_', '.':
+			return -1
+		}
+		return r
+	}, name)
+}
+
+// knowledgeAliases 从知识条目的 alias 字段和标题生成别名记录
+func knowledgeAliases(k *model.Knowledge) []interface{} {
+	var docs []interface{}
+	seen := make(map[string]bool)
+	add := func(name, source string) {
+		name = strings.TrimSpace(util.CleanString(name))
+		key := normalizeAliasKey(name)
+		if key == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		docs = append(docs, model.Alias{
+			ID:          primitive.NewObjectID().Hex(),
+			Key:         key,
+			Name:        name,
+			KnowledgeID: k.ID,
+			Source:      source,
+		})
+	}
+
+	add(k.Title, model.AliasSourceTitle)
+	for _, alias := range util.SplitList(k.Alias) {
+		add(alias, model.AliasSourceField)
+	}
+	return docs
+}
+
+// syncAliases 用知识条目当前的 alias 和标题刷新注册表，手动维护的别名保持不变
+func syncAliases(ctx context.Context, k *model.Knowledge) error {
+	collection := database.GetCollection("aliases")
+
+	filter := bson.M{
+		"knowledgeId": k.ID,
+		"source":      bson.M{"$in": []string{model.AliasSourceField, model.AliasSourceTitle}},
+	}
+	if _, err := collection.DeleteMany(ctx, filter); err != nil {
+		return err
+	}
+
+	docs := knowledgeAliases(k)
+	if len(docs) == 0 {
+		return nil
+	}
+	_, err := collection.InsertMany(ctx, docs)
+	return err
+}
+
+// removeAliases 删除指向某个知识条目的全部别名
+func removeAliases(ctx context.Context, id string) error {
+	_, err := database.GetCollection("aliases").DeleteMany(ctx, bson.M{"knowledgeId": id})
+	return err
+}
+
+// moveAliases 合并知识条目时把手动维护的别名移到保留条目，保留条目已有同名别名的去掉。
+// 来自字段和标题的别名直接删除，由 syncAliases 按合并后的条目重新生成
+func moveAliases(ctx context.Context, from, to string) error {
+	collection := database.GetCollection("aliases")
+	cursor, err := collection.Find(ctx, bson.M{"knowledgeId": from, "source": model.AliasSourceManual})
+	if err != nil {
+		return err
+	}
+	var aliases []*model.Alias
+	if err := cursor.All(ctx, &aliases); err != nil {
+		return err
+	}
+
+	for _, alias := range aliases {
+		count, err := collection.CountDocuments(ctx, bson.M{"key": alias.Key, "knowledgeId": to})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			_, err = collection.DeleteOne(ctx, bson.M{"_id": alias.ID})
+		} else {
+			_, err = collection.UpdateOne(ctx, bson.M{"_id": alias.ID}, bson.M{"$set": bson.M{"knowledgeId": to}})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return removeAliases(ctx, from)
+}
+
+// aliasExpansion 关键字通过别名注册表扩展后的结果
+type aliasExpansion struct {
+	ids   map[string]string // 直接命中的知识条目 id -> 命中的别名
+	terms []string          // 同义的其他名称，用于在文本字段中继续搜索
+}
+
+// expandAliases 查找关键字命中的别名，并取出这些知识条目的全部同义名称
+func expandAliases(ctx context.Context, keywords []string) (*aliasExpansion, error) {
+	collection := database.GetCollection("aliases")
+	expansion := &aliasExpansion{ids: make(map[string]string)}
+
+	var keys []string
+	original := make(map[string]bool)
+	for _, keyword := range keywords {
+		if key := normalizeAliasKey(keyword); key != "" {
+			keys = append(keys, key)
+			original[key] = true
+		}
+	}
+	if len(keys) == 0 {
+		return expansion, nil
+	}
+
+	var matched []*model.Alias
+	cursor, err := collection.Find(ctx, bson.M{"key": bson.M{"$in": keys}})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &matched); err != nil {
+		return nil, err
+	}
+	if len(matched) == 0 {
+		return expansion, nil
+	}
+
+	var ids []string
+	for _, alias := range matched {
+		if _, ok := expansion.ids[alias.KnowledgeID]; !ok {
+			expansion.ids[alias.KnowledgeID] = alias.Name
+			ids = append(ids, alias.KnowledgeID)
+		}
+	}
+
+	var synonyms []*model.Alias
+	cursor, err = collection.Find(ctx, bson.M{"knowledgeId": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &synonyms); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, alias := range synonyms {
+		if original[alias.Key] || seen[alias.Key] {
+			continue
+		}
+		seen[alias.Key] = true
+		expansion.terms = append(expansion.terms, alias.Name)
+	}
+
+	return expansion, nil
+}
+
+// conditions 生成扩展后的 $or 条件：命中的 id 以及同义名称在指定字段中的匹配
+func (e *aliasExpansion) conditions(fields ...string) []bson.M {
+	var orConditions []bson.M
+	if len(e.ids) > 0 {
+		ids := make([]string, 0, len(e.ids))
+		for id := range e.ids {
+			ids = append(ids, id)
+		}
+		orConditions = append(orConditions, bson.M{"_id": bson.M{"$in": ids}})
+	}
+	for _, term := range e.terms {
+		pattern := regexp.QuoteMeta(term)
+		for _, field := range fields {
+			orConditions = append(orConditions, bson.M{field: bson.M{"$regex": pattern, "$options": "i"}})
+		}
+	}
+	return orConditions
+}
+
+// annotate 为通过别名命中的结果标记匹配到的别名
+func (e *aliasExpansion) annotate(results []*model.Knowledge) {
+	for _, result := range results {
+		if name, ok := e.ids[result.ID]; ok {
+			result.MatchedAlias = name
+			continue
+		}
+		text := strings.ToLower(result.Title + "\n" + result.Abstract + "\n" + result.Content)
+		for _, term := range e.terms {
+			if strings.Contains(text, strings.ToLower(term)) {
+				result.MatchedAlias = term
+				break
+			}
+		}
+	}
+}
+
+// ResolveAlias 将任意名称解析为对应的规范知识条目
+func (r *queryResolver) ResolveAlias(ctx context.Context, name string) ([]*model.AliasResolution, error) {
+	key := normalizeAliasKey(name)
+	if key == "" {
+		return nil, errors.New("name must be provided")
+	}
+
+	aliases := database.GetCollection("aliases")
+	var matched []*model.Alias
+	cursor, err := aliases.Find(ctx, bson.M{"key": key})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &matched); err != nil {
+		return nil, err
+	}
+
+	collection := database.GetCollection("knowledge")
+	results := []*model.AliasResolution{}
+	seen := make(map[string]bool)
+	for _, alias := range matched {
+		if seen[alias.KnowledgeID] {
+			continue
+		}
+		seen[alias.KnowledgeID] = true
+
+		var knowledge model.Knowledge
+		if err := collection.FindOne(ctx, bson.M{"_id": alias.KnowledgeID}).Decode(&knowledge); err != nil {
+			log.Printf("alias %s points to missing knowledge %s: %v", alias.Name, alias.KnowledgeID, err)
+			continue
+		}
+		knowledge.Success = true
+		knowledge.MatchedAlias = alias.Name
+
+		all, err := r.ListAliases(ctx, alias.KnowledgeID)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(all))
+		for _, a := range all {
+			names = append(names, a.Name)
+		}
+
+		results = append(results, &model.AliasResolution{
+			Name:         name,
+			MatchedAlias: alias.Name,
+			Knowledge:    &knowledge,
+			Aliases:      names,
+		})
+	}
+
+	return results, nil
+}
+
+// ListAliases 列出别名注册表，knowledgeID 为空时返回全部
+func (r *queryResolver) ListAliases(ctx context.Context, knowledgeID string) ([]*model.Alias, error) {
+	collection := database.GetCollection("aliases")
+
+	filter := bson.M{}
+	if knowledgeID != "" {
+		id, err := resolveKnowledgeID(ctx, knowledgeID)
+		if err != nil {
+			return nil, err
+		}
+		filter["knowledgeId"] = id
+	}
+
+	results := []*model.Alias{}
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// CreateAlias 手动为知识条目添加别名
+func (r *mutationResolver) CreateAlias(ctx context.Context, input model.NewAlias) (*model.Alias, error) {
+	key := normalizeAliasKey(input.Name)
+	if key == "" || input.KnowledgeID == "" {
+		return nil, errors.New("name and knowledgeId must be provided")
+	}
+
+	knowledge, err := findKnowledge(ctx, input.KnowledgeID)
+	if err != nil {
+		return nil, err
+	}
+	input.KnowledgeID = knowledge.ID
+
+	collection := database.GetCollection("aliases")
+	var existing model.Alias
+	err = collection.FindOne(ctx, bson.M{"key": key, "knowledgeId": input.KnowledgeID}).Decode(&existing)
+	if err == nil {
+		return &existing, nil
+	}
+
+	alias := model.Alias{
+		ID:          primitive.NewObjectID().Hex(),
+		Key:         key,
+		Name:        strings.TrimSpace(input.Name),
+		KnowledgeID: input.KnowledgeID,
+		Source:      model.AliasSourceManual,
+	}
+	if _, err := collection.InsertOne(ctx, alias); err != nil {
+		return nil, err
+	}
+	return &alias, nil
+}
+
+// DeleteAlias 删除一条别名记录
+func (r *mutationResolver) DeleteAlias(ctx context.Context, id string) (*model.DeletionStatus, error) {
+	collection := database.GetCollection("aliases")
+
+	deleteResult, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return &model.DeletionStatus{Success: false, Message: err.Error()}, err
+	}
+	if deleteResult.DeletedCount == 0 {
+		return &model.DeletionStatus{Success: false, Message: "No alias found with that ID"}, nil
+	}
+	return &model.DeletionStatus{Success: true, Message: "Deleted successfully"}, nil
+}
+
+// RebuildAliasRegistry 根据全部知识条目的 alias 字段和标题重建别名注册表
+func (r *mutationResolver) RebuildAliasRegistry(ctx context.Context) (*model.AliasRebuildStatus, error) {
+	aliases := database.GetCollection("aliases")
+	filter := bson.M{"source": bson.M{"$in": []string{model.AliasSourceField, model.AliasSourceTitle}}}
+	if _, err := aliases.DeleteMany(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	collection := database.GetCollection("knowledge")
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	status := &model.AliasRebuildStatus{}
+	for cursor.Next(ctx) {
+		var knowledge model.Knowledge
+		if err := cursor.Decode(&knowledge); err != nil {
+			return nil, err
+		}
+		docs := knowledgeAliases(&knowledge)
+		status.Knowledge++
+		if len(docs) == 0 {
+			continue
+		}
+		if _, err := aliases.InsertMany(ctx, docs); err != nil {
+			return nil, err
+		}
+		status.Aliases += len(docs)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
@@ -0,0 +1,430 @@
+package resolvers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BatchMaxDocuments 单次批量操作最多处理的条目数，避免条件写错时改动整个知识库
+var BatchMaxDocuments = 10000
+
+// batchField 可以批量修改的字段，即 NewKnowledge 中的字段
+type batchField struct {
+	bson  string
+	json  string
+	array bool // []string 字段，可以使用 add、remove
+}
+
+// batchFields JSON 字段名和 bson 字段名都可以使用
+var batchFields, batchFieldList = loadBatchFields()
+
+func loadBatchFields() (map[string]*batchField, []*batchField) {
+	fields := make(map[string]*batchField)
+	var list []*batchField
+	t := reflect.TypeOf(model.NewKnowledge{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		bsonName, _, _ := strings.Cut(f.Tag.Get("bson"), ",")
+		jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if bsonName == "" || bsonName == "-" || bsonName == "_id" {
+			continue
+		}
+		field := &batchField{bson: bsonName, json: jsonName, array: f.Type == reflect.TypeOf([]string{})}
+		fields[bsonName] = field
+		fields[jsonName] = field
+		list = append(list, field)
+	}
+	return fields, list
+}
+
+func lookupBatchField(name string) (*batchField, error) {
+	field, ok := batchFields[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: field %q cannot be edited in batch", ErrInvalidInput, name)
+	}
+	return field, nil
+}
+
+// checkBatchOperations 在读取条目前检查操作是否完整
+func checkBatchOperations(ops []*model.BatchOperation) error {
+	if len(ops) == 0 {
+		return fmt.Errorf("%w: operations must be provided", ErrInvalidInput)
+	}
+	for i, op := range ops {
+		at := fmt.Sprintf("operations[%d]", i)
+		switch op.Op {
+		case model.BatchDelete:
+			if len(ops) > 1 {
+				return fmt.Errorf("%w: %s: delete cannot be combined with other operations", ErrInvalidInput, at)
+			}
+		case model.BatchConfidentiality:
+			value, ok := op.Value.(string)
+			if !ok || util.NormalizeTLP(value) == "" {
+				return fmt.Errorf("%w: %s: unknown confidentiality %v", ErrInvalidInput, at, op.Value)
+			}
+		case model.BatchSet, model.BatchUnset, model.BatchAdd, model.BatchRemove:
+			field, err := lookupBatchField(op.Field)
+			if err != nil {
+				return fmt.Errorf("%s: %w", at, err)
+			}
+			if (op.Op == model.BatchAdd || op.Op == model.BatchRemove) && (!field.array || len(op.Values) == 0) {
+				return fmt.Errorf("%w: %s: %s needs an array field and values", ErrInvalidInput, at, op.Op)
+			}
+			if op.Op == model.BatchSet && op.Value == nil {
+				return fmt.Errorf("%w: %s: set needs a value, use unset to clear a field", ErrInvalidInput, at)
+			}
+		default:
+			return fmt.Errorf("%w: %s: unknown op %q", ErrInvalidInput, at, op.Op)
+		}
+	}
+	return nil
+}
+
+// editableJSON 返回条目可编辑字段的 JSON 形式，字段名与创建接口一致
+func editableJSON(k *model.Knowledge) (map[string]interface{}, error) {
+	doc, err := bson.Marshal(k)
+	if err != nil {
+		return nil, err
+	}
+	var input model.NewKnowledge
+	if err := bson.Unmarshal(doc, &input); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+// applyBatchOperations 在可编辑字段的 JSON 上应用操作，返回修改后的条目
+func applyBatchOperations(before *model.Knowledge, ops []*model.BatchOperation) (*model.Knowledge, error) {
+	m, err := editableJSON(before)
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range ops {
+		if op.Op == model.BatchConfidentiality {
+			m["confidentiality"] = op.Value
+			continue
+		}
+		field, _ := lookupBatchField(op.Field)
+		switch op.Op {
+		case model.BatchSet:
+			m[field.json] = op.Value
+		case model.BatchUnset:
+			delete(m, field.json)
+		case model.BatchAdd, model.BatchRemove:
+			var values []string
+			switch list := m[field.json].(type) {
+			case []string: // 前面的操作已修改过
+				values = list
+			case []interface{}:
+				for _, v := range list {
+					values = append(values, fmt.Sprint(v))
+				}
+			}
+			if op.Op == model.BatchAdd {
+				values = cleanTermNames(append(values, op.Values...))
+			} else {
+				values = removeValues(values, op.Values)
+			}
+			m[field.json] = values
+		}
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var input model.NewKnowledge
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	// 不可编辑的字段(created、kev、epss 等)保持原值
+	var merged bson.M
+	doc, err := bson.Marshal(before)
+	if err != nil {
+		return nil, err
+	}
+	if err := bson.Unmarshal(doc, &merged); err != nil {
+		return nil, err
+	}
+	edited := bson.M{}
+	if doc, err = bson.Marshal(input); err != nil {
+		return nil, err
+	}
+	if err := bson.Unmarshal(doc, &edited); err != nil {
+		return nil, err
+	}
+	for _, field := range batchFieldList {
+		delete(merged, field.bson)
+		if value, ok := edited[field.bson]; ok {
+			merged[field.bson] = value
+		}
+	}
+	if doc, err = bson.Marshal(merged); err != nil {
+		return nil, err
+	}
+	var after model.Knowledge
+	err = bson.Unmarshal(doc, &after)
+	return &after, err
+}
+
+// removeValues 删除数组中的值，不区分大小写
+func removeValues(values, remove []string) []string {
+	drop := make(map[string]bool)
+	for _, v := range remove {
+		drop[strings.ToLower(strings.TrimSpace(v))] = true
+	}
+	var out []string
+	for _, v := range values {
+		if !drop[strings.ToLower(strings.TrimSpace(v))] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// batchUpdate 比较修改前后的可编辑字段，返回变更列表和 update 文档，没有变更时返回 nil
+func batchUpdate(before, after *model.Knowledge) ([]*model.BatchChange, bson.M, error) {
+	beforeJSON, err := editableJSON(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterJSON, err := editableJSON(after)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err := bson.Marshal(after)
+	if err != nil {
+		return nil, nil, err
+	}
+	var afterBSON bson.M
+	if err := bson.Unmarshal(doc, &afterBSON); err != nil {
+		return nil, nil, err
+	}
+
+	var changes []*model.BatchChange
+	set, unset := bson.M{}, bson.M{}
+	for _, field := range batchFieldList {
+		if reflect.DeepEqual(beforeJSON[field.json], afterJSON[field.json]) {
+			continue
+		}
+		changes = append(changes, &model.BatchChange{Field: field.json, Before: beforeJSON[field.json], After: afterJSON[field.json]})
+		if value, ok := afterBSON[field.bson]; ok {
+			set[field.bson] = value
+		} else {
+			unset[field.bson] = ""
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil, nil
+	}
+
+	for field, value := range deriveKnowledge(after) {
+		set[field] = value
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return changes, touchKnowledge(update), nil
+}
+
+// BatchKnowledge 对 ID 列表或查询条件选中的知识条目应用一组操作，通过 BulkWrite 一次写入。
+// 修改后的条目同样经过词表规范化和 schema 校验，不通过的条目记为失败，其他条目照常写入。
+// 试运行时结果中的 updated、deleted 表示将会修改、删除。
+// Atomic 时在事务中执行，有条目失败则全部回滚，报告中 rolledBack 为 true
+func (r *mutationResolver) BatchKnowledge(ctx context.Context, req model.BatchRequest) (*model.BatchReport, error) {
+	if err := checkBatchOperations(req.Operations); err != nil {
+		return nil, err
+	}
+	atomic := req.Atomic && !req.DryRun
+
+	var report *model.BatchReport
+	rolledBack, err := inTransaction(ctx, atomic, func(ctx context.Context) error {
+		var err error
+		report, err = batchKnowledge(ctx, req, atomic)
+		if err == nil && atomic && report.Failed > 0 {
+			return errRollback
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.RolledBack = rolledBack
+	return report, nil
+}
+
+func batchKnowledge(ctx context.Context, req model.BatchRequest, atomic bool) (*model.BatchReport, error) {
+
+	var filter bson.M
+	switch {
+	case len(req.IDs) > 0:
+		filter = bson.M{"_id": bson.M{"$in": req.IDs}}
+	case req.Filter != nil:
+		var err error
+		if filter, err = whereFilter(req.Filter); err != nil {
+			return nil, err
+		}
+		if len(filter) == 0 {
+			return nil, fmt.Errorf("%w: filter matches every document, list the ids instead", ErrInvalidInput)
+		}
+	default:
+		return nil, fmt.Errorf("%w: ids or filter must be provided", ErrInvalidInput)
+	}
+
+	collection := database.GetCollection("knowledge")
+	count, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if count > int64(BatchMaxDocuments) {
+		return nil, fmt.Errorf("%w: %d documents matched, at most %d can be edited at once", ErrInvalidInput, count, BatchMaxDocuments)
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	report := &model.BatchReport{DryRun: req.DryRun, Results: []*model.BatchResult{}}
+	var models []mongo.WriteModel
+	var written []*model.BatchResult // 与 models 一一对应
+	updated := make(map[string]*model.Knowledge)
+	found := make(map[string]bool)
+	deleting := req.Operations[0].Op == model.BatchDelete
+
+	for cursor.Next(ctx) {
+		var before model.Knowledge
+		if err := cursor.Decode(&before); err != nil {
+			return nil, err
+		}
+		found[before.ID] = true
+		report.Matched++
+		result := &model.BatchResult{ID: before.ID, Title: before.Title}
+		report.Results = append(report.Results, result)
+
+		if deleting {
+			result.Status = model.BatchDeleted
+			models = append(models, mongo.NewDeleteOneModel().SetFilter(bson.M{"_id": before.ID}))
+			written = append(written, result)
+			continue
+		}
+
+		after, err := applyBatchOperations(&before, req.Operations)
+		if err == nil {
+			err = normalizeKnowledge(ctx, after, &before)
+		}
+		if err == nil {
+			err = validateKnowledgeSchema(ctx, after)
+		}
+		var changes []*model.BatchChange
+		var update bson.M
+		if err == nil {
+			changes, update, err = batchUpdate(&before, after)
+		}
+		if err != nil {
+			result.Status, result.Error = model.BatchFailed, err.Error()
+			continue
+		}
+		if update == nil {
+			result.Status = model.BatchUnchanged
+			continue
+		}
+
+		result.Status, result.Changes = model.BatchUpdated, changes
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(bson.M{"_id": before.ID}).SetUpdate(update))
+		written = append(written, result)
+		updated[before.ID] = after
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	// 列出的 ID 中不存在的条目
+	for _, id := range req.IDs {
+		if !found[id] {
+			found[id] = true
+			report.Results = append(report.Results, &model.BatchResult{ID: id, Status: model.BatchFailed, Error: "No document found with that ID"})
+		}
+	}
+
+	if !req.DryRun && len(models) > 0 {
+		_, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		var bulkErr mongo.BulkWriteException
+		if errors.As(err, &bulkErr) && !bulkErr.HasErrorLabel("TransientTransactionError") {
+			for _, writeErr := range bulkErr.WriteErrors {
+				written[writeErr.Index].Status = model.BatchFailed
+				written[writeErr.Index].Error = writeErr.Message
+			}
+			// 事务中出现写入错误后事务已经失效，整体回滚，不再同步别名等
+			if atomic {
+				written = nil
+			}
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, result := range written {
+			switch result.Status {
+			case model.BatchUpdated:
+				after := updated[result.ID]
+				if err := syncAliases(ctx, after); err != nil {
+					log.Println("Failed to sync aliases:", err)
+				}
+				if err := syncIndicators(ctx, result.ID, after.IoC); err != nil {
+					log.Println("Failed to sync indicators:", err)
+				}
+				if err := refreshDetectionRules(ctx, after); err != nil {
+					log.Println("Failed to refresh detection rules:", err)
+				}
+			case model.BatchDeleted:
+				if err := removeAliases(ctx, result.ID); err != nil {
+					log.Println("Failed to remove aliases:", err)
+				}
+				if err := removeIndicators(ctx, result.ID); err != nil {
+					log.Println("Failed to remove indicators:", err)
+				}
+				if err := removeDetectionRules(ctx, result.ID); err != nil {
+					log.Println("Failed to remove detection rules:", err)
+				}
+				if err := removeRelationships(ctx, result.ID); err != nil {
+					log.Println("Failed to remove relationships:", err)
+				}
+			}
+		}
+	}
+
+	for _, result := range report.Results {
+		switch result.Status {
+		case model.BatchUpdated:
+			report.Updated++
+		case model.BatchDeleted:
+			report.Deleted++
+		case model.BatchUnchanged:
+			report.Unchanged++
+		case model.BatchFailed:
+			report.Failed++
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,352 @@
+package resolvers
+
+import (
+	"context"
+	"math"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// knowledgeFromInput 将输入转换为知识文档
+func knowledgeFromInput(input model.NewKnowledge) model.Knowledge {
+	return model.Knowledge{
+		ID:                  input.ID,
+		Title:               input.Title,
+		Tags:                input.Tags,
+		TechniquesID:        input.TechniquesID,
+		TacticsID:           input.TacticsID,
+		KnowledgeType:       input.KnowledgeType,
+		KnowledgeSource:     input.KnowledgeSource,
+		Confidentiality:     input.Confidentiality,
+		Abstract:            input.Abstract,
+		Content:             input.Content,
+		Detection:           input.Detection,
+		Mitigations:         input.Mitigations,
+		Recommendations:     input.Recommendations,
+		Directory:           input.Directory,
+		Techniques:          input.Techniques,
+		Tactics:             input.Tactics,
+		UsedExploits:        input.UsedExploits,
+		Alias:               input.Alias,
+		VulType:             input.VulType,
+		Affiliation:         input.Affiliation,
+		UsedTools:           input.UsedTools,
+		StrategicCapability: input.StrategicCapability,
+		FirstActivity:       input.FirstActivity,
+		LatestActivity:      input.LatestActivity,
+		TargetedGeography:   input.TargetedGeography,
+		TimeLine:            input.TimeLine,
+		Scenario:            input.Scenario,
+		Motivations:         input.Motivations,
+		TargetedIndustry:    input.TargetedIndustry,
+		Preparation:         input.Preparation,
+		Alert:               input.Alert,
+		Analysis:            input.Analysis,
+		Traces:              input.Traces,
+		Containment:         input.Containment,
+		Eradication:         input.Eradication,
+		Recovery:            input.Recovery,
+		FollowUp:            input.FollowUp,
+		DisposalProcess:     input.DisposalProcess,
+		Cases:               input.Cases,
+		Cve:                 input.Cve,
+		Cnnvd:               input.Cnnvd,
+		Cwd:                 input.Cwd,
+		Cvss:                input.Cvss,
+		Bugtraq:             input.Bugtraq,
+		CvssStr:             input.CvssStr,
+		Msf:                 input.Msf,
+		Exploitdb:           input.Exploitdb,
+		IsExp:               input.IsExp,
+		Vendor:              input.Vendor,
+		AppType:             input.AppType,
+		Consequence:         input.Consequence,
+		FingerPrint:         input.FingerPrint,
+		RevisionDate:        input.RevisionDate,
+		Products:            input.Products,
+		Reference:           input.Reference,
+		Author:              input.Author,
+		UID:                 input.UID,
+		SubTechniquesID:     input.SubTechniquesID,
+		Platforms:           input.Platforms,
+		AffectedVerison:     input.AffectedVerison,
+		ThreatSeverity:      input.ThreatSeverity,
+		Solution:            input.Solution,
+		Cnvd:                input.Cnvd,
+		Cwe:                 input.Cwe,
+		AppName:             input.AppName,
+		OrganizationIds:     input.OrganizationIds,
+		IoC:                 input.IoC,
+		TiName:              input.TiName,
+		InputParameters:     input.InputParameters,
+		OutputParameters:    input.OutputParameters,
+		AffectedProducts:    input.AffectedProducts,
+	}
+}
+
+// normalizeKnowledge 校验并规范化录入的结构化字段和受词表管理的字段，创建和更新前调用
+func normalizeKnowledge(ctx context.Context, doc *model.Knowledge, stored ...*model.Knowledge) error {
+	products, err := normalizeAffectedProducts(doc.AffectedProducts)
+	if err != nil {
+		return err
+	}
+	doc.AffectedProducts = products
+
+	if err := applyTaxonomy(ctx, doc, stored...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// normalizeMerged 导入时合并到已有条目的内容与其他写入一样经过规范化和 schema 校验。
+// stored 为合并前的条目，set 中的受管字段换成规范化后的值
+func normalizeMerged(ctx context.Context, doc, stored *model.Knowledge, set bson.M) error {
+	if err := normalizeKnowledge(ctx, doc, stored); err != nil {
+		return err
+	}
+	if err := validateKnowledgeSchema(ctx, doc); err != nil {
+		return err
+	}
+	for _, field := range model.TaxonomyFields {
+		if _, ok := set[field]; ok {
+			set[field] = *taxonomyValues(doc, field)
+		}
+	}
+	if _, ok := set["affectedProducts"]; ok {
+		set["affectedProducts"] = doc.AffectedProducts
+	}
+	return nil
+}
+
+// deriveKnowledge 根据原始字段计算结构化的派生字段，返回需要写入数据库的 $set 内容
+func deriveKnowledge(doc *model.Knowledge) bson.M {
+	doc.CvssInfo = deriveCvss(doc.Cvss, doc.CvssStr)
+	doc.ExploitInfo = deriveExploit(doc.IsExp, doc.Msf, doc.Exploitdb)
+	doc.TimelineEvents, doc.Activity = deriveTimeline(doc)
+
+	return bson.M{
+		"cvssInfo":       doc.CvssInfo,
+		"exploitInfo":    doc.ExploitInfo,
+		"timelineEvents": doc.TimelineEvents,
+		"activity":       doc.Activity,
+	}
+}
+
+// touchKnowledge 在 knowledge 集合的更新语句中写入 modified。TAXII 的 added_after 过滤和分页游标都按
+// modified 查询，不更新这个字段的修改订阅方看不到，所有修改知识条目的写操作都要经过这里
+func touchKnowledge(update bson.M) bson.M {
+	set := bson.M{"modified": time.Now().UTC()}
+	if fields, ok := update["$set"].(bson.M); ok {
+		for field, value := range fields {
+			set[field] = value
+		}
+	}
+	update["$set"] = set
+	return update
+}
+
+// changedKnowledge 在 filter 中加上 set 中至少一个字段与现值不同的条件，配合 touchKnowledge 使用，
+// 值没有变化的条目不会被匹配，modified 也就不会变
+func changedKnowledge(filter, set bson.M) bson.M {
+	var or []bson.M
+	for field, value := range set {
+		or = append(or, bson.M{field: bson.M{"$ne": value}})
+	}
+	filter["$or"] = or
+	return filter
+}
+
+// deriveCvss 解析 cvssStr 中的向量并计算分值，与录入的 cvss 分值比对
+func deriveCvss(cvss, cvssStr string) *model.CvssInfo {
+	vector := cvssStr
+	if vector == "" && util.LooksLikeCVSSVector(cvss) {
+		vector = cvss
+	}
+
+	info := &model.CvssInfo{}
+	supplied, hasSupplied := util.ParseCVSSScore(cvss)
+	if util.LooksLikeCVSSVector(cvss) {
+		hasSupplied = false
+	}
+	if hasSupplied {
+		info.SuppliedScore = &supplied
+		info.BaseScore = supplied
+		info.Severity = util.CVSSSeverity(util.CVSSv31, supplied)
+	}
+
+	if vector == "" {
+		if !hasSupplied {
+			return nil
+		}
+		return info
+	}
+
+	v, err := util.ParseCVSS(vector)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+
+	info.Version = v.Version
+	info.Vector = v.String()
+	info.MacroVector = v.MacroVector()
+	if score, ok := v.Score(); ok {
+		info.BaseScore = score
+		info.Computed = true
+		info.Mismatch = hasSupplied && math.Abs(score-supplied) > 0.05
+	}
+	info.Severity = util.CVSSSeverity(v.Version, info.BaseScore)
+
+	return info
+}
+
+// deriveExploit 从 isExp、msf、exploitdb 自由文本中提取利用信息，三者都为空时返回 nil
+func deriveExploit(isExp, msf, exploitdb string) *model.ExploitInfo {
+	if strings.TrimSpace(isExp) == "" && strings.TrimSpace(msf) == "" && strings.TrimSpace(exploitdb) == "" {
+		return nil
+	}
+	info := &model.ExploitInfo{
+		MsfModules:   util.ExtractMsfModules(msf),
+		ExploitDBIDs: util.ExtractExploitDBIDs(exploitdb),
+	}
+	// msf、exploitdb 中可能只写了 "是"、"无" 之类的说明，没有模块路径或编号
+	info.Available = len(info.MsfModules) > 0 || len(info.ExploitDBIDs) > 0 ||
+		util.IsTruthy(isExp) || util.IsTruthy(msf) || util.IsTruthy(exploitdb)
+	return info
+}
+
+// deriveTimeline 解析日期字段和 timeLine 文本，返回按时间排序的事件和活跃范围。
+// revisionDate 是知识条目的修订日期，只记录为事件，不计入活跃范围
+func deriveTimeline(doc *model.Knowledge) ([]*model.TimelineEvent, *model.ActivityRange) {
+	var events []*model.TimelineEvent
+	add := func(d util.Date, text, description, source string) {
+		events = append(events, &model.TimelineEvent{
+			Start:       d.Start,
+			End:         d.End,
+			Precision:   d.Precision,
+			Date:        strings.TrimSpace(text),
+			Description: description,
+			Source:      source,
+		})
+	}
+
+	// "至今"、"2019-present" 表示仍在活跃，范围延续到今天，有日期时保留开始日期
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	ongoing := false
+	untilToday := func(d util.Date) util.Date {
+		if d.Start.IsZero() || d.Start.After(today) {
+			d = util.Date{Start: today, Precision: util.PrecisionDay}
+		}
+		d.End = today
+		ongoing = true
+		return d
+	}
+
+	if d, ok := util.FindDate(doc.FirstActivity); ok {
+		add(d, doc.FirstActivity, "", model.TimelineFirstActivity)
+	}
+	latest, ok := util.FindDate(doc.LatestActivity)
+	if util.Ongoing(doc.LatestActivity) {
+		latest, ok = untilToday(latest), true
+	}
+	if ok {
+		add(latest, doc.LatestActivity, "", model.TimelineLatestActivity)
+	}
+	for _, entry := range util.ParseTimeline(doc.TimeLine) {
+		if entry.Ongoing {
+			entry.Date = untilToday(entry.Date)
+		}
+		add(entry.Date, entry.Text, entry.Description, model.TimelineEntry)
+	}
+	for _, revision := range doc.RevisionDate {
+		if d, ok := util.FindDate(revision); ok {
+			add(d, revision, "", model.TimelineRevision)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+
+	var activity *model.ActivityRange
+	for _, e := range events {
+		if e.Source == model.TimelineRevision {
+			continue
+		}
+		if activity == nil {
+			activity = &model.ActivityRange{Start: e.Start, End: e.End}
+			continue
+		}
+		if e.Start.Before(activity.Start) {
+			activity.Start = e.Start
+		}
+		if e.End.After(activity.End) {
+			activity.End = e.End
+		}
+	}
+	if activity != nil {
+		activity.Ongoing = ongoing
+	}
+	return events, activity
+}
+
+// RefreshDerivedFields 对已有知识重新计算派生字段，用于解析规则更新后回填历史数据
+func (r *mutationResolver) RefreshDerivedFields(ctx context.Context) (*model.RefreshStatus, error) {
+	collection := database.GetCollection("knowledge")
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	status := &model.RefreshStatus{Success: true}
+	for cursor.Next(ctx) {
+		var knowledge model.Knowledge
+		if err := cursor.Decode(&knowledge); err != nil {
+			status.Failed = append(status.Failed, cursor.Current.Lookup("_id").String()+": "+err.Error())
+			continue
+		}
+		status.Scanned++
+
+		set := deriveKnowledge(&knowledge)
+		// 派生字段没有变化的条目不更新，避免回填时所有条目的 modified 都变成当前时间
+		filter, update := changedKnowledge(bson.M{"_id": knowledge.ID}, set), touchKnowledge(bson.M{"$set": set})
+		if knowledge.Modified.IsZero() {
+			// 早期数据没有记录时间，使用 ObjectID 中的创建时间回填
+			created := time.Now().UTC()
+			if oid, err := primitive.ObjectIDFromHex(knowledge.ID); err == nil {
+				created = oid.Timestamp().UTC()
+			}
+			if knowledge.Created.IsZero() {
+				set["created"] = created
+			}
+			set["modified"] = created
+			filter, update = bson.M{"_id": knowledge.ID}, bson.M{"$set": set}
+		}
+		result, err := collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			status.Failed = append(status.Failed, knowledge.ID+": "+err.Error())
+			continue
+		}
+		if result.ModifiedCount > 0 {
+			status.Updated++
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	status.Success = len(status.Failed) == 0
+	return status, nil
+}
@@ -0,0 +1,275 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var detectionIndexOnce sync.Once
+
+func ensureDetectionIndexes() {
+	detectionIndexOnce.Do(func() {
+		_, err := database.GetCollection("detection_rules").Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+			{Keys: bson.D{{Key: "knowledgeId", Value: 1}}},
+			{Keys: bson.D{{Key: "type", Value: 1}}},
+		})
+		if err != nil {
+			log.Println("Failed to create detection rule indexes:", err)
+		}
+	})
+}
+
+// unmatchedTechniques 返回规则声明了、但知识条目 techniquesId/subTechniquesId 中没有的技术。
+// 子技术的父技术已列出时视为一致
+func unmatchedTechniques(k *model.Knowledge, declared []string) []string {
+	known := make(map[string]bool)
+	for _, list := range [][]string{k.TechniquesID, k.SubTechniquesID} {
+		for _, id := range list {
+			known[strings.ToUpper(strings.TrimSpace(id))] = true
+		}
+	}
+
+	var unmatched []string
+	for _, id := range declared {
+		parent, _, _ := strings.Cut(id, ".")
+		if !known[id] && !known[parent] {
+			unmatched = append(unmatched, id)
+		}
+	}
+	return unmatched
+}
+
+// checkDetectionRule 校验规则语法，返回规范化后的规则类型和校验结果
+func checkDetectionRule(ruleType, rule string) (string, *util.RuleCheck, error) {
+	normalized := util.NormalizeRuleType(ruleType)
+	if normalized == "" {
+		return "", nil, errors.New("type must be one of sigma, yara, snort, suricata, kql, spl")
+	}
+	check := util.ValidateRule(normalized, rule)
+	if len(check.Errors) > 0 {
+		return "", nil, &model.RuleValidationError{Type: normalized, Errors: check.Errors}
+	}
+	return normalized, check, nil
+}
+
+// findKnowledge 按 ID 查找知识条目，已被合并的 ID 返回保留条目
+func findKnowledge(ctx context.Context, id string) (*model.Knowledge, error) {
+	id, err := resolveKnowledgeID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var knowledge model.Knowledge
+	err = database.GetCollection("knowledge").FindOne(ctx, bson.M{"_id": id}).Decode(&knowledge)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, errors.New("No document found with that ID")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &knowledge, nil
+}
+
+// ValidateDetectionRule 只校验规则，不保存。knowledgeID 不为空时同时与知识条目的技术做对照
+func (r *queryResolver) ValidateDetectionRule(ctx context.Context, input model.NewDetectionRule) (*model.DetectionRule, error) {
+	ruleType, check, err := checkDetectionRule(input.Type, input.Rule)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &model.DetectionRule{
+		KnowledgeID: input.KnowledgeID,
+		Type:        ruleType,
+		Name:        input.Name,
+		Rule:        input.Rule,
+		Techniques:  check.Techniques,
+	}
+	if rule.Name == "" {
+		rule.Name = check.Name
+	}
+	if input.KnowledgeID != "" {
+		knowledge, err := findKnowledge(ctx, input.KnowledgeID)
+		if err != nil {
+			return nil, err
+		}
+		rule.Unmatched = unmatchedTechniques(knowledge, check.Techniques)
+	}
+	return rule, nil
+}
+
+// CreateDetectionRule 为知识条目添加检测规则，保存前校验语法
+func (r *mutationResolver) CreateDetectionRule(ctx context.Context, input model.NewDetectionRule) (*model.DetectionRule, error) {
+	if input.KnowledgeID == "" {
+		return nil, errors.New("knowledgeId must be provided")
+	}
+	ruleType, check, err := checkDetectionRule(input.Type, input.Rule)
+	if err != nil {
+		return nil, err
+	}
+	knowledge, err := findKnowledge(ctx, input.KnowledgeID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	rule := model.DetectionRule{
+		ID:          primitive.NewObjectID().Hex(),
+		KnowledgeID: knowledge.ID,
+		Type:        ruleType,
+		Name:        input.Name,
+		Rule:        input.Rule,
+		Techniques:  check.Techniques,
+		Unmatched:   unmatchedTechniques(knowledge, check.Techniques),
+		Created:     now,
+		Modified:    now,
+	}
+	if rule.Name == "" {
+		rule.Name = check.Name
+	}
+
+	ensureDetectionIndexes()
+	if _, err := database.GetCollection("detection_rules").InsertOne(ctx, rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// UpdateDetectionRule 修改规则内容，未提供的类型沿用原值
+func (r *mutationResolver) UpdateDetectionRule(ctx context.Context, id string, input model.UpdateDetectionRule) (*model.DetectionRule, error) {
+	collection := database.GetCollection("detection_rules")
+
+	var rule model.DetectionRule
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&rule)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, errors.New("No detection rule found with that ID")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Type == "" {
+		input.Type = rule.Type
+	}
+	if input.Rule == "" {
+		input.Rule = rule.Rule
+	}
+	ruleType, check, err := checkDetectionRule(input.Type, input.Rule)
+	if err != nil {
+		return nil, err
+	}
+	knowledge, err := findKnowledge(ctx, rule.KnowledgeID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.Type = ruleType
+	rule.Rule = input.Rule
+	if input.Name != "" {
+		rule.Name = input.Name
+	} else if check.Name != "" {
+		rule.Name = check.Name
+	}
+	rule.Techniques = check.Techniques
+	rule.Unmatched = unmatchedTechniques(knowledge, check.Techniques)
+	rule.Modified = time.Now().UTC()
+
+	if _, err := collection.ReplaceOne(ctx, bson.M{"_id": id}, rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// DeleteDetectionRule 删除一条检测规则
+func (r *mutationResolver) DeleteDetectionRule(ctx context.Context, id string) (*model.DeletionStatus, error) {
+	deleteResult, err := database.GetCollection("detection_rules").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return &model.DeletionStatus{Success: false, Message: err.Error()}, err
+	}
+	if deleteResult.DeletedCount == 0 {
+		return &model.DeletionStatus{Success: false, Message: "No detection rule found with that ID"}, nil
+	}
+	return &model.DeletionStatus{Success: true, Message: "Deleted successfully"}, nil
+}
+
+// ListDetectionRules 按知识条目或规则类型列出检测规则，两者都为空时返回全部
+func (r *queryResolver) ListDetectionRules(ctx context.Context, knowledgeID, ruleType string) ([]*model.DetectionRule, error) {
+	filter := bson.M{}
+	if knowledgeID != "" {
+		id, err := resolveKnowledgeID(ctx, knowledgeID)
+		if err != nil {
+			return nil, err
+		}
+		filter["knowledgeId"] = id
+	}
+	if ruleType != "" {
+		normalized := util.NormalizeRuleType(ruleType)
+		if normalized == "" {
+			return nil, errors.New("type must be one of sigma, yara, snort, suricata, kql, spl")
+		}
+		filter["type"] = normalized
+	}
+
+	ensureDetectionIndexes()
+	results := []*model.DetectionRule{}
+	findOptions := options.Find().SetSort(bson.D{{Key: "knowledgeId", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := database.GetCollection("detection_rules").Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// refreshDetectionRules 知识条目的技术变化后，重新对照其规则声明的技术
+func refreshDetectionRules(ctx context.Context, k *model.Knowledge) error {
+	collection := database.GetCollection("detection_rules")
+	var rules []*model.DetectionRule
+	cursor, err := collection.Find(ctx, bson.M{"knowledgeId": k.ID}, options.Find().SetProjection(bson.M{"techniques": 1, "unmatched": 1}))
+	if err != nil {
+		return err
+	}
+	if err := cursor.All(ctx, &rules); err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		unmatched := unmatchedTechniques(k, rule.Techniques)
+		if strings.Join(unmatched, ",") == strings.Join(rule.Unmatched, ",") {
+			continue
+		}
+		update := bson.M{"$set": bson.M{"unmatched": unmatched}}
+		if len(unmatched) == 0 {
+			update = bson.M{"$unset": bson.M{"unmatched": ""}}
+		}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": rule.ID}, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveDetectionRules 合并知识条目时把检测规则移到保留条目，未对应的技术由 refreshDetectionRules 重新计算
+func moveDetectionRules(ctx context.Context, from, to string) error {
+	update := bson.M{"$set": bson.M{"knowledgeId": to, "modified": time.Now().UTC()}}
+	_, err := database.GetCollection("detection_rules").UpdateMany(ctx, bson.M{"knowledgeId": from}, update)
+	return err
+}
+
+// removeDetectionRules 删除某个知识条目的全部检测规则
+func removeDetectionRules(ctx context.Context, id string) error {
+	_, err := database.GetCollection("detection_rules").DeleteMany(ctx, bson.M{"knowledgeId": id})
+	return err
+}
@@ -0,0 +1,669 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DuplicateThreshold 默认的文本相似度阈值
+const DuplicateThreshold = 0.8
+
+// DuplicateMinThreshold 可以使用的最低阈值。LSH 分段只让相似度约 0.7 以上的文本进入比较，
+// 更低的阈值找不全，不接受
+const DuplicateMinThreshold = 0.7
+
+const (
+	// duplicateBands LSH 分段数，每段 8 行，相似度约 0.7 以上的文本会被比较
+	duplicateBands = 16
+	// duplicateMinShingles 文本太短时相似度没有意义，不参与文本比较
+	duplicateMinShingles = 20
+	// duplicateGroupLimit 共用同一编号的条目超过这个数量时通常是引用而不是重复，不逐对比较
+	duplicateGroupLimit = 20
+)
+
+var cnvdPattern = regexp.MustCompile(`(?i)CNVD-\d{4}-\d+`)
+
+// mergeProtected 合并时不按字段规则取值的字段：时间由合并过程维护，派生字段重新计算
+var mergeProtected = func() map[string]bool {
+	protected := map[string]bool{"_id": true, "created": true, "modified": true, "success": true, "message": true, "affectedProducts": true}
+	for field := range deriveKnowledge(&model.Knowledge{}) {
+		protected[field] = true
+	}
+	return protected
+}()
+
+// duplicateEntry 检测重复时读取的字段
+type duplicateEntry struct {
+	ID            string   `bson:"_id"`
+	Title         string   `bson:"title"`
+	Content       string   `bson:"content"`
+	Cve           string   `bson:"cve"`
+	Cnvd          string   `bson:"cnvd"`
+	TechniquesID  []string `bson:"techniquesId"`
+	KnowledgeType []string `bson:"knowledgeType"`
+	sig           []uint64
+}
+
+// duplicatePairID 两个条目 ID 按顺序连接，同一对条目只有一条审核记录
+func duplicatePairID(a, b string) string {
+	if a > b {
+		a, b = b, a
+	}
+	return a + "|" + b
+}
+
+// duplicateDetector 汇总各项依据得到的疑似重复对
+type duplicateDetector struct {
+	entries []*duplicateEntry
+	pairs   map[string]*model.DuplicateCandidate
+}
+
+func (d *duplicateDetector) add(a, b *duplicateEntry, reason *model.DuplicateReason) {
+	if a.ID == b.ID {
+		return
+	}
+	if a.ID > b.ID {
+		a, b = b, a
+	}
+	id := duplicatePairID(a.ID, b.ID)
+	candidate, ok := d.pairs[id]
+	if !ok {
+		candidate = &model.DuplicateCandidate{
+			ID:         id,
+			IDs:        []string{a.ID, b.ID},
+			Titles:     []string{a.Title, b.Title},
+			Similarity: util.MinHashSimilarity(a.sig, b.sig),
+		}
+		d.pairs[id] = candidate
+	}
+	for _, existing := range candidate.Reasons {
+		if existing.Type == reason.Type && existing.Value == reason.Value {
+			return
+		}
+	}
+	candidate.Reasons = append(candidate.Reasons, reason)
+}
+
+// byIdentifier 共用 CVE、CNVD 编号，或者知识类型相同且 techniquesId 完全相同的条目
+func (d *duplicateDetector) byIdentifier() {
+	groups := make(map[model.DuplicateReason][]*duplicateEntry)
+	for _, e := range d.entries {
+		for _, cve := range util.ExtractCVEIDs(e.Cve) {
+			key := model.DuplicateReason{Type: model.DuplicateByCve, Value: cve}
+			groups[key] = append(groups[key], e)
+		}
+		for _, cnvd := range cnvdPattern.FindAllString(e.Cnvd, -1) {
+			key := model.DuplicateReason{Type: model.DuplicateByCnvd, Value: strings.ToUpper(cnvd)}
+			groups[key] = append(groups[key], e)
+		}
+		// 不同类型的条目(如组织和技术)引用同样的技术编号很常见，只比较同类型的条目
+		if techniques := cleanTermNames(e.TechniquesID); len(techniques) > 0 {
+			sort.Strings(techniques)
+			value := strings.ToUpper(strings.Join(techniques, ","))
+			for _, kt := range cleanTermNames(e.KnowledgeType) {
+				key := model.DuplicateReason{Type: model.DuplicateByTechniques, Value: kt + ":" + value}
+				groups[key] = append(groups[key], e)
+			}
+		}
+	}
+
+	for key, group := range groups {
+		if len(group) < 2 || len(group) > duplicateGroupLimit {
+			continue
+		}
+		reason := key
+		if reason.Type == model.DuplicateByTechniques {
+			_, reason.Value, _ = strings.Cut(reason.Value, ":")
+		}
+		for i := range group {
+			for j := i + 1; j < len(group); j++ {
+				r := reason
+				d.add(group[i], group[j], &r)
+			}
+		}
+	}
+}
+
+// byText 标题和正文的 MinHash 相似度不低于阈值的条目，先用 LSH 分段找出需要比较的条目
+func (d *duplicateDetector) byText(threshold float64) {
+	buckets := make(map[uint64][]*duplicateEntry)
+	for _, e := range d.entries {
+		if e.sig == nil {
+			continue
+		}
+		for _, key := range util.MinHashBands(e.sig, duplicateBands) {
+			buckets[key] = append(buckets[key], e)
+		}
+	}
+
+	compared := make(map[string]bool)
+	for _, bucket := range buckets {
+		for i := range bucket {
+			for j := i + 1; j < len(bucket); j++ {
+				a, b := bucket[i], bucket[j]
+				id := duplicatePairID(a.ID, b.ID)
+				if compared[id] {
+					continue
+				}
+				compared[id] = true
+				if similarity := util.MinHashSimilarity(a.sig, b.sig); similarity >= threshold {
+					d.add(a, b, &model.DuplicateReason{Type: model.DuplicateByText, Similarity: similarity})
+				}
+			}
+		}
+	}
+}
+
+// DetectDuplicates 扫描全部知识条目，按编号和文本相似度找出疑似重复的条目对，写入审核队列。
+// 已忽略或已合并的记录保持原状态，不再被检测到的待审核记录被删除
+func (r *mutationResolver) DetectDuplicates(ctx context.Context, threshold float64) (*model.DuplicateDetectStatus, error) {
+	if threshold <= 0 {
+		threshold = DuplicateThreshold
+	}
+	if threshold < DuplicateMinThreshold || threshold > 1 {
+		return nil, fmt.Errorf("%w: threshold must be between %g and 1", ErrInvalidInput, DuplicateMinThreshold)
+	}
+
+	projection := bson.M{"title": 1, "content": 1, "cve": 1, "cnvd": 1, "techniquesId": 1, "knowledgeType": 1}
+	cursor, err := database.GetCollection("knowledge").Find(ctx, bson.M{}, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	detector := &duplicateDetector{pairs: make(map[string]*model.DuplicateCandidate)}
+	status := &model.DuplicateDetectStatus{}
+	for cursor.Next(ctx) {
+		var e duplicateEntry
+		if err := cursor.Decode(&e); err != nil {
+			log.Println("Skipping document in duplicate detection:", err)
+			continue
+		}
+		status.Scanned++
+		if shingles := util.Shingles(e.Title + "\n" + e.Content); len(shingles) >= duplicateMinShingles {
+			e.sig = util.MinHash(shingles)
+		}
+		detector.entries = append(detector.entries, &e)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	detector.byIdentifier()
+	detector.byText(threshold)
+	status.Candidates = len(detector.pairs)
+
+	collection := database.GetCollection("duplicate_candidates")
+	// 按数据库保存的毫秒精度截断，本次写入的记录不会被下面的清理误删
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	var models []mongo.WriteModel
+	flush := func() error {
+		if len(models) == 0 {
+			return nil
+		}
+		result, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if result != nil {
+			status.New += int(result.UpsertedCount)
+		}
+		models = models[:0]
+		return err
+	}
+	for _, c := range detector.pairs {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": c.ID}).
+			SetUpdate(bson.M{
+				"$set":         bson.M{"ids": c.IDs, "titles": c.Titles, "reasons": c.Reasons, "similarity": c.Similarity, "detected": now},
+				"$setOnInsert": bson.M{"status": model.DuplicatePending},
+			}).
+			SetUpsert(true))
+		if len(models) >= 500 {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	if _, err := collection.DeleteMany(ctx, bson.M{"status": model.DuplicatePending, "detected": bson.M{"$lt": now}}); err != nil {
+		return nil, err
+	}
+	pending, err := collection.CountDocuments(ctx, bson.M{"status": model.DuplicatePending})
+	if err != nil {
+		return nil, err
+	}
+	status.Pending = int(pending)
+	return status, nil
+}
+
+// DuplicateCandidates 审核队列，按相似度从高到低排列，status 为空时返回待审核的记录
+func (r *queryResolver) DuplicateCandidates(ctx context.Context, status string, nums int) ([]*model.DuplicateCandidate, error) {
+	if status == "" {
+		status = model.DuplicatePending
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "similarity", Value: -1}, {Key: "_id", Value: 1}})
+	if nums > 0 {
+		opts.SetLimit(int64(nums))
+	}
+	cursor, err := database.GetCollection("duplicate_candidates").Find(ctx, bson.M{"status": status}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	candidates := []*model.DuplicateCandidate{}
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// DismissDuplicate 标记为不是重复，之后的检测不会再把它放回待审核
+func (r *mutationResolver) DismissDuplicate(ctx context.Context, id string) (*model.DuplicateCandidate, error) {
+	now := time.Now().UTC()
+	var candidate model.DuplicateCandidate
+	err := database.GetCollection("duplicate_candidates").FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"status": model.DuplicateDismissed, "reviewed": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&candidate)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("No document found with that ID")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &candidate, nil
+}
+
+// isEmptyValue 字段缺失、为空字符串或空列表
+func isEmptyValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case bson.A:
+		return len(v) == 0
+	}
+	return false
+}
+
+// unionValues 列表取并集，保留条目的值在前；文本不同且互不包含时拼接
+func unionValues(w, l interface{}) interface{} {
+	switch w := w.(type) {
+	case bson.A:
+		la, ok := l.(bson.A)
+		if !ok {
+			return w
+		}
+		seen := make(map[string]bool)
+		out := bson.A{}
+		for _, v := range append(append(bson.A{}, w...), la...) {
+			key := fmt.Sprint(v)
+			if s, ok := v.(string); ok {
+				key = strings.ToLower(strings.TrimSpace(s))
+			}
+			if !seen[key] {
+				seen[key] = true
+				out = append(out, v)
+			}
+		}
+		return out
+	case string:
+		ls, ok := l.(string)
+		if !ok || strings.Contains(w, ls) {
+			return w
+		}
+		if strings.Contains(ls, w) {
+			return ls
+		}
+		return w + "\n\n" + ls
+	}
+	return w
+}
+
+// mergeDocuments 按字段规则合并两个条目，返回合并结果和两者不同的字段实际使用的取值方式
+func mergeDocuments(winner, loser bson.M, choices map[string]string) (bson.M, map[string]string) {
+	merged := bson.M{}
+	keys := make(map[string]bool)
+	for key, v := range winner {
+		merged[key] = v
+		keys[key] = true
+	}
+	for key := range loser {
+		keys[key] = true
+	}
+
+	applied := make(map[string]string)
+	for key := range keys {
+		if mergeProtected[key] {
+			continue
+		}
+		w, l := winner[key], loser[key]
+		if reflect.DeepEqual(w, l) {
+			continue
+		}
+		mode, ok := choices[key]
+		if !ok {
+			_, wList := w.(bson.A)
+			_, lList := l.(bson.A)
+			switch {
+			case isEmptyValue(l):
+				mode = model.MergeWinner
+			case isEmptyValue(w):
+				mode = model.MergeLoser
+			case wList && lList:
+				mode = model.MergeUnion
+			default:
+				mode = model.MergeWinner
+			}
+		}
+
+		switch mode {
+		case model.MergeLoser:
+			if l == nil {
+				delete(merged, key)
+			} else {
+				merged[key] = l
+			}
+		case model.MergeUnion:
+			if isEmptyValue(w) {
+				merged[key] = l
+			} else if !isEmptyValue(l) {
+				merged[key] = unionValues(w, l)
+			}
+		}
+		applied[key] = mode
+	}
+	return merged, applied
+}
+
+// earliestTime 两个条目中较早的创建时间
+func earliestTime(a, b interface{}) interface{} {
+	at, aok := a.(primitive.DateTime)
+	bt, bok := b.(primitive.DateTime)
+	switch {
+	case aok && bok && bt < at:
+		return bt
+	case aok:
+		return at
+	case bok:
+		return bt
+	}
+	return a
+}
+
+// MergeKnowledge 将 Loser 合并到 Winner：逐字段合并后写回 Winner，删除 Loser 并将其 ID 重定向到 Winner，
+// 合并前两个条目的完整内容保存在合并记录中。Atomic 时在事务中执行
+func (r *mutationResolver) MergeKnowledge(ctx context.Context, req model.MergeRequest) (*model.MergeResult, error) {
+	if req.Winner == "" || req.Loser == "" {
+		return nil, fmt.Errorf("%w: winner and loser must be provided", ErrInvalidInput)
+	}
+	if req.Winner == req.Loser {
+		return nil, fmt.Errorf("%w: cannot merge a document into itself", ErrInvalidInput)
+	}
+	for field, mode := range req.Fields {
+		if mergeProtected[field] {
+			return nil, fmt.Errorf("%w: %s is maintained by the server and cannot be chosen", ErrInvalidInput, field)
+		}
+		if mode != model.MergeWinner && mode != model.MergeLoser && mode != model.MergeUnion {
+			return nil, fmt.Errorf("%w: unknown merge mode %q for %s", ErrInvalidInput, mode, field)
+		}
+	}
+
+	var result *model.MergeResult
+	_, err := inTransaction(ctx, req.Atomic, func(ctx context.Context) error {
+		var err error
+		result, err = mergeKnowledge(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func mergeKnowledge(ctx context.Context, req model.MergeRequest) (*model.MergeResult, error) {
+	collection := database.GetCollection("knowledge")
+	for _, id := range []*string{&req.Winner, &req.Loser} {
+		var err error
+		if *id, err = resolveKnowledgeID(ctx, *id); err != nil {
+			return nil, err
+		}
+	}
+	if req.Winner == req.Loser {
+		return nil, fmt.Errorf("%w: %s has already been merged into %s", ErrInvalidInput, req.Loser, req.Winner)
+	}
+	var winner, loser bson.M
+	for id, doc := range map[string]*bson.M{req.Winner: &winner, req.Loser: &loser} {
+		err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(doc)
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("No document found with that ID")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	merged, applied := mergeDocuments(winner, loser, req.Fields)
+
+	// 合并结果同样经过词表规范化、schema 校验，并重新计算派生字段
+	data, err := bson.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	var doc model.Knowledge
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: merged document: %v", ErrInvalidInput, err)
+	}
+	// 两个条目中原有的词表外的值不因严格模式被拒绝
+	var stored []*model.Knowledge
+	for _, m := range []bson.M{winner, loser} {
+		data, err := bson.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		var k model.Knowledge
+		if err := bson.Unmarshal(data, &k); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		stored = append(stored, &k)
+	}
+	if err := normalizeKnowledge(ctx, &doc, stored...); err != nil {
+		return nil, err
+	}
+	if err := validateKnowledgeSchema(ctx, &doc); err != nil {
+		return nil, err
+	}
+	derived := deriveKnowledge(&doc)
+	derived["affectedProducts"] = doc.AffectedProducts
+	for _, field := range model.TaxonomyFields {
+		derived[field] = *taxonomyValues(&doc, field)
+	}
+	for field, value := range derived {
+		if value == nil || reflect.ValueOf(value).IsZero() {
+			delete(merged, field)
+		} else {
+			merged[field] = value
+		}
+	}
+	now := time.Now().UTC()
+	merged["created"] = earliestTime(winner["created"], loser["created"])
+	merged["modified"] = now
+
+	if _, err := collection.ReplaceOne(ctx, bson.M{"_id": req.Winner}, merged); err != nil {
+		return nil, err
+	}
+	if _, err := collection.DeleteOne(ctx, bson.M{"_id": req.Loser}); err != nil {
+		return nil, err
+	}
+
+	// 被合并的 ID 和之前重定向到它的 ID 都指向保留条目
+	redirects := database.GetCollection("knowledge_redirects")
+	redirect := &model.KnowledgeRedirect{ID: req.Loser, Target: req.Winner, Merged: now}
+	if _, err := redirects.ReplaceOne(ctx, bson.M{"_id": req.Loser}, redirect, options.Replace().SetUpsert(true)); err != nil {
+		return nil, err
+	}
+	if _, err := redirects.UpdateMany(ctx, bson.M{"target": req.Loser}, bson.M{"$set": bson.M{"target": req.Winner}}); err != nil {
+		return nil, err
+	}
+
+	record := &model.KnowledgeMerge{
+		ID:     primitive.NewObjectID().Hex(),
+		Winner: req.Winner,
+		Loser:  req.Loser,
+		Fields: applied,
+		Before: winner,
+		Merged: loser,
+		Time:   now,
+	}
+	if _, err := database.GetCollection("knowledge_merges").InsertOne(ctx, record); err != nil {
+		return nil, err
+	}
+
+	// 这一对标记为已合并，涉及被合并条目的其他待审核记录已失效
+	candidates := database.GetCollection("duplicate_candidates")
+	if _, err := candidates.UpdateOne(ctx, bson.M{"_id": duplicatePairID(req.Winner, req.Loser)}, bson.M{"$set": bson.M{"status": model.DuplicateMerged, "reviewed": now}}); err != nil {
+		return nil, err
+	}
+	if _, err := candidates.DeleteMany(ctx, bson.M{"ids": req.Loser, "status": model.DuplicatePending}); err != nil {
+		return nil, err
+	}
+
+	// 手动添加的别名、检测规则和关系都是分析人员录入的，移到保留条目而不是删除。
+	// 任何一步失败都返回错误，atomic 时整个合并回滚
+	if err := moveAliases(ctx, req.Loser, req.Winner); err != nil {
+		return nil, err
+	}
+	if err := removeIndicators(ctx, req.Loser); err != nil {
+		return nil, err
+	}
+	if err := moveDetectionRules(ctx, req.Loser, req.Winner); err != nil {
+		return nil, err
+	}
+	if err := moveRelationships(ctx, req.Loser, req.Winner); err != nil {
+		return nil, err
+	}
+
+	var result model.Knowledge
+	if err := collection.FindOne(ctx, bson.M{"_id": req.Winner}).Decode(&result); err != nil {
+		return nil, err
+	}
+	if err := syncAliases(ctx, &result); err != nil {
+		return nil, err
+	}
+	if err := syncIndicators(ctx, result.ID, result.IoC); err != nil {
+		return nil, err
+	}
+	if err := refreshDetectionRules(ctx, &result); err != nil {
+		return nil, err
+	}
+	return &model.MergeResult{Knowledge: &result, Merge: record}, nil
+}
+
+// knowledgeRedirect 被合并条目的 ID 指向的保留条目，没有重定向时返回空
+func knowledgeRedirect(ctx context.Context, id string) (string, error) {
+	var redirect model.KnowledgeRedirect
+	err := database.GetCollection("knowledge_redirects").FindOne(ctx, bson.M{"_id": id}).Decode(&redirect)
+	if err == mongo.ErrNoDocuments {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return redirect.Target, nil
+}
+
+// resolveKnowledgeID 按 ID 操作知识条目前调用：条目不存在且已被合并时返回保留条目的 ID，其他情况原样返回
+func resolveKnowledgeID(ctx context.Context, id string) (string, error) {
+	count, err := database.GetCollection("knowledge").CountDocuments(ctx, bson.M{"_id": id}, options.Count().SetLimit(1))
+	if err != nil || count > 0 {
+		return id, err
+	}
+	target, err := knowledgeRedirect(ctx, id)
+	if err != nil || target == "" {
+		return id, err
+	}
+	return target, nil
+}
+
+// resolveKnowledgeIDs 批量版本的 resolveKnowledgeID，一次查出全部重定向，结果保持顺序并去重
+func resolveKnowledgeIDs(ctx context.Context, ids []string) ([]string, error) {
+	cursor, err := database.GetCollection("knowledge_redirects").Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	var redirects []*model.KnowledgeRedirect
+	if err := cursor.All(ctx, &redirects); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(redirects))
+	for _, redirect := range redirects {
+		targets[redirect.ID] = redirect.Target
+	}
+
+	resolved := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if target, ok := targets[id]; ok {
+			id = target
+		}
+		if !seen[id] {
+			seen[id] = true
+			resolved = append(resolved, id)
+		}
+	}
+	return resolved, nil
+}
+
+// KnowledgeMerges 条目的合并历史，包括被合并到它的条目之前的合并记录，按时间倒序
+func (r *queryResolver) KnowledgeMerges(ctx context.Context, id string) ([]*model.KnowledgeMerge, error) {
+	if target, err := knowledgeRedirect(ctx, id); err != nil {
+		return nil, err
+	} else if target != "" {
+		id = target
+	}
+
+	ids := []string{id}
+	cursor, err := database.GetCollection("knowledge_redirects").Find(ctx, bson.M{"target": id})
+	if err != nil {
+		return nil, err
+	}
+	var redirects []*model.KnowledgeRedirect
+	if err := cursor.All(ctx, &redirects); err != nil {
+		return nil, err
+	}
+	for _, redirect := range redirects {
+		ids = append(ids, redirect.ID)
+	}
+
+	filter := bson.M{"$or": []bson.M{{"winner": bson.M{"$in": ids}}, {"loser": bson.M{"$in": ids}}}}
+	cursor, err = database.GetCollection("knowledge_merges").Find(ctx, filter, options.Find().SetSort(bson.M{"time": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	merges := []*model.KnowledgeMerge{}
+	if err := cursor.All(ctx, &merges); err != nil {
+		return nil, err
+	}
+	return merges, nil
+}
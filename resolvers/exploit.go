@@ -0,0 +1,153 @@
+package resolvers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// annotateKnowledge 写入导入的标注字段，根据结果记录更新或未变化
+func annotateKnowledge(ctx context.Context, collection *mongo.Collection, key, id string, set bson.M) *model.ImportItem {
+	item := &model.ImportItem{Key: key, KnowledgeID: id}
+	// 标注没有变化时不匹配，避免重复导入同一份目录时更新 modified
+	result, err := collection.UpdateOne(ctx, changedKnowledge(bson.M{"_id": id}, set), touchKnowledge(bson.M{"$set": set}))
+	switch {
+	case err != nil:
+		item.Action, item.Error = model.ImportFailed, err.Error()
+	case result.ModifiedCount > 0:
+		item.Action = model.ImportUpdated
+		for field := range set {
+			item.Changes = append(item.Changes, field)
+		}
+	default:
+		item.Action = model.ImportUnchanged
+	}
+	return item
+}
+
+// ImportKEVCatalog 导入本地的 CISA KEV 目录(known_exploited_vulnerabilities.json)，标注已在野利用的漏洞
+func (r *mutationResolver) ImportKEVCatalog(ctx context.Context, path string) (*model.ImportReport, error) {
+	file, err := util.OpenDataFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var catalog model.KEVCatalog
+	if err := json.NewDecoder(file).Decode(&catalog); err != nil {
+		return nil, err
+	}
+
+	collection := database.GetCollection("knowledge")
+	index, err := cveIndex(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.ImportReport{Success: true, Source: path}
+	for _, entry := range catalog.Vulnerabilities {
+		entry.CveID = strings.ToUpper(strings.TrimSpace(entry.CveID))
+		ids := index[entry.CveID]
+		if len(ids) == 0 {
+			report.Add(&model.ImportItem{Key: entry.CveID, Action: model.ImportSkipped, Error: "no knowledge for cve"})
+			continue
+		}
+		for _, id := range ids {
+			kev := entry
+			report.Add(annotateKnowledge(ctx, collection, entry.CveID, id, bson.M{"knownExploited": true, "kev": &kev}))
+		}
+	}
+
+	return report, nil
+}
+
+// ImportEPSSScores 导入本地的 FIRST EPSS CSV 文件(epss_scores-YYYY-MM-DD.csv.gz)，为对应的漏洞标注利用概率。
+// 文件包含全部 CVE，没有对应知识条目的行只计入 skipped，不逐条列出
+func (r *mutationResolver) ImportEPSSScores(ctx context.Context, path string) (*model.ImportReport, error) {
+	file, err := util.OpenDataFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	collection := database.GetCollection("knowledge")
+	index, err := cveIndex(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	report := &model.ImportReport{Success: true, Source: path}
+	date := ""
+	columns := map[string]int{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// 第一行为注释：#model_version:v2023.03.01,score_date:2024-01-01T00:00:00+0000
+		if strings.HasPrefix(record[0], "#") {
+			for _, field := range record {
+				if v, ok := strings.CutPrefix(strings.TrimSpace(field), "score_date:"); ok && len(v) >= 10 {
+					date = v[:10]
+				}
+			}
+			continue
+		}
+		if len(columns) == 0 {
+			for i, name := range record {
+				columns[strings.ToLower(strings.TrimSpace(name))] = i
+			}
+			for _, name := range []string{"cve", "epss", "percentile"} {
+				if _, ok := columns[name]; !ok {
+					return nil, errors.New("invalid EPSS file: missing column " + name)
+				}
+			}
+			continue
+		}
+
+		field := func(name string) string {
+			if i := columns[name]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		cve := strings.ToUpper(field("cve"))
+		ids := index[cve]
+		if len(ids) == 0 {
+			report.Total++
+			report.Skipped++
+			continue
+		}
+
+		score, err1 := strconv.ParseFloat(field("epss"), 64)
+		percentile, err2 := strconv.ParseFloat(field("percentile"), 64)
+		if err1 != nil || err2 != nil {
+			report.Add(&model.ImportItem{Key: cve, Action: model.ImportFailed, Error: "invalid epss score"})
+			continue
+		}
+		epss := &model.EPSSScore{Score: score, Percentile: percentile, Date: date}
+		for _, id := range ids {
+			report.Add(annotateKnowledge(ctx, collection, cve, id, bson.M{"epss": epss}))
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,397 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mongdbs/database"
+	"mongdbs/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	graphMaxHops      = 6
+	graphDefaultLimit = 500
+	graphMaxLimit     = 5000
+)
+
+// graphStep 一跳中可以经过的关系。out 为从关系起点走到终点，in 为从终点走到起点
+type graphStep struct {
+	out, in       map[string]bool
+	allOut, allIn bool
+	filter        bson.M
+}
+
+func newGraphStep(hop *model.GraphHop) (*graphStep, error) {
+	switch hop.Direction {
+	case "", "both", model.RelationshipOutgoing, model.RelationshipIncoming:
+	default:
+		return nil, fmt.Errorf("%w: direction must be outgoing, incoming or both", ErrInvalidInput)
+	}
+	outgoing := hop.Direction != model.RelationshipIncoming
+	incoming := hop.Direction != model.RelationshipOutgoing
+
+	step := &graphStep{out: map[string]bool{}, in: map[string]bool{}}
+	if len(hop.Types) == 0 {
+		// related-to 没有方向，只走一个方向时另一方向的 related-to 也可以经过
+		step.allOut, step.allIn = outgoing, incoming
+		step.out[model.RelationshipRelatedTo] = true
+		step.in[model.RelationshipRelatedTo] = true
+	}
+	for _, t := range hop.Types {
+		if inverse := inverseRelationshipType(t); inverse != "" {
+			step.in[inverse] = true
+			continue
+		}
+		relType, err := normalizeRelationshipType(t)
+		if err != nil {
+			return nil, err
+		}
+		if outgoing || relType == model.RelationshipRelatedTo {
+			step.out[relType] = true
+		}
+		if incoming || relType == model.RelationshipRelatedTo {
+			step.in[relType] = true
+		}
+	}
+
+	if hop.Filter != nil {
+		filter, err := whereFilter(hop.Filter)
+		if err != nil {
+			return nil, err
+		}
+		step.filter = filter
+	}
+	return step, nil
+}
+
+// conditions 从 frontier 中的节点出发可以经过的关系的查询条件
+func (s *graphStep) conditions(frontier []string) []bson.M {
+	var or []bson.M
+	if s.allOut || len(s.out) > 0 {
+		c := bson.M{"source": bson.M{"$in": frontier}}
+		if !s.allOut {
+			c["type"] = bson.M{"$in": stringKeys(s.out)}
+		}
+		or = append(or, c)
+	}
+	if s.allIn || len(s.in) > 0 {
+		c := bson.M{"target": bson.M{"$in": frontier}}
+		if !s.allIn {
+			c["type"] = bson.M{"$in": stringKeys(s.in)}
+		}
+		or = append(or, c)
+	}
+	return or
+}
+
+func (s *graphStep) follows(relType string, fromSource bool) bool {
+	if fromSource {
+		return s.allOut || s.out[relType]
+	}
+	return s.allIn || s.in[relType]
+}
+
+func stringKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// graphMove 遍历中沿一条关系从 from 走到 to
+type graphMove struct {
+	rel      string
+	from, to string
+	hop      int
+}
+
+type graphTraversal struct {
+	result *model.GraphResult
+	nodes  map[string]*model.GraphNode
+	used   map[string]bool // 每条关系只经过一次，避免沿同一条边来回
+	moves  []graphMove
+	limit  int
+}
+
+func (t *graphTraversal) addNode(k *model.Knowledge, depth int) bool {
+	if _, ok := t.nodes[k.ID]; ok {
+		return true
+	}
+	if len(t.nodes) >= t.limit {
+		t.result.Truncated = true
+		return false
+	}
+	node := &model.GraphNode{ID: k.ID, Label: k.Title, KnowledgeType: k.KnowledgeType, Depth: depth}
+	t.nodes[k.ID] = node
+	t.result.Nodes = append(t.result.Nodes, node)
+	return true
+}
+
+func (t *graphTraversal) addEdge(rel *model.Relationship, from, to string, hop int) {
+	if !t.used[rel.ID] {
+		t.used[rel.ID] = true
+		t.result.Edges = append(t.result.Edges, &model.GraphEdge{
+			ID:          rel.ID,
+			Source:      rel.Source,
+			Target:      rel.Target,
+			Type:        rel.Type,
+			Label:       rel.Type,
+			Description: rel.Description,
+			Hop:         hop,
+		})
+	}
+	t.moves = append(t.moves, graphMove{rel: rel.ID, from: from, to: to, hop: hop})
+}
+
+// expand 从 frontier 走一跳，返回新到达的节点。revisit 为 false 时已经到达过的节点只连边，不再扩展
+func (t *graphTraversal) expand(ctx context.Context, hop int, step *graphStep, frontier []string, revisit bool) ([]string, error) {
+	cursor, err := database.GetCollection("relationships").Find(ctx, bson.M{"$or": step.conditions(frontier)}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var relationships []*model.Relationship
+	if err := cursor.All(ctx, &relationships); err != nil {
+		return nil, err
+	}
+
+	inFrontier := make(map[string]bool, len(frontier))
+	for _, id := range frontier {
+		inFrontier[id] = true
+	}
+	type move struct {
+		rel      *model.Relationship
+		from, to string
+	}
+	var moves []move
+	var candidates []string
+	seen := make(map[string]bool)
+	for _, rel := range relationships {
+		if t.used[rel.ID] {
+			continue
+		}
+		if inFrontier[rel.Source] && step.follows(rel.Type, true) {
+			moves = append(moves, move{rel, rel.Source, rel.Target})
+		}
+		if inFrontier[rel.Target] && step.follows(rel.Type, false) {
+			moves = append(moves, move{rel, rel.Target, rel.Source})
+		}
+	}
+	for _, m := range moves {
+		if !seen[m.to] {
+			seen[m.to] = true
+			candidates = append(candidates, m.to)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	// 下一层节点需要满足本跳的条件
+	filter := bson.M{}
+	for k, v := range step.filter {
+		filter[k] = v
+	}
+	filter["_id"] = bson.M{"$in": candidates}
+	matched, err := graphKnowledge(ctx, filter, 0)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*model.Knowledge, len(matched))
+	for _, k := range matched {
+		byID[k.ID] = k
+	}
+
+	var next []string
+	added := make(map[string]bool)
+	for _, m := range moves {
+		k, ok := byID[m.to]
+		if !ok {
+			continue
+		}
+		_, visited := t.nodes[m.to]
+		if !t.addNode(k, hop) {
+			continue
+		}
+		t.addEdge(m.rel, m.from, m.to, hop)
+		if visited && !revisit {
+			continue
+		}
+		if !added[m.to] {
+			added[m.to] = true
+			next = append(next, m.to)
+		}
+	}
+	return next, nil
+}
+
+// prune 只保留从起点走完全部 hops 跳、到达 reached 中节点的路径
+func (t *graphTraversal) prune(hops int, reached []string) {
+	alive := make(map[string]bool)
+	for _, id := range reached {
+		alive[id] = true
+	}
+	keepNodes := make(map[string]bool)
+	keepEdges := make(map[string]bool)
+	for id := range alive {
+		keepNodes[id] = true
+	}
+	for hop := hops; hop >= 1; hop-- {
+		prev := make(map[string]bool)
+		for _, m := range t.moves {
+			if m.hop == hop && alive[m.to] {
+				keepEdges[m.rel] = true
+				keepNodes[m.from] = true
+				prev[m.from] = true
+			}
+		}
+		alive = prev
+	}
+
+	nodes := []*model.GraphNode{}
+	for _, node := range t.result.Nodes {
+		if keepNodes[node.ID] {
+			nodes = append(nodes, node)
+		}
+	}
+	edges := []*model.GraphEdge{}
+	for _, edge := range t.result.Edges {
+		if keepEdges[edge.ID] {
+			edges = append(edges, edge)
+		}
+	}
+	t.result.Nodes, t.result.Edges = nodes, edges
+}
+
+// graphKnowledge 读取节点需要的标题和类型，按 ID 排序
+func graphKnowledge(ctx context.Context, filter bson.M, limit int) ([]*model.Knowledge, error) {
+	findOptions := options.Find().
+		SetProjection(bson.M{"title": 1, "knowledgeType": 1}).
+		SetSort(bson.D{{Key: "_id", Value: 1}})
+	if limit > 0 {
+		findOptions.SetLimit(int64(limit))
+	}
+	cursor, err := database.GetCollection("knowledge").Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	var results []*model.Knowledge
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// graphStart 起点为 StartIDs 或满足 Start 条件的条目，已被合并的 ID 使用合并后的条目
+func graphStart(ctx context.Context, query model.GraphQuery, limit int) ([]*model.Knowledge, error) {
+	var filter bson.M
+	switch {
+	case len(query.StartIDs) > 0:
+		ids, err := resolveKnowledgeIDs(ctx, query.StartIDs)
+		if err != nil {
+			return nil, err
+		}
+		filter = bson.M{"_id": bson.M{"$in": ids}}
+	case query.Start != nil:
+		var err error
+		if filter, err = whereFilter(query.Start); err != nil {
+			return nil, err
+		}
+		if len(filter) == 0 {
+			return nil, fmt.Errorf("%w: start filter matches every document, list the startIds instead", ErrInvalidInput)
+		}
+	default:
+		return nil, fmt.Errorf("%w: startIds or start must be provided", ErrInvalidInput)
+	}
+
+	start, err := graphKnowledge(ctx, filter, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(start) == 0 && len(query.StartIDs) > 0 {
+		return nil, errors.New("No document found with that ID")
+	}
+	return start, nil
+}
+
+// KnowledgeGraph 从起点沿关系逐跳扩展，返回经过的节点和边。
+// 每跳的节点条件作用于 knowledge 集合，$graphLookup 只能按 relationships 集合的字段限制遍历，因此逐层查询
+func (r *queryResolver) KnowledgeGraph(ctx context.Context, query model.GraphQuery) (*model.GraphResult, error) {
+	hops := query.Hops
+	if len(hops) == 0 {
+		depth := query.Depth
+		if depth <= 0 {
+			depth = 1
+		}
+		if depth > graphMaxHops {
+			return nil, fmt.Errorf("%w: depth must not exceed %d", ErrInvalidInput, graphMaxHops)
+		}
+		for i := 0; i < depth; i++ {
+			hops = append(hops, &model.GraphHop{Types: query.Types, Direction: query.Direction, Filter: query.Filter})
+		}
+	}
+	if len(hops) > graphMaxHops {
+		return nil, fmt.Errorf("%w: at most %d hops are allowed", ErrInvalidInput, graphMaxHops)
+	}
+	steps := make([]*graphStep, len(hops))
+	for i, hop := range hops {
+		if hop == nil {
+			return nil, fmt.Errorf("%w: hop %d is empty", ErrInvalidInput, i+1)
+		}
+		var err error
+		if steps[i], err = newGraphStep(hop); err != nil {
+			return nil, err
+		}
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = graphDefaultLimit
+	}
+	if limit > graphMaxLimit {
+		limit = graphMaxLimit
+	}
+
+	start, err := graphStart(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	ensureRelationshipIndexes()
+	t := &graphTraversal{
+		result: &model.GraphResult{Nodes: []*model.GraphNode{}, Edges: []*model.GraphEdge{}},
+		nodes:  make(map[string]*model.GraphNode),
+		used:   make(map[string]bool),
+		limit:  limit,
+	}
+	var frontier []string
+	for _, k := range start {
+		t.addNode(k, 0)
+		frontier = append(frontier, k.ID)
+	}
+
+	// 指定 Hops 时每跳的条件不同，已到达的节点在后面的跳中可以再次扩展；按深度扩展时每个节点只扩展一次
+	revisit := len(query.Hops) > 0
+	completed := 0
+	for i, step := range steps {
+		if len(frontier) == 0 {
+			break
+		}
+		if frontier, err = t.expand(ctx, i+1, step, frontier, revisit); err != nil {
+			return nil, err
+		}
+		completed++
+	}
+
+	if len(query.Hops) > 0 && !query.Partial {
+		var reached []string
+		if completed == len(steps) {
+			reached = frontier
+		}
+		t.prune(len(steps), reached)
+	}
+	return t.result, nil
+}
@@ -0,0 +1,189 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"net"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var indicatorIndexOnce sync.Once
+
+// ensureIndicatorIndexes 为 indicators 集合创建按值查询和按知识条目删除所需的索引
+func ensureIndicatorIndexes() {
+	indicatorIndexOnce.Do(func() {
+		_, err := database.GetCollection("indicators").Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+			{Keys: bson.D{{Key: "value", Value: 1}, {Key: "type", Value: 1}}},
+			{Keys: bson.D{{Key: "type", Value: 1}}},
+			{Keys: bson.D{{Key: "knowledgeId", Value: 1}}},
+		})
+		if err != nil {
+			log.Println("Failed to create indicator indexes:", err)
+		}
+	})
+}
+
+// knowledgeIndicators 从 IoC 字段提取指标记录
+func knowledgeIndicators(id, ioc string) []interface{} {
+	var docs []interface{}
+	for _, indicator := range util.ExtractIndicators(ioc) {
+		docs = append(docs, model.Indicator{
+			ID:          primitive.NewObjectID().Hex(),
+			Type:        indicator.Type,
+			Value:       indicator.Value,
+			KnowledgeID: id,
+		})
+	}
+	return docs
+}
+
+// syncIndicators 用知识条目当前的 IoC 字段刷新 indicators 集合
+func syncIndicators(ctx context.Context, id, ioc string) error {
+	ensureIndicatorIndexes()
+	collection := database.GetCollection("indicators")
+
+	if _, err := collection.DeleteMany(ctx, bson.M{"knowledgeId": id}); err != nil {
+		return err
+	}
+
+	docs := knowledgeIndicators(id, ioc)
+	if len(docs) == 0 {
+		return nil
+	}
+	_, err := collection.InsertMany(ctx, docs)
+	return err
+}
+
+// removeIndicators 删除某个知识条目的全部指标
+func removeIndicators(ctx context.Context, id string) error {
+	_, err := database.GetCollection("indicators").DeleteMany(ctx, bson.M{"knowledgeId": id})
+	return err
+}
+
+// SearchIndicator 查找提及某个指标的全部知识条目。值会先还原去武装化写法并规范化；
+// 查询 IP 时同时返回记录了包含该 IP 的网段的条目
+func (r *queryResolver) SearchIndicator(ctx context.Context, value string) (*model.IndicatorSearchResult, error) {
+	indicator, ok := util.NormalizeIndicator(value)
+	if !ok {
+		return nil, errors.New("value is not a recognized indicator")
+	}
+	ensureIndicatorIndexes()
+
+	collection := database.GetCollection("indicators")
+	var hits []*model.Indicator
+	cursor, err := collection.Find(ctx, bson.M{"type": indicator.Type, "value": indicator.Value})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &hits); err != nil {
+		return nil, err
+	}
+
+	if ip := net.ParseIP(indicator.Value); ip != nil {
+		var networks []*model.Indicator
+		cursor, err := collection.Find(ctx, bson.M{"type": util.IndicatorCIDR})
+		if err != nil {
+			return nil, err
+		}
+		if err := cursor.All(ctx, &networks); err != nil {
+			return nil, err
+		}
+		for _, n := range networks {
+			if _, network, err := net.ParseCIDR(n.Value); err == nil && network.Contains(ip) {
+				hits = append(hits, n)
+			}
+		}
+	}
+
+	result := &model.IndicatorSearchResult{
+		Query:   value,
+		Type:    indicator.Type,
+		Value:   indicator.Value,
+		Matches: []*model.IndicatorMatch{},
+	}
+	byKnowledge := make(map[string]*model.IndicatorMatch)
+	var ids []string
+	for _, hit := range hits {
+		match, ok := byKnowledge[hit.KnowledgeID]
+		if !ok {
+			match = &model.IndicatorMatch{}
+			byKnowledge[hit.KnowledgeID] = match
+			ids = append(ids, hit.KnowledgeID)
+		}
+		match.Indicators = append(match.Indicators, hit)
+	}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var knowledge []*model.Knowledge
+	cursor, err = database.GetCollection("knowledge").Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &knowledge); err != nil {
+		return nil, err
+	}
+	found := make(map[string]*model.Knowledge, len(knowledge))
+	for _, k := range knowledge {
+		found[k.ID] = k
+	}
+	for _, id := range ids {
+		if k, ok := found[id]; ok {
+			match := byKnowledge[id]
+			match.Knowledge = k
+			result.Matches = append(result.Matches, match)
+		} else {
+			log.Printf("indicator points to missing knowledge %s", id)
+		}
+	}
+
+	return result, nil
+}
+
+// RebuildIndicators 根据全部知识条目的 IoC 字段重建 indicators 集合
+func (r *mutationResolver) RebuildIndicators(ctx context.Context) (*model.IndicatorRebuildStatus, error) {
+	ensureIndicatorIndexes()
+	indicators := database.GetCollection("indicators")
+	if _, err := indicators.DeleteMany(ctx, bson.M{}); err != nil {
+		return nil, err
+	}
+
+	collection := database.GetCollection("knowledge")
+	cursor, err := collection.Find(ctx, bson.M{"ioc": bson.M{"$nin": []interface{}{nil, ""}}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	status := &model.IndicatorRebuildStatus{}
+	for cursor.Next(ctx) {
+		var knowledge model.Knowledge
+		if err := cursor.Decode(&knowledge); err != nil {
+			return nil, err
+		}
+		docs := knowledgeIndicators(knowledge.ID, knowledge.IoC)
+		status.Knowledge++
+		if len(docs) == 0 {
+			continue
+		}
+		if _, err := indicators.InsertMany(ctx, docs); err != nil {
+			return nil, err
+		}
+		status.Indicators += len(docs)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
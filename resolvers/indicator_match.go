@@ -0,0 +1,206 @@
+package resolvers
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"net"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	indicatorBatchSize   = 1000 // 每次 $in 查询的指标数
+	indicatorLineLimit   = 100  // 每个知识条目最多返回的命中行数
+	indicatorLineMaxSize = 500  // 返回的命中行最大长度
+)
+
+// MatchIndicators 逐行提取提交文本(日志、指标列表)中的指标，批量与知识库中的 IoC 匹配，
+// 结果按知识条目分组。typeArg 不为空时只返回这些知识类型(如威胁组织、攻击活动)
+func (r *queryResolver) MatchIndicators(ctx context.Context, reader io.Reader, typeArg []string) (*model.BulkIndicatorReport, error) {
+	report := &model.BulkIndicatorReport{Matches: []*model.BulkIndicatorMatch{}}
+
+	// 指标 -> 出现的行号
+	occurrences := make(map[util.Indicator][]int)
+	var order []util.Indicator
+	lines := make(map[int]string)
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		report.Lines++
+		line := scanner.Text()
+		indicators := util.ExtractIndicators(line)
+		if len(indicators) == 0 {
+			continue
+		}
+		if len(line) > indicatorLineMaxSize {
+			line = line[:indicatorLineMaxSize]
+		}
+		lines[report.Lines] = line
+		for _, indicator := range indicators {
+			if _, ok := occurrences[indicator]; !ok {
+				order = append(order, indicator)
+			}
+			occurrences[indicator] = append(occurrences[indicator], report.Lines)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	report.Indicators = len(order)
+	if len(order) == 0 {
+		return report, nil
+	}
+
+	ensureIndicatorIndexes()
+	collection := database.GetCollection("indicators")
+
+	// 命中的库中指标 -> 提交文本中对应的指标
+	type hit struct {
+		stored    *model.Indicator
+		submitted util.Indicator
+	}
+	var hits []hit
+
+	for start := 0; start < len(order); start += indicatorBatchSize {
+		end := start + indicatorBatchSize
+		if end > len(order) {
+			end = len(order)
+		}
+		values := make([]string, 0, end-start)
+		wanted := make(map[util.Indicator]bool, end-start)
+		for _, indicator := range order[start:end] {
+			values = append(values, indicator.Value)
+			wanted[indicator] = true
+		}
+
+		var stored []*model.Indicator
+		cursor, err := collection.Find(ctx, bson.M{"value": bson.M{"$in": values}})
+		if err != nil {
+			return nil, err
+		}
+		if err := cursor.All(ctx, &stored); err != nil {
+			return nil, err
+		}
+		for _, s := range stored {
+			key := util.Indicator{Type: s.Type, Value: s.Value}
+			if wanted[key] {
+				hits = append(hits, hit{stored: s, submitted: key})
+			}
+		}
+	}
+
+	// 提交的 IP 落在库中记录的网段内也算命中
+	var networks []*model.Indicator
+	cursor, err := collection.Find(ctx, bson.M{"type": util.IndicatorCIDR})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &networks); err != nil {
+		return nil, err
+	}
+	if len(networks) > 0 {
+		parsed := make([]*net.IPNet, len(networks))
+		for i, n := range networks {
+			_, parsed[i], _ = net.ParseCIDR(n.Value)
+		}
+		for _, indicator := range order {
+			if indicator.Type != util.IndicatorIPv4 && indicator.Type != util.IndicatorIPv6 {
+				continue
+			}
+			ip := net.ParseIP(indicator.Value)
+			for i, network := range parsed {
+				if network != nil && network.Contains(ip) {
+					hits = append(hits, hit{stored: networks[i], submitted: indicator})
+				}
+			}
+		}
+	}
+
+	// 按知识条目分组
+	groups := make(map[string]*model.BulkIndicatorMatch)
+	groupLines := make(map[string]map[int]bool)
+	submitted := make(map[string][]util.Indicator)
+	var ids []string
+	for _, h := range hits {
+		group, ok := groups[h.stored.KnowledgeID]
+		if !ok {
+			group = &model.BulkIndicatorMatch{}
+			groups[h.stored.KnowledgeID] = group
+			groupLines[h.stored.KnowledgeID] = make(map[int]bool)
+			ids = append(ids, h.stored.KnowledgeID)
+		}
+		submitted[h.stored.KnowledgeID] = append(submitted[h.stored.KnowledgeID], h.submitted)
+		if !containsIndicator(group.Indicators, h.stored) {
+			group.Indicators = append(group.Indicators, h.stored)
+		}
+		for _, n := range occurrences[h.submitted] {
+			groupLines[h.stored.KnowledgeID][n] = true
+		}
+	}
+	if len(ids) == 0 {
+		return report, nil
+	}
+
+	filter := bson.M{"_id": bson.M{"$in": ids}}
+	if len(typeArg) > 0 {
+		filter["knowledgeType"] = bson.M{"$in": typeArg}
+	}
+	var knowledge []*model.Knowledge
+	cursor, err = database.GetCollection("knowledge").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &knowledge); err != nil {
+		return nil, err
+	}
+
+	// 命中数只统计按 typeArg 过滤后仍返回的知识条目
+	matched := make(map[util.Indicator]bool)
+	for _, k := range knowledge {
+		group := groups[k.ID]
+		group.Knowledge = k
+		for _, indicator := range submitted[k.ID] {
+			matched[indicator] = true
+		}
+
+		group.Count = len(group.Indicators)
+
+		numbers := make([]int, 0, len(groupLines[k.ID]))
+		for n := range groupLines[k.ID] {
+			numbers = append(numbers, n)
+		}
+		sort.Ints(numbers)
+		if len(numbers) > indicatorLineLimit {
+			numbers = numbers[:indicatorLineLimit]
+			group.Truncated = true
+		}
+		for _, n := range numbers {
+			group.Lines = append(group.Lines, &model.IndicatorLine{Number: n, Text: lines[n]})
+		}
+
+		report.Matches = append(report.Matches, group)
+	}
+
+	report.Matched = len(matched)
+
+	sort.SliceStable(report.Matches, func(i, j int) bool {
+		return report.Matches[i].Count > report.Matches[j].Count
+	})
+
+	return report, nil
+}
+
+func containsIndicator(list []*model.Indicator, indicator *model.Indicator) bool {
+	for _, i := range list {
+		if i.Type == indicator.Type && i.Value == indicator.Value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,317 @@
+package resolvers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mongdbs/database"
+	"mongdbs/model"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrInvalidInput 提交的内容不符合种类的结构，如包含其他种类的字段
+var ErrInvalidInput = errors.New("invalid input")
+
+// bsonFieldNames 返回结构体的 bson 字段名，展开 inline 的嵌入结构
+func bsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("bson")
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && strings.Contains(opts, "inline") {
+			names = append(names, bsonFieldNames(f.Type)...)
+			continue
+		}
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// kindFields 返回种类可以录入的字段，不包括 _id 和服务端维护的时间
+func kindFields(kind string) []string {
+	v := model.NewTypedKnowledge(kind)
+	if v == nil {
+		return nil
+	}
+	var fields []string
+	for _, name := range bsonFieldNames(reflect.TypeOf(v).Elem()) {
+		if name != "_id" && name != "created" && name != "modified" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// decodeTypedKnowledge 按 knowledgeType 判别种类解码文档，无法判别的旧数据解码为扁平的 Knowledge
+func decodeTypedKnowledge(raw bson.Raw) (model.TypedKnowledge, error) {
+	var probe struct {
+		KnowledgeType []string `bson:"knowledgeType"`
+	}
+	if err := bson.Unmarshal(raw, &probe); err != nil {
+		return nil, err
+	}
+
+	kind := model.KindOf(probe.KnowledgeType)
+	v := model.NewTypedKnowledge(kind)
+	if v == nil {
+		var k model.Knowledge
+		if err := bson.Unmarshal(raw, &k); err != nil {
+			return nil, err
+		}
+		return &k, nil
+	}
+	if err := bson.Unmarshal(raw, v); err != nil {
+		return nil, err
+	}
+	v.(interface{ Base() *model.KnowledgeBase }).Base().Kind = kind
+	return v, nil
+}
+
+// decodeKindInput 解析提交的 JSON，不允许出现种类之外的字段
+func decodeKindInput(kind string, data []byte, v model.TypedKnowledge) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidInput, kind, err)
+	}
+	return nil
+}
+
+func checkKindType(kind string, v model.TypedKnowledge) error {
+	base := v.(interface{ Base() *model.KnowledgeBase }).Base()
+	if len(base.KnowledgeType) == 0 {
+		base.KnowledgeType = []string{model.KindInfoOf(kind).KnowledgeType}
+	}
+	if actual := model.KindOf(base.KnowledgeType); actual != kind {
+		return fmt.Errorf("%w: knowledgeType %v is not a %s", ErrInvalidInput, base.KnowledgeType, kind)
+	}
+	return nil
+}
+
+// KnowledgeKinds 返回全部种类及其字段
+func (r *queryResolver) KnowledgeKinds(ctx context.Context) ([]*model.KindInfo, error) {
+	var kinds []*model.KindInfo
+	for _, info := range model.Kinds {
+		kind := *info
+		kind.Fields = kindFields(info.Kind)
+		kinds = append(kinds, &kind)
+	}
+	return kinds, nil
+}
+
+// TypedKnowledgeByID 按种类返回知识条目
+func (r *queryResolver) TypedKnowledgeByID(ctx context.Context, id string) (model.TypedKnowledge, error) {
+	id, err := resolveKnowledgeID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := database.GetCollection("knowledge").FindOne(ctx, bson.M{"_id": id}).Raw()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, errors.New("No document found with that ID")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeTypedKnowledge(raw)
+}
+
+// ListTypedKnowledge 列出某一种类的知识条目
+func (r *queryResolver) ListTypedKnowledge(ctx context.Context, kind string, nums int) ([]model.TypedKnowledge, error) {
+	info := model.KindInfoOf(kind)
+	if info == nil {
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidInput, kind)
+	}
+
+	var keywords []string
+	for _, keyword := range info.Keywords {
+		keywords = append(keywords, regexp.QuoteMeta(keyword))
+	}
+	filter := bson.M{"knowledgeType": bson.M{"$regex": strings.Join(keywords, "|"), "$options": "i"}}
+	cursor, err := database.GetCollection("knowledge").Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []model.TypedKnowledge{}
+	for cursor.Next(ctx) {
+		v, err := decodeTypedKnowledge(cursor.Current)
+		if err != nil {
+			return nil, err
+		}
+		// 同时命中多个种类关键字的条目按 KindOf 的顺序归类
+		if v.KnowledgeKind() != kind {
+			continue
+		}
+		results = append(results, v)
+		if nums > 0 && len(results) >= nums {
+			break
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// CreateTypedKnowledge 按种类创建知识条目，knowledgeType 为空时使用种类的默认类型
+func (r *mutationResolver) CreateTypedKnowledge(ctx context.Context, kind string, data []byte) (model.TypedKnowledge, error) {
+	v := model.NewTypedKnowledge(kind)
+	if v == nil {
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidInput, kind)
+	}
+	if err := decodeKindInput(kind, data, v); err != nil {
+		return nil, err
+	}
+	if err := checkKindType(kind, v); err != nil {
+		return nil, err
+	}
+
+	// 各种类的字段名与扁平结构一致，经 bson 转换为 NewKnowledge 后走原有的创建流程
+	doc, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var input model.NewKnowledge
+	if err := bson.Unmarshal(doc, &input); err != nil {
+		return nil, err
+	}
+	created, err := r.CreateKnowledge(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return (&queryResolver{r.Resolver}).TypedKnowledgeByID(ctx, created.ID)
+}
+
+// UpdateTypedKnowledge 按种类修改知识条目。只修改提交的字段，字段值为空时删除；
+// 不属于该种类的字段保持不变
+func (r *mutationResolver) UpdateTypedKnowledge(ctx context.Context, kind, id string, data []byte) (model.TypedKnowledge, error) {
+	collection := database.GetCollection("knowledge")
+	v := model.NewTypedKnowledge(kind)
+	if v == nil {
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidInput, kind)
+	}
+
+	id, err := resolveKnowledgeID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := collection.FindOne(ctx, bson.M{"_id": id}).Raw()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, errors.New("No document found with that ID")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var existing model.Knowledge
+	if err := bson.Unmarshal(raw, &existing); err != nil {
+		return nil, err
+	}
+	if actual := model.KindOf(existing.KnowledgeType); actual != kind {
+		return nil, fmt.Errorf("%w: knowledge %s is not a %s", ErrInvalidInput, id, kind)
+	}
+
+	// 先载入现有值，再用提交的 JSON 覆盖，未提交的字段保持原值
+	if err := bson.Unmarshal(raw, v); err != nil {
+		return nil, err
+	}
+	if err := decodeKindInput(kind, data, v); err != nil {
+		return nil, err
+	}
+	base := v.(interface{ Base() *model.KnowledgeBase }).Base()
+	base.ID = id
+	if err := checkKindType(kind, v); err != nil {
+		return nil, err
+	}
+
+	// 用种类的字段替换扁平结构中的对应字段，再做规范化和派生
+	typedDoc, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var values bson.M
+	if err := bson.Unmarshal(typedDoc, &values); err != nil {
+		return nil, err
+	}
+	var merged bson.M
+	if err := bson.Unmarshal(raw, &merged); err != nil {
+		return nil, err
+	}
+	fields := kindFields(kind)
+	for _, field := range fields {
+		delete(merged, field)
+		if value, ok := values[field]; ok {
+			merged[field] = value
+		}
+	}
+	mergedDoc, err := bson.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	var updated model.Knowledge
+	if err := bson.Unmarshal(mergedDoc, &updated); err != nil {
+		return nil, err
+	}
+	if err := normalizeKnowledge(ctx, &updated, &existing); err != nil {
+		return nil, err
+	}
+	if err := validateKnowledgeSchema(ctx, &updated); err != nil {
+		return nil, err
+	}
+
+	normalizedDoc, err := bson.Marshal(&updated)
+	if err != nil {
+		return nil, err
+	}
+	var normalized bson.M
+	if err := bson.Unmarshal(normalizedDoc, &normalized); err != nil {
+		return nil, err
+	}
+	set, unset := bson.M{}, bson.M{}
+	for _, field := range fields {
+		if value, ok := normalized[field]; ok {
+			set[field] = value
+		} else {
+			unset[field] = ""
+		}
+	}
+	for field, value := range deriveKnowledge(&updated) {
+		set[field] = value
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": id}, touchKnowledge(update)); err != nil {
+		return nil, err
+	}
+
+	if err := syncAliases(ctx, &updated); err != nil {
+		log.Println("Failed to sync aliases:", err)
+	}
+	if err := syncIndicators(ctx, id, updated.IoC); err != nil {
+		log.Println("Failed to sync indicators:", err)
+	}
+	if err := refreshDetectionRules(ctx, &updated); err != nil {
+		log.Println("Failed to refresh detection rules:", err)
+	}
+
+	return (&queryResolver{r.Resolver}).TypedKnowledgeByID(ctx, id)
+}
@@ -0,0 +1,289 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migration 一次数据结构变更。Up、Down 可能因中断而重复执行，需要保证重复执行结果不变；
+// Down 为空表示无法撤销
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context) error
+	Down        func(ctx context.Context) error
+}
+
+// migrations 按版本号递增登记，已发布的迁移不能再修改，只能追加新的版本
+var migrations = []*Migration{
+	{
+		Version:     1,
+		Description: "move affectedVersion, IoC, TiName written by UpdateKnowledge to affectedVerison, ioc, tiName",
+		// 修改时写入的是错误字段名，错误字段中的值比正确字段中的新，直接覆盖。
+		// 之后无法区分哪些值来自错误字段，因此不能撤销
+		Up: func(ctx context.Context) error {
+			return renameKnowledgeFields(ctx, map[string]string{
+				"affectedVersion": "affectedVerison",
+				"IoC":             "ioc",
+				"TiName":          "tiName",
+			})
+		},
+	},
+	{
+		Version:     2,
+		Description: "compute cvssInfo base score and severity for CVSS 4.0 vectors",
+		// 之前 4.0 向量不计算分值，重新计算后结果只取决于 cvss、cvssStr，重复执行结果不变
+		Up: recomputeCvss40,
+	},
+}
+
+// recomputeCvss40 重新计算 cvssInfo.version 为 4.0 的条目
+func recomputeCvss40(ctx context.Context) error {
+	collection := database.GetCollection("knowledge")
+	cursor, err := collection.Find(ctx, bson.M{"cvssInfo.version": util.CVSSv40}, options.Find().SetProjection(bson.M{"cvss": 1, "cvssStr": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var models []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var doc model.Knowledge
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		update := touchKnowledge(bson.M{"$set": bson.M{"cvssInfo": deriveCvss(doc.Cvss, doc.CvssStr)}})
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(bson.M{"_id": doc.ID}).SetUpdate(update))
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if len(models) == 0 {
+		return nil
+	}
+	_, err = collection.BulkWrite(ctx, models)
+	return err
+}
+
+const migrationLockID = "lock"
+
+// migrationLockTimeout 超过这个时间的锁视为上次执行中断后遗留的
+const migrationLockTimeout = 30 * time.Minute
+
+// ErrMigrationLocked 其他实例正在执行迁移
+var ErrMigrationLocked = errors.New("another migration is running")
+
+// renameKnowledgeFields 将 knowledge 集合中的字段改名，目标字段已存在时被覆盖
+func renameKnowledgeFields(ctx context.Context, renames map[string]string) error {
+	var exists []bson.M
+	for from := range renames {
+		exists = append(exists, bson.M{from: bson.M{"$exists": true}})
+	}
+	_, err := database.GetCollection("knowledge").UpdateMany(ctx, bson.M{"$or": exists}, touchKnowledge(bson.M{"$rename": renames}))
+	return err
+}
+
+// lockMigrations 在 _migrations 集合中写入锁记录，避免多个实例同时启动时重复执行迁移
+func lockMigrations(ctx context.Context) (func(), error) {
+	collection := database.GetCollection("_migrations")
+	now := time.Now().UTC()
+	if _, err := collection.DeleteOne(ctx, bson.M{"_id": migrationLockID, "locked": bson.M{"$lt": now.Add(-migrationLockTimeout)}}); err != nil {
+		return nil, err
+	}
+	_, err := collection.InsertOne(ctx, bson.M{"_id": migrationLockID, "locked": now})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrMigrationLocked
+	}
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		if _, err := collection.DeleteOne(context.Background(), bson.M{"_id": migrationLockID}); err != nil {
+			log.Println("Failed to release migration lock:", err)
+		}
+	}, nil
+}
+
+// appliedMigrations 读取已执行的迁移，锁记录的 _id 不是数字，不会被读出
+func appliedMigrations(ctx context.Context) (map[int]*model.MigrationRecord, error) {
+	cursor, err := database.GetCollection("_migrations").Find(ctx, bson.M{"_id": bson.M{"$type": "number"}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []*model.MigrationRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	applied := make(map[int]*model.MigrationRecord, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// currentVersion 已执行的最大版本号，没有执行过迁移时为 0
+func currentVersion(applied map[int]*model.MigrationRecord) int {
+	current := 0
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+	return current
+}
+
+func latestVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// checkMigrations 版本号必须为正数并且递增
+func checkMigrations() error {
+	prev := 0
+	for _, m := range migrations {
+		if m.Version <= prev || m.Up == nil {
+			return fmt.Errorf("invalid migration %d: versions must be positive, increasing and have an up step", m.Version)
+		}
+		prev = m.Version
+	}
+	return nil
+}
+
+func (r *queryResolver) MigrationStatus(ctx context.Context) (*model.MigrationStatus, error) {
+	applied, err := appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.MigrationStatus{Current: currentVersion(applied), Latest: latestVersion(), Migrations: []*model.MigrationState{}}
+	for _, m := range migrations {
+		state := &model.MigrationState{Version: m.Version, Description: m.Description, Reversible: m.Down != nil}
+		if record, ok := applied[m.Version]; ok {
+			state.Applied = &record.Applied
+		}
+		status.Migrations = append(status.Migrations, state)
+	}
+	return status, nil
+}
+
+// MigrateUp 依次执行版本号不超过 to 且还没有执行过的迁移，to 为 0 时执行到最新版本。
+// 某个迁移出错时停止，之前执行的迁移保留
+func (r *mutationResolver) MigrateUp(ctx context.Context, to int) (*model.MigrationRun, error) {
+	if err := checkMigrations(); err != nil {
+		return nil, err
+	}
+	if to <= 0 {
+		to = latestVersion()
+	}
+	unlock, err := lockMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &model.MigrationRun{Direction: "up", From: currentVersion(applied), Executed: []*model.MigrationRecord{}}
+	collection := database.GetCollection("_migrations")
+	for _, m := range migrations {
+		if m.Version > to || applied[m.Version] != nil {
+			continue
+		}
+		if err := m.Up(ctx); err != nil {
+			run.To, run.Error = currentVersion(applied), fmt.Sprintf("migration %d: %v", m.Version, err)
+			return run, errors.New(run.Error)
+		}
+		record := &model.MigrationRecord{Version: m.Version, Description: m.Description, Applied: time.Now().UTC()}
+		if _, err := collection.ReplaceOne(ctx, bson.M{"_id": m.Version}, record, options.Replace().SetUpsert(true)); err != nil {
+			return run, err
+		}
+		applied[m.Version] = record
+		run.Executed = append(run.Executed, record)
+		log.Printf("Applied migration %d: %s", m.Version, m.Description)
+	}
+	run.To = currentVersion(applied)
+	return run, nil
+}
+
+// MigrateDown 按版本号从大到小撤销版本号大于 to 的迁移，to 为负数时只撤销最后一个。
+// 遇到无法撤销的迁移时停止
+func (r *mutationResolver) MigrateDown(ctx context.Context, to int) (*model.MigrationRun, error) {
+	if err := checkMigrations(); err != nil {
+		return nil, err
+	}
+	unlock, err := lockMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var versions []int
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+	if to < 0 {
+		to = 0
+		if len(versions) > 1 {
+			to = versions[1]
+		}
+	}
+
+	registered := make(map[int]*Migration, len(migrations))
+	for _, m := range migrations {
+		registered[m.Version] = m
+	}
+
+	run := &model.MigrationRun{Direction: "down", From: currentVersion(applied), Executed: []*model.MigrationRecord{}}
+	collection := database.GetCollection("_migrations")
+	for _, version := range versions {
+		if version <= to {
+			break
+		}
+		m := registered[version]
+		switch {
+		case m == nil:
+			run.Error = fmt.Sprintf("migration %d is not registered in this build", version)
+		case m.Down == nil:
+			run.Error = fmt.Sprintf("migration %d cannot be reverted", version)
+		default:
+			if err := m.Down(ctx); err != nil {
+				run.Error = fmt.Sprintf("migration %d: %v", version, err)
+			}
+		}
+		if run.Error != "" {
+			run.To = currentVersion(applied)
+			return run, errors.New(run.Error)
+		}
+		if _, err := collection.DeleteOne(ctx, bson.M{"_id": version}); err != nil {
+			return run, err
+		}
+		run.Executed = append(run.Executed, applied[version])
+		delete(applied, version)
+		log.Printf("Reverted migration %d: %s", m.Version, m.Description)
+	}
+	run.To = currentVersion(applied)
+	return run, nil
+}
@@ -0,0 +1,422 @@
+package resolvers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	intelType  = "威胁情报"
+	mispSource = "MISP"
+)
+
+var (
+	attackIDPattern = regexp.MustCompile(`\b(TA\d{4}|T\d{4}(?:\.\d{3})?)\b`)
+
+	// knowledgeNamespace 由知识条目 id 生成稳定 UUID 的命名空间
+	knowledgeNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("mongdbs:knowledge"))
+)
+
+// knowledgeUUID 返回知识条目对应的稳定 UUID，id 本身是 UUID 时直接使用
+func knowledgeUUID(id string) uuid.UUID {
+	if u, err := uuid.Parse(id); err == nil {
+		return u
+	}
+	return uuid.NewSHA1(knowledgeNamespace, []byte(id))
+}
+
+// appendUnique 向列表追加不存在的值，返回新列表以及是否有变化
+func appendUnique(list []string, values ...string) ([]string, bool) {
+	changed := false
+	for _, v := range values {
+		if v != "" && !containsString(list, v) {
+			list = append(list, v)
+			changed = true
+		}
+	}
+	return list, changed
+}
+
+// mispIndicatorTypes 指标类型对应的 MISP 属性类型和分类
+var mispIndicatorTypes = map[string][2]string{
+	util.IndicatorIPv4:     {"ip-dst", "Network activity"},
+	util.IndicatorIPv6:     {"ip-dst", "Network activity"},
+	util.IndicatorCIDR:     {"ip-dst", "Network activity"},
+	util.IndicatorDomain:   {"domain", "Network activity"},
+	util.IndicatorURL:      {"url", "Network activity"},
+	util.IndicatorEmail:    {"email-src", "Payload delivery"},
+	util.IndicatorMD5:      {"md5", "Payload delivery"},
+	util.IndicatorSHA1:     {"sha1", "Payload delivery"},
+	util.IndicatorSHA256:   {"sha256", "Payload delivery"},
+	util.IndicatorFilePath: {"filename", "Payload delivery"},
+	util.IndicatorRegistry: {"regkey", "Persistence mechanism"},
+}
+
+// mispDistribution TLP 等级对应的 MISP 分发范围
+var mispDistribution = map[string]string{
+	util.TLPRed:         "0", // Your organisation only
+	util.TLPAmberStrict: "0",
+	util.TLPAmber:       "1", // This community only
+	util.TLPGreen:       "2", // Connected communities
+	util.TLPClear:       "3", // All communities
+}
+
+// mispThreatLevel CVSS 等级对应的 MISP 威胁等级：1 High、2 Medium、3 Low、4 Undefined
+func mispThreatLevel(k *model.Knowledge) string {
+	if k.CvssInfo != nil {
+		switch k.CvssInfo.Severity {
+		case "Critical", "High":
+			return "1"
+		case "Medium":
+			return "2"
+		case "Low", "None":
+			return "3"
+		}
+	}
+	return "4"
+}
+
+// mispEvent 将知识条目转换为 MISP 事件，confidentiality 转换为 TLP 标签
+func mispEvent(k *model.Knowledge) *model.MISPEventWrapper {
+	eventUUID := knowledgeUUID(k.ID)
+	event := model.MISPEvent{
+		UUID:          eventUUID.String(),
+		Info:          k.Title,
+		Date:          time.Now().Format("2006-01-02"),
+		ThreatLevelID: mispThreatLevel(k),
+		Analysis:      "2",
+		Distribution:  "1",
+		Timestamp:     strconv.FormatInt(time.Now().Unix(), 10),
+		Attribute:     []model.MISPAttribute{},
+	}
+	if len(k.RevisionDate) > 0 && len(k.RevisionDate[0]) >= 10 {
+		event.Date = k.RevisionDate[0][:10]
+	}
+	if len(k.Author) > 0 {
+		event.Orgc = &model.MISPOrg{Name: k.Author[0]}
+	}
+
+	if tlp := util.NormalizeTLP(k.Confidentiality); tlp != "" {
+		event.Tag = append(event.Tag, model.MISPTag{Name: strings.ToLower(tlp)})
+		event.Distribution = mispDistribution[tlp]
+	}
+	for _, tag := range k.Tags {
+		event.Tag = append(event.Tag, model.MISPTag{Name: tag})
+	}
+
+	addAttribute := func(attrType, category, value string) {
+		event.Attribute = append(event.Attribute, model.MISPAttribute{
+			UUID:     uuid.NewSHA1(eventUUID, []byte(attrType+"|"+value)).String(),
+			Type:     attrType,
+			Category: category,
+			Value:    value,
+			ToIDs:    category != "External analysis",
+		})
+	}
+	for _, indicator := range util.ExtractIndicators(k.IoC) {
+		t := mispIndicatorTypes[indicator.Type]
+		addAttribute(t[0], t[1], indicator.Value)
+	}
+	for _, cve := range util.ExtractCVEIDs(k.Cve) {
+		addAttribute("vulnerability", "External analysis", cve)
+	}
+	for _, ref := range splitLines(k.Reference) {
+		if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") {
+			addAttribute("link", "External analysis", ref)
+		}
+	}
+
+	// MISP 的 ATT&CK galaxy 只有技术(mitre-attack-pattern)，没有战术，tacticsId 不导出
+	var clusters []model.MISPGalaxyCluster
+	for _, ids := range [][]string{k.TechniquesID, k.SubTechniquesID} {
+		for _, id := range ids {
+			meta, _ := json.Marshal([]string{id})
+			clusters = append(clusters, model.MISPGalaxyCluster{
+				Type:    "mitre-attack-pattern",
+				Value:   id,
+				TagName: fmt.Sprintf("misp-galaxy:mitre-attack-pattern=%q", id),
+				Meta:    map[string]json.RawMessage{"external_id": meta},
+			})
+		}
+	}
+	if len(clusters) > 0 {
+		event.Galaxy = []model.MISPGalaxy{{Name: "Attack Pattern", Type: "mitre-attack-pattern", GalaxyCluster: clusters}}
+	}
+
+	return &model.MISPEventWrapper{Event: event}
+}
+
+// ExportMISPEvent 将单个知识条目导出为 MISP 事件
+func (r *queryResolver) ExportMISPEvent(ctx context.Context, id string) (*model.MISPEventWrapper, error) {
+	knowledge, err := findKnowledge(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return mispEvent(knowledge), nil
+}
+
+// ExportMISPSearch 将组合查询的结果导出为 MISP restSearch 格式
+func (r *queryResolver) ExportMISPSearch(ctx context.Context, where *model.KnowledgeFilter, keyword []string, authors []string, nums int, nodedict string) (*model.MISPSearchResponse, error) {
+	results, err := r.Search(ctx, where, keyword, authors, nums, nodedict)
+	if err != nil {
+		return nil, err
+	}
+	response := &model.MISPSearchResponse{Response: []*model.MISPEventWrapper{}}
+	for _, k := range results {
+		response.Response = append(response.Response, mispEvent(k))
+	}
+	return response, nil
+}
+
+// decodeMISPEvents 兼容单个事件 {"Event":{}}、事件数组以及 restSearch 的 {"response":[...]}
+func decodeMISPEvents(data []byte) ([]*model.MISPEventWrapper, error) {
+	var single model.MISPEventWrapper
+	if err := json.Unmarshal(data, &single); err == nil && single.Event.UUID != "" {
+		return []*model.MISPEventWrapper{&single}, nil
+	}
+	var search model.MISPSearchResponse
+	if err := json.Unmarshal(data, &search); err == nil && len(search.Response) > 0 {
+		return search.Response, nil
+	}
+	var list []*model.MISPEventWrapper
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, errors.New("invalid MISP file: no events found")
+	}
+	return list, nil
+}
+
+// mispRecord 从 MISP 事件中提取出的、需要写入知识条目的内容
+type mispRecord struct {
+	iocs          []string
+	cves          []string
+	references    []string
+	tags          []string
+	tlp           string
+	tactics       []string
+	techniques    []string
+	subTechniques []string
+}
+
+func newMISPRecord(event *model.MISPEvent) *mispRecord {
+	rec := &mispRecord{}
+
+	attributes := append([]model.MISPAttribute{}, event.Attribute...)
+	for _, object := range event.Object {
+		attributes = append(attributes, object.Attribute...)
+	}
+	for _, a := range attributes {
+		value := strings.TrimSpace(a.Value)
+		if value == "" {
+			continue
+		}
+		switch a.Type {
+		case "vulnerability":
+			rec.cves, _ = appendUnique(rec.cves, strings.ToUpper(value))
+		case "link":
+			rec.references, _ = appendUnique(rec.references, value)
+		case "comment", "text", "other":
+		default:
+			// filename|md5 这类复合属性拆成多个值
+			for _, v := range strings.Split(value, "|") {
+				rec.iocs, _ = appendUnique(rec.iocs, strings.TrimSpace(v))
+			}
+		}
+	}
+
+	addAttackIDs := func(text string) {
+		for _, id := range attackIDPattern.FindAllString(text, -1) {
+			switch {
+			case strings.HasPrefix(id, "TA"):
+				rec.tactics, _ = appendUnique(rec.tactics, id)
+			case strings.Contains(id, "."):
+				rec.subTechniques, _ = appendUnique(rec.subTechniques, id)
+				rec.techniques, _ = appendUnique(rec.techniques, id[:strings.Index(id, ".")])
+			default:
+				rec.techniques, _ = appendUnique(rec.techniques, id)
+			}
+		}
+	}
+	for _, galaxy := range event.Galaxy {
+		for _, cluster := range galaxy.GalaxyCluster {
+			if !strings.HasPrefix(cluster.Type, "mitre-") {
+				continue
+			}
+			var externalIDs []string
+			if raw, ok := cluster.Meta["external_id"]; ok {
+				json.Unmarshal(raw, &externalIDs)
+			}
+			if len(externalIDs) > 0 {
+				addAttackIDs(strings.Join(externalIDs, " "))
+			} else {
+				addAttackIDs(cluster.Value + " " + cluster.TagName)
+			}
+		}
+	}
+
+	for _, tag := range event.Tag {
+		name := strings.TrimSpace(tag.Name)
+		switch {
+		case strings.HasPrefix(strings.ToLower(name), "tlp:"):
+			if tlp := util.NormalizeTLP(name); util.TLPLevel(tlp) > util.TLPLevel(rec.tlp) {
+				rec.tlp = tlp
+			}
+		case strings.HasPrefix(name, "misp-galaxy:mitre-"):
+			// 已经通过 Galaxy 映射为 ATT&CK 编号
+			addAttackIDs(name)
+		case name != "":
+			rec.tags, _ = appendUnique(rec.tags, name)
+		}
+	}
+
+	return rec
+}
+
+// mergeMISPEvent 按事件 UUID 创建或补充知识条目。IoC、标签、ATT&CK 编号、CVE 和参考链接合并；
+// confidentiality 取已有值和事件 TLP 标签中更严格的一个，重新导入不会自动放宽
+func (r *mutationResolver) mergeMISPEvent(ctx context.Context, event *model.MISPEvent) *model.ImportItem {
+	collection := database.GetCollection("knowledge")
+	id := strings.ToLower(event.UUID)
+	item := &model.ImportItem{Key: id, KnowledgeID: id}
+	rec := newMISPRecord(event)
+
+	var existing model.Knowledge
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		input := model.NewKnowledge{
+			ID:              id,
+			Title:           event.Info,
+			Abstract:        event.Info,
+			KnowledgeType:   []string{intelType},
+			KnowledgeSource: []string{mispSource},
+			Confidentiality: rec.tlp,
+			Tags:            rec.tags,
+			TacticsID:       rec.tactics,
+			TechniquesID:    rec.techniques,
+			SubTechniquesID: rec.subTechniques,
+			IoC:             strings.Join(rec.iocs, "\n"),
+			Cve:             strings.Join(rec.cves, ","),
+			Reference:       strings.Join(rec.references, "\n"),
+		}
+		if event.Orgc != nil && event.Orgc.Name != "" {
+			input.Author = []string{event.Orgc.Name}
+		}
+		if event.Date != "" {
+			input.RevisionDate = []string{event.Date}
+		}
+		if _, err := r.CreateKnowledge(ctx, input); err != nil {
+			item.Action, item.Error = model.ImportFailed, err.Error()
+			return item
+		}
+		item.Action = model.ImportCreated
+		return item
+	}
+	if err != nil {
+		item.Action, item.Error = model.ImportFailed, err.Error()
+		return item
+	}
+
+	stored := existing
+	set := bson.M{}
+	mergeList := func(name string, field *[]string, values []string) {
+		if merged, changed := appendUnique(*field, values...); changed {
+			*field = merged
+			set[name] = merged
+			item.Changes = append(item.Changes, name)
+		}
+	}
+	mergeText := func(name string, field *string, values []string, split func(string) []string, sep string) {
+		if merged := mergeValues(*field, values, split, sep); merged != *field {
+			*field = merged
+			set[name] = merged
+			item.Changes = append(item.Changes, name)
+		}
+	}
+
+	mergeList("tags", &existing.Tags, rec.tags)
+	mergeList("tacticsId", &existing.TacticsID, rec.tactics)
+	mergeList("techniquesId", &existing.TechniquesID, rec.techniques)
+	mergeList("subTechniquesId", &existing.SubTechniquesID, rec.subTechniques)
+	mergeList("knowledgeSource", &existing.KnowledgeSource, []string{mispSource})
+	mergeText("ioc", &existing.IoC, rec.iocs, splitLines, "\n")
+	mergeText("cve", &existing.Cve, rec.cves, util.SplitList, ",")
+	mergeText("reference", &existing.Reference, rec.references, splitLines, "\n")
+	// 已有值无法识别时按 TLP:RED 处理(见 util.TLPAllows)，不覆盖
+	current := util.TLPLevel(existing.Confidentiality)
+	if rec.tlp != "" && (strings.TrimSpace(existing.Confidentiality) == "" || current >= 0 && util.TLPLevel(rec.tlp) > current) {
+		existing.Confidentiality = rec.tlp
+		set["confidentiality"] = rec.tlp
+		item.Changes = append(item.Changes, "confidentiality")
+	}
+
+	if len(set) == 0 {
+		item.Action = model.ImportUnchanged
+		return item
+	}
+
+	if err := normalizeMerged(ctx, &existing, &stored, set); err != nil {
+		item.Action, item.Error = model.ImportFailed, err.Error()
+		return item
+	}
+	for field, value := range deriveKnowledge(&existing) {
+		set[field] = value
+	}
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": id}, touchKnowledge(bson.M{"$set": set})); err != nil {
+		item.Action, item.Error = model.ImportFailed, err.Error()
+		return item
+	}
+	if err := syncIndicators(ctx, id, existing.IoC); err != nil {
+		log.Println("Failed to sync indicators:", err)
+	}
+	if err := refreshDetectionRules(ctx, &existing); err != nil {
+		log.Println("Failed to refresh detection rules:", err)
+	}
+
+	item.Action = model.ImportUpdated
+	return item
+}
+
+// ImportMISPEvents 导入本地的 MISP 事件 JSON 文件，每个事件对应一条知识条目
+func (r *mutationResolver) ImportMISPEvents(ctx context.Context, path string) (*model.ImportReport, error) {
+	file, err := util.OpenDataFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(file).Decode(&raw); err != nil {
+		return nil, err
+	}
+	events, err := decodeMISPEvents(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.ImportReport{Success: true, Source: path}
+	for _, e := range events {
+		if e == nil || e.Event.UUID == "" {
+			report.Add(&model.ImportItem{Action: model.ImportSkipped, Error: "missing event uuid"})
+			continue
+		}
+		report.Add(r.mergeMISPEvent(ctx, &e.Event))
+	}
+
+	return report, nil
+}
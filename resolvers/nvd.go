@@ -0,0 +1,394 @@
+package resolvers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	vulnerabilityType = "漏洞"
+	nvdSource         = "NVD"
+)
+
+// nvdRecord 从 NVD 记录中提取出的、需要写入知识条目的内容
+type nvdRecord struct {
+	id          string
+	description string
+	score       string
+	vector      string
+	cwes        []string
+	references  []string
+	vendors     []string
+	products    []string
+	modified    string
+	affected    []model.AffectedProduct
+}
+
+func newNVDRecord(cve *model.NVDCve) *nvdRecord {
+	rec := &nvdRecord{id: strings.ToUpper(strings.TrimSpace(cve.ID))}
+
+	for _, d := range cve.Descriptions {
+		if d.Lang == "en" || rec.description == "" {
+			rec.description = strings.TrimSpace(d.Value)
+		}
+		if d.Lang == "en" {
+			break
+		}
+	}
+
+	// 优先使用 v3.1，其次 v3.0、v4.0、v2；同一版本中优先 Primary
+	for _, metrics := range [][]model.NVDCvssMetric{cve.Metrics.CvssMetricV31, cve.Metrics.CvssMetricV30, cve.Metrics.CvssMetricV40, cve.Metrics.CvssMetricV2} {
+		if len(metrics) == 0 {
+			continue
+		}
+		metric := metrics[0]
+		for _, m := range metrics {
+			if m.Type == "Primary" {
+				metric = m
+				break
+			}
+		}
+		rec.vector = metric.CvssData.VectorString
+		rec.score = strconv.FormatFloat(metric.CvssData.BaseScore, 'f', 1, 64)
+		break
+	}
+
+	seen := make(map[string]bool)
+	for _, w := range cve.Weaknesses {
+		for _, d := range w.Description {
+			if strings.HasPrefix(d.Value, "CWE-") && !seen[d.Value] {
+				seen[d.Value] = true
+				rec.cwes = append(rec.cwes, d.Value)
+			}
+		}
+	}
+
+	for _, ref := range cve.References {
+		if ref.URL != "" {
+			rec.references = append(rec.references, ref.URL)
+		}
+	}
+
+	vendors := make(map[string]bool)
+	products := make(map[string]bool)
+	for _, cpe := range nvdCpeMatches(cve) {
+		parsed, err := util.ParseCPE(cpe.Criteria)
+		if err != nil {
+			continue
+		}
+		rec.affected = append(rec.affected, model.AffectedProduct{
+			CPE:                   cpe.Criteria,
+			VersionStartIncluding: cpe.VersionStartIncluding,
+			VersionStartExcluding: cpe.VersionStartExcluding,
+			VersionEndIncluding:   cpe.VersionEndIncluding,
+			VersionEndExcluding:   cpe.VersionEndExcluding,
+			Source:                nvdSource,
+		})
+		if parsed.Vendor != "*" && !vendors[parsed.Vendor] {
+			vendors[parsed.Vendor] = true
+			rec.vendors = append(rec.vendors, parsed.Vendor)
+		}
+		if parsed.Product != "*" && !products[parsed.Product] {
+			products[parsed.Product] = true
+			rec.products = append(rec.products, parsed.Product)
+		}
+	}
+
+	if len(cve.LastModified) >= 10 {
+		rec.modified = cve.LastModified[:10]
+	}
+
+	return rec
+}
+
+// nvdCpeMatches 返回 configurations 中标记为受影响的 CPE 匹配条件
+func nvdCpeMatches(cve *model.NVDCve) []model.NVDCpeMatch {
+	var matches []model.NVDCpeMatch
+	for _, config := range cve.Configurations {
+		for _, node := range config.Nodes {
+			if node.Negate {
+				continue
+			}
+			for _, m := range node.CpeMatch {
+				if m.Vulnerable {
+					matches = append(matches, m)
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// mergeValues 将新的值合并进已有的列表文本，已存在的值(不区分大小写)不重复添加
+func mergeValues(current string, values []string, split func(string) []string, sep string) string {
+	existing := split(current)
+	seen := make(map[string]bool, len(existing))
+	for _, v := range existing {
+		seen[strings.ToLower(v)] = true
+	}
+	merged := existing
+	for _, v := range values {
+		if !seen[strings.ToLower(v)] {
+			seen[strings.ToLower(v)] = true
+			merged = append(merged, v)
+		}
+	}
+	return strings.Join(merged, sep)
+}
+
+// mergeNVDProducts 用 NVD 的 CPE 配置替换来源为 NVD 的受影响范围，其他来源的保持不变
+func mergeNVDProducts(current, feed []model.AffectedProduct) ([]model.AffectedProduct, bool) {
+	feed, err := normalizeAffectedProducts(feed)
+	if err != nil || len(feed) == 0 {
+		return current, false
+	}
+
+	var merged, previous []model.AffectedProduct
+	for _, p := range current {
+		if p.Source == nvdSource {
+			previous = append(previous, p)
+		} else {
+			merged = append(merged, p)
+		}
+	}
+	if reflect.DeepEqual(previous, feed) {
+		return current, false
+	}
+	return append(merged, feed...), true
+}
+
+func splitLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// cveIndex 建立 CVE 编号到知识条目 id 的索引，cve 字段中可能包含多个编号。
+// 批量导入开始时读出一次，避免每条记录都对 cve 字段做不走索引的正则扫描
+func cveIndex(ctx context.Context, collection *mongo.Collection) (map[string][]string, error) {
+	cursor, err := collection.Find(ctx, bson.M{"cve": bson.M{"$nin": []interface{}{nil, ""}}},
+		options.Find().SetProjection(bson.M{"cve": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	index := make(map[string][]string)
+	for cursor.Next(ctx) {
+		var k model.Knowledge
+		if err := cursor.Decode(&k); err != nil {
+			return nil, err
+		}
+		for _, id := range util.ExtractCVEIDs(k.Cve) {
+			index[id] = append(index[id], k.ID)
+		}
+	}
+	return index, cursor.Err()
+}
+
+// findIndexedCve 按 cveIndex 取出 CVE 编号对应的第一个知识条目
+func findIndexedCve(ctx context.Context, collection *mongo.Collection, index map[string][]string, cve string) (*model.Knowledge, error) {
+	ids := index[strings.ToUpper(cve)]
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var existing model.Knowledge
+	err := collection.FindOne(ctx, bson.M{"_id": ids[0]}).Decode(&existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// mergeNVDRecord 按 CVE 编号创建或补充漏洞知识。cvss、cvssStr、cwe 以 NVD 为准；
+// reference、vendor、products 合并；标题和描述仅在为空时填充；solution、analysis 等人工字段不做修改
+func (r *mutationResolver) mergeNVDRecord(ctx context.Context, rec *nvdRecord, index map[string][]string) *model.ImportItem {
+	collection := database.GetCollection("knowledge")
+	item := &model.ImportItem{Key: rec.id}
+
+	existing, err := findIndexedCve(ctx, collection, index, rec.id)
+	if err != nil {
+		item.Action, item.Error = model.ImportFailed, err.Error()
+		return item
+	}
+
+	if existing == nil {
+		input := model.NewKnowledge{
+			Title:           rec.id,
+			KnowledgeType:   []string{vulnerabilityType},
+			KnowledgeSource: []string{nvdSource},
+			Abstract:        rec.description,
+			Content:         rec.description,
+			Cve:             rec.id,
+			Cvss:            rec.score,
+			CvssStr:         rec.vector,
+			Cwe:             strings.Join(rec.cwes, ","),
+			Reference:       strings.Join(rec.references, "\n"),
+			Vendor:          strings.Join(rec.vendors, ","),
+			Products:        strings.Join(rec.products, ","),
+		}
+		if rec.modified != "" {
+			input.RevisionDate = []string{rec.modified}
+		}
+		input.AffectedProducts = rec.affected
+		created, err := r.CreateKnowledge(ctx, input)
+		if err != nil {
+			item.Action, item.Error = model.ImportFailed, err.Error()
+			return item
+		}
+		index[strings.ToUpper(rec.id)] = []string{created.ID}
+		item.KnowledgeID = created.ID
+		item.Action = model.ImportCreated
+		return item
+	}
+
+	item.KnowledgeID = existing.ID
+	stored := *existing
+	set := bson.M{}
+	setField := func(name string, field *string, value string) {
+		if value != "" && *field != value {
+			*field = value
+			set[name] = value
+			item.Changes = append(item.Changes, name)
+		}
+	}
+	fillField := func(name string, field *string, value string) {
+		if strings.TrimSpace(*field) == "" {
+			setField(name, field, value)
+		}
+	}
+
+	fillField("title", &existing.Title, rec.id)
+	fillField("abstract", &existing.Abstract, rec.description)
+	fillField("content", &existing.Content, rec.description)
+	setField("cvss", &existing.Cvss, rec.score)
+	setField("cvssStr", &existing.CvssStr, rec.vector)
+	setField("cwe", &existing.Cwe, strings.Join(rec.cwes, ","))
+	setField("reference", &existing.Reference, mergeValues(existing.Reference, rec.references, splitLines, "\n"))
+	setField("vendor", &existing.Vendor, mergeValues(existing.Vendor, rec.vendors, util.SplitList, ","))
+	setField("products", &existing.Products, mergeValues(existing.Products, rec.products, util.SplitList, ","))
+
+	if !containsString(existing.KnowledgeSource, nvdSource) {
+		existing.KnowledgeSource = append(existing.KnowledgeSource, nvdSource)
+		set["knowledgeSource"] = existing.KnowledgeSource
+		item.Changes = append(item.Changes, "knowledgeSource")
+	}
+	if affected, changed := mergeNVDProducts(existing.AffectedProducts, rec.affected); changed {
+		existing.AffectedProducts = affected
+		set["affectedProducts"] = affected
+		item.Changes = append(item.Changes, "affectedProducts")
+	}
+	if rec.modified != "" && !containsString(existing.RevisionDate, rec.modified) {
+		existing.RevisionDate = append(existing.RevisionDate, rec.modified)
+		set["revisionDate"] = existing.RevisionDate
+		item.Changes = append(item.Changes, "revisionDate")
+	}
+
+	if len(set) == 0 {
+		item.Action = model.ImportUnchanged
+		return item
+	}
+
+	if err := normalizeMerged(ctx, existing, &stored, set); err != nil {
+		item.Action, item.Error = model.ImportFailed, err.Error()
+		return item
+	}
+	for field, value := range deriveKnowledge(existing) {
+		set[field] = value
+	}
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": existing.ID}, touchKnowledge(bson.M{"$set": set})); err != nil {
+		item.Action, item.Error = model.ImportFailed, err.Error()
+		return item
+	}
+	if err := syncAliases(ctx, existing); err != nil {
+		log.Println("Failed to sync aliases:", err)
+	}
+
+	item.Action = model.ImportUpdated
+	return item
+}
+
+// ImportNVDFeed 导入本地的 NVD CVE JSON 2.0 数据文件(支持 .json.gz)，逐条流式解析避免整体读入内存
+func (r *mutationResolver) ImportNVDFeed(ctx context.Context, path string) (*model.ImportReport, error) {
+	file, err := util.OpenDataFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	index, err := cveIndex(ctx, database.GetCollection("knowledge"))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.ImportReport{Success: true, Source: path}
+	dec := json.NewDecoder(file)
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("invalid NVD feed: expected JSON object")
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		if key != "vulnerabilities" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+			return nil, fmt.Errorf("invalid NVD feed: vulnerabilities must be an array")
+		}
+		for dec.More() {
+			var vuln model.NVDVulnerability
+			if err := dec.Decode(&vuln); err != nil {
+				return nil, err
+			}
+			if vuln.Cve.ID == "" {
+				report.Add(&model.ImportItem{Action: model.ImportSkipped, Error: "missing cve id"})
+				continue
+			}
+			report.Add(r.mergeNVDRecord(ctx, newNVDRecord(&vuln.Cve), index))
+		}
+		if _, err := dec.Token(); err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,260 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const migratedSource = "migrated"
+
+// normalizeProductName 按 CPE 的写法规范化厂商和产品名，"Apache HTTP Server" -> "apache_http_server"
+func normalizeProductName(s string) string {
+	s = strings.ToLower(strings.TrimSpace(util.CleanString(s)))
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '-'
+	}), "_")
+}
+
+// normalizeAffectedProducts 校验受影响产品范围，CPE 中的厂商、产品、版本补全到对应字段
+func normalizeAffectedProducts(products []model.AffectedProduct) ([]model.AffectedProduct, error) {
+	var result []model.AffectedProduct
+	for i, p := range products {
+		if p.CPE != "" {
+			cpe, err := util.ParseCPE(p.CPE)
+			if err != nil {
+				return nil, fmt.Errorf("affectedProducts[%d]: %v", i, err)
+			}
+			p.CPE = cpe.String()
+			if p.Vendor == "" && cpe.Vendor != "*" {
+				p.Vendor = cpe.Vendor
+			}
+			if p.Product == "" && cpe.Product != "*" {
+				p.Product = cpe.Product
+			}
+			if p.Version == "" && cpe.Version != "*" {
+				p.Version = cpe.Version
+			}
+		}
+		p.Vendor = normalizeProductName(p.Vendor)
+		p.Product = normalizeProductName(p.Product)
+		if p.Product == "" {
+			return nil, fmt.Errorf("affectedProducts[%d]: product must be provided", i)
+		}
+		if p.Version == "" && !productRange(p).HasBounds() {
+			p.Version = "*"
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+func productRange(p model.AffectedProduct) util.VersionRange {
+	return util.VersionRange{
+		Version:        p.Version,
+		StartIncluding: p.VersionStartIncluding,
+		StartExcluding: p.VersionStartExcluding,
+		EndIncluding:   p.VersionEndIncluding,
+		EndExcluding:   p.VersionEndExcluding,
+	}
+}
+
+// resolveProductQuery 将 CPE 查询转换为厂商、产品、版本
+func resolveProductQuery(q model.ProductQuery) (model.ProductQuery, error) {
+	if q.CPE != "" {
+		cpe, err := util.ParseCPE(q.CPE)
+		if err != nil {
+			return q, err
+		}
+		q.Vendor, q.Product, q.Version = cpe.Vendor, cpe.Product, cpe.Version
+	}
+	q.Vendor = normalizeProductName(q.Vendor)
+	q.Product = normalizeProductName(q.Product)
+	if q.Vendor == "*" {
+		q.Vendor = ""
+	}
+	if q.Version == "*" || q.Version == "-" {
+		q.Version = ""
+	}
+	if q.Product == "" || q.Product == "*" {
+		return q, errors.New("cpe or product must be provided")
+	}
+	return q, nil
+}
+
+// productMatches 判断受影响范围是否覆盖查询的产品版本，未指定版本时只比较厂商和产品
+func productMatches(p model.AffectedProduct, q model.ProductQuery) bool {
+	if p.Product != q.Product {
+		return false
+	}
+	if q.Vendor != "" && p.Vendor != "" && p.Vendor != "*" && p.Vendor != q.Vendor {
+		return false
+	}
+	if q.Version == "" {
+		return true
+	}
+	return productRange(p).Contains(q.Version)
+}
+
+// SearchByAffectedProduct 查询影响指定产品(及版本)的漏洞知识
+func (r *queryResolver) SearchByAffectedProduct(ctx context.Context, query model.ProductQuery, nums int) ([]*model.ProductMatch, error) {
+	q, err := resolveProductQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	elem := bson.M{"product": q.Product}
+	if q.Vendor != "" {
+		elem["$or"] = []bson.M{
+			{"vendor": q.Vendor},
+			{"vendor": "*"},
+			{"vendor": bson.M{"$exists": false}},
+		}
+	}
+	filter := bson.M{"affectedProducts": bson.M{"$elemMatch": elem}}
+
+	collection := database.GetCollection("knowledge")
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []*model.ProductMatch{}
+	for cursor.Next(ctx) {
+		var result model.Knowledge
+		if err := cursor.Decode(&result); err != nil {
+			return nil, err
+		}
+
+		// 版本范围无法用查询表达，在这里逐条比较
+		var matched []model.AffectedProduct
+		for _, p := range result.AffectedProducts {
+			if productMatches(p, q) {
+				matched = append(matched, p)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		results = append(results, &model.ProductMatch{Knowledge: &result, Matched: matched})
+		if nums > 0 && len(results) >= nums {
+			break
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// migratedProducts 将 vendor/products/appName/affectedVerison 自由文本转换为结构化范围，返回无法识别的版本描述
+func migratedProducts(k *model.Knowledge) ([]model.AffectedProduct, []string) {
+	products := util.SplitList(k.Products)
+	if len(products) == 0 {
+		products = util.SplitList(k.AppName)
+	}
+	if len(products) == 0 {
+		return nil, nil
+	}
+	vendors := util.SplitList(k.Vendor)
+
+	ranges := []util.VersionRange{{Version: "*"}}
+	var unparsed []string
+	if strings.TrimSpace(k.AffectedVerison) != "" {
+		ranges, unparsed = util.ParseVersionExpr(k.AffectedVerison)
+	}
+
+	var result []model.AffectedProduct
+	for i, product := range products {
+		vendor := ""
+		switch {
+		case len(vendors) == 1:
+			vendor = vendors[0]
+		case len(vendors) == len(products):
+			vendor = vendors[i]
+		}
+		for _, v := range ranges {
+			result = append(result, model.AffectedProduct{
+				Vendor:                vendor,
+				Product:               product,
+				Version:               v.Version,
+				VersionStartIncluding: v.StartIncluding,
+				VersionStartExcluding: v.StartExcluding,
+				VersionEndIncluding:   v.EndIncluding,
+				VersionEndExcluding:   v.EndExcluding,
+				Source:                migratedSource,
+			})
+		}
+	}
+	return result, unparsed
+}
+
+// MigrateAffectedProducts 为还没有结构化受影响范围的知识条目，从自由文本字段中解析生成
+func (r *mutationResolver) MigrateAffectedProducts(ctx context.Context) (*model.ImportReport, error) {
+	collection := database.GetCollection("knowledge")
+
+	filter := bson.M{
+		"affectedProducts": bson.M{"$in": []interface{}{nil, bson.A{}}},
+		"$or": []bson.M{
+			{"products": bson.M{"$nin": []interface{}{nil, ""}}},
+			{"appName": bson.M{"$nin": []interface{}{nil, ""}}},
+		},
+	}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetProjection(bson.M{
+		"vendor": 1, "products": 1, "appName": 1, "affectedVerison": 1,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	report := &model.ImportReport{Success: true, Source: "knowledge"}
+	for cursor.Next(ctx) {
+		var knowledge model.Knowledge
+		if err := cursor.Decode(&knowledge); err != nil {
+			return nil, err
+		}
+		item := &model.ImportItem{Key: knowledge.ID, KnowledgeID: knowledge.ID}
+
+		products, unparsed := migratedProducts(&knowledge)
+		if len(unparsed) > 0 {
+			item.Error = "unparseable version: " + strings.Join(unparsed, "; ")
+		}
+		products, err := normalizeAffectedProducts(products)
+		if err != nil {
+			item.Action, item.Error = model.ImportFailed, err.Error()
+			report.Add(item)
+			continue
+		}
+		if len(products) == 0 {
+			item.Action = model.ImportSkipped
+			report.Add(item)
+			continue
+		}
+
+		_, err = collection.UpdateOne(ctx, bson.M{"_id": knowledge.ID}, touchKnowledge(bson.M{"$set": bson.M{"affectedProducts": products}}))
+		if err != nil {
+			item.Action, item.Error = model.ImportFailed, err.Error()
+		} else {
+			item.Action = model.ImportUpdated
+			item.Changes = []string{"affectedProducts"}
+		}
+		report.Add(item)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
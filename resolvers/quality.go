@@ -0,0 +1,512 @@
+package resolvers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// qualityRules 全部检查规则，可修复的规则只做不改变含义的修改
+var qualityRules = []*model.QualityRule{
+	{ID: model.QualityEmptyTitle, Description: "title is empty or only whitespace"},
+	{ID: model.QualityDuplicateTitle, Description: "title is shared with another document, ignoring case and surrounding whitespace"},
+	{ID: model.QualityInvalidAttack, Description: "tacticsId, techniquesId or subTechniquesId is not a valid ATT&CK id; ids that only differ by case, whitespace or invisible characters are fixed", Fixable: true},
+	{ID: model.QualityOrphanedImage, Description: "content links an image that does not exist or is still in the upload temp folder"},
+	{ID: model.QualityArrayAsString, Description: "list field is stored as a string; fixed by splitting on commas, semicolons and line breaks", Fixable: true},
+	{ID: model.QualityZeroWidth, Description: "field contains zero-width characters; fixed by removing them from title and list fields, other text is only reported because joiners are needed by emoji and some scripts", Fixable: true},
+}
+
+// imageTempFolder 上传时未指定 id 的图片所在目录，与 main 中的 temp 一致
+const imageTempFolder = "ret2-image-temp-folder"
+
+var (
+	// imageLinkPattern content 中指向 /api/images/:type/:id/:filename 的链接
+	imageLinkPattern = regexp.MustCompile(`/api/images/([^/\s"'()<>]+)/([^/\s"'()<>]+)/([^/\s"'()<>?#]+)`)
+	// attackPatterns 各 ATT&CK 字段合法的编号格式
+	attackPatterns = map[string]*regexp.Regexp{
+		"tacticsId":       regexp.MustCompile(`^TA\d{4}$`),
+		"techniquesId":    regexp.MustCompile(`^T\d{4}(\.\d{3})?$`),
+		"subTechniquesId": regexp.MustCompile(`^T\d{4}\.\d{3}$`),
+	}
+	attackFields = []string{"tacticsId", "techniquesId", "subTechniquesId"}
+	// listFields knowledge 中类型为列表的字段
+	listFields = knowledgeListFields()
+)
+
+// knowledgeListFields 通过反射找出 Knowledge 中类型为 []string 的字段
+func knowledgeListFields() []string {
+	t := reflect.TypeOf(model.Knowledge{})
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("bson"), ",")
+		if name == "" || name == "-" || f.Type != reflect.TypeOf([]string(nil)) {
+			continue
+		}
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// qualityScan 一次检查选中的规则和选项。各检查函数在 fixed 中记录修复后需要写回的字段
+type qualityScan struct {
+	rules  map[string]bool
+	fix    bool
+	folder string
+}
+
+func (s *qualityScan) fixing(rule string) bool {
+	return s.fix && s.rules[rule]
+}
+
+// documentID 返回 _id 的字符串形式，早期数据中可能是 ObjectID
+func documentID(v interface{}) string {
+	switch id := v.(type) {
+	case string:
+		return id
+	case primitive.ObjectID:
+		return id.Hex()
+	}
+	return fmt.Sprint(v)
+}
+
+// qualityTitle 去掉零宽字符和首尾空白后的标题
+func qualityTitle(doc bson.M) string {
+	title, _ := doc["title"].(string)
+	return strings.TrimSpace(util.CleanString(title))
+}
+
+// splitListString 将存成字符串的列表拆分，字符串本身是 JSON 数组时按 JSON 解析
+func splitListString(s string) []string {
+	s = strings.TrimSpace(s)
+	var values []string
+	if strings.HasPrefix(s, "[") && json.Unmarshal([]byte(s), &values) == nil {
+		return values
+	}
+	values = []string{}
+	for _, v := range strings.FieldsFunc(s, func(r rune) bool {
+		switch r {
+		case ',', ';', '|', '\n', '\r', '，', '；', '、':
+			return true
+		}
+		return false
+	}) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// stringValues 返回字段中的全部字符串，字段存成字符串时按列表拆分
+func stringValues(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return splitListString(v)
+	case bson.A:
+		var values []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	case []string:
+		return v
+	}
+	return nil
+}
+
+// checkListFields 列表字段存成了字符串或其他类型
+func (s *qualityScan) checkListFields(doc bson.M, fixed map[string]bool) []*model.QualityIssue {
+	var issues []*model.QualityIssue
+	for _, field := range listFields {
+		v, ok := doc[field]
+		if !ok || v == nil {
+			continue
+		}
+		switch v := v.(type) {
+		case bson.A:
+			continue
+		case string:
+			issue := &model.QualityIssue{Rule: model.QualityArrayAsString, Field: field, Value: v, Message: "list stored as string"}
+			if s.fixing(model.QualityArrayAsString) {
+				doc[field] = splitListString(v)
+				fixed[field], issue.Fixed = true, true
+			}
+			issues = append(issues, issue)
+		default:
+			issues = append(issues, &model.QualityIssue{Rule: model.QualityArrayAsString, Field: field, Value: fmt.Sprint(v), Message: fmt.Sprintf("list stored as %T", v)})
+		}
+	}
+	return issues
+}
+
+// zeroWidthFixable 只修复标题和列表字段(标签、ATT&CK 编号等标识类的值)。正文等文本中的 U+200D
+// 是 emoji 组合和阿拉伯文、印度系文字连写需要的，只报告不修改
+func zeroWidthFixable(field string) bool {
+	if field == "title" {
+		return true
+	}
+	for _, f := range listFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// checkZeroWidth 字符串字段和列表中的字符串含有零宽字符
+func (s *qualityScan) checkZeroWidth(doc bson.M, fixed map[string]bool) []*model.QualityIssue {
+	fields := make([]string, 0, len(doc))
+	for field := range doc {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var issues []*model.QualityIssue
+	for _, field := range fields {
+		fix := s.fixing(model.QualityZeroWidth) && zeroWidthFixable(field)
+		var found []string
+		switch v := doc[field].(type) {
+		case string:
+			if util.CleanString(v) != v {
+				found = append(found, v)
+				if fix {
+					doc[field] = util.CleanString(v)
+				}
+			}
+		case bson.A:
+			cleaned := make(bson.A, len(v))
+			for i, item := range v {
+				cleaned[i] = item
+				if value, ok := item.(string); ok && util.CleanString(value) != value {
+					found = append(found, value)
+					cleaned[i] = util.CleanString(value)
+				}
+			}
+			if fix && len(found) > 0 {
+				doc[field] = cleaned
+			}
+		case []string: // 本次检查中由字符串拆分得到的列表
+			cleaned := make([]string, len(v))
+			for i, value := range v {
+				if cleaned[i] = util.CleanString(value); cleaned[i] != value {
+					found = append(found, value)
+				}
+			}
+			if fix && len(found) > 0 {
+				doc[field] = cleaned
+			}
+		}
+		if len(found) == 0 {
+			continue
+		}
+		issue := &model.QualityIssue{Rule: model.QualityZeroWidth, Field: field, Value: strings.Join(found, ", "), Message: fmt.Sprintf("%d value(s) contain zero-width characters", len(found))}
+		if fix {
+			fixed[field], issue.Fixed = true, true
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// checkAttack ATT&CK 编号格式不正确，只差大小写、空白或不可见字符的编号可以修复
+func (s *qualityScan) checkAttack(doc bson.M, fixed map[string]bool) []*model.QualityIssue {
+	fix := s.fixing(model.QualityInvalidAttack)
+	var issues []*model.QualityIssue
+	for _, field := range attackFields {
+		pattern := attackPatterns[field]
+		values := stringValues(doc[field])
+		// 仍是字符串或列表中有非字符串元素时不修复，由 array-as-string 处理
+		canFix := fix
+		switch v := doc[field].(type) {
+		case string:
+			canFix = false
+		case bson.A:
+			canFix = canFix && len(v) == len(values)
+		}
+
+		changed := false
+		for i, value := range values {
+			if pattern.MatchString(value) {
+				continue
+			}
+			issue := &model.QualityIssue{Rule: model.QualityInvalidAttack, Field: field, Value: value, Message: "not a valid ATT&CK id"}
+			if candidate := strings.ToUpper(strings.TrimSpace(util.CleanString(value))); pattern.MatchString(candidate) {
+				issue.Message = "should be " + candidate
+				if canFix {
+					values[i], changed, issue.Fixed = candidate, true, true
+				}
+			}
+			issues = append(issues, issue)
+		}
+		if changed {
+			doc[field] = values
+			fixed[field] = true
+		}
+	}
+	return issues
+}
+
+// checkImages content 中引用的图片文件不存在，或者上传后没有从临时目录移到条目目录
+func (s *qualityScan) checkImages(doc bson.M) []*model.QualityIssue {
+	content, _ := doc["content"].(string)
+	var issues []*model.QualityIssue
+	seen := make(map[string]bool)
+	for _, match := range imageLinkPattern.FindAllStringSubmatch(content, -1) {
+		if seen[match[0]] {
+			continue
+		}
+		seen[match[0]] = true
+
+		name, err := url.PathUnescape(match[3])
+		if err != nil {
+			name = match[3]
+		}
+		if match[2] == imageTempFolder {
+			issues = append(issues, &model.QualityIssue{Rule: model.QualityOrphanedImage, Field: "content", Value: match[0], Message: "image is still in the upload temp folder"})
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(s.folder, filepath.Clean("/"+filepath.Join(match[1], match[2], name)))); err != nil {
+			issues = append(issues, &model.QualityIssue{Rule: model.QualityOrphanedImage, Field: "content", Value: match[0], Message: "image file not found"})
+		}
+	}
+	return issues
+}
+
+// QualityRules 返回全部检查规则
+func (r *queryResolver) QualityRules(ctx context.Context) ([]*model.QualityRule, error) {
+	return qualityRules, nil
+}
+
+// ScanKnowledgeQuality 对 knowledge 集合执行选中的检查规则，按规则和条目汇总问题。
+// Fix 时修复可修复的问题并写回，同步别名、IoC 指标和检测规则
+func (r *mutationResolver) ScanKnowledgeQuality(ctx context.Context, req model.QualityScanRequest) (*model.QualityReport, error) {
+	scan := &qualityScan{rules: make(map[string]bool), fix: req.Fix, folder: req.ImageFolder}
+	known := make(map[string]bool, len(qualityRules))
+	for _, rule := range qualityRules {
+		known[rule.ID] = true
+	}
+	for _, rule := range req.Rules {
+		if !known[rule] {
+			return nil, fmt.Errorf("%w: unknown rule %q", ErrInvalidInput, rule)
+		}
+		scan.rules[rule] = true
+	}
+	if len(scan.rules) == 0 {
+		scan.rules = known
+	}
+
+	filter := bson.M{}
+	if req.Filter != nil {
+		var err error
+		if filter, err = whereFilter(req.Filter); err != nil {
+			return nil, err
+		}
+	}
+
+	collection := database.GetCollection("knowledge")
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	report := &model.QualityReport{Documents: []*model.QualityDocument{}}
+	titles := make(map[string][]*model.QualityDocument)
+	var models []mongo.WriteModel
+	var fixedDocs []*model.Knowledge
+	flush := func() error {
+		if len(models) == 0 {
+			return nil
+		}
+		if _, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+		report.Fixed += len(models)
+		for _, doc := range fixedDocs {
+			syncQualityFix(ctx, doc)
+		}
+		models, fixedDocs = models[:0], fixedDocs[:0]
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		report.Scanned++
+		stored := storedTaxonomy(doc)
+
+		result := &model.QualityDocument{ID: documentID(doc["_id"]), Title: qualityTitle(doc)}
+		fixed := make(map[string]bool)
+		if scan.rules[model.QualityEmptyTitle] && result.Title == "" {
+			result.Issues = append(result.Issues, &model.QualityIssue{Rule: model.QualityEmptyTitle, Field: "title", Message: "title is empty"})
+		}
+		// 先把字符串拆成列表，之后的检查可以修复列表中的值
+		if scan.rules[model.QualityArrayAsString] {
+			result.Issues = append(result.Issues, scan.checkListFields(doc, fixed)...)
+		}
+		if scan.rules[model.QualityZeroWidth] {
+			result.Issues = append(result.Issues, scan.checkZeroWidth(doc, fixed)...)
+		}
+		if scan.rules[model.QualityInvalidAttack] {
+			result.Issues = append(result.Issues, scan.checkAttack(doc, fixed)...)
+		}
+		if scan.rules[model.QualityOrphanedImage] && scan.folder != "" {
+			result.Issues = append(result.Issues, scan.checkImages(doc)...)
+		}
+		if scan.rules[model.QualityDuplicateTitle] && result.Title != "" {
+			key := strings.ToLower(result.Title)
+			titles[key] = append(titles[key], result)
+		}
+		if len(result.Issues) > 0 {
+			report.Documents = append(report.Documents, result)
+		}
+
+		if len(fixed) > 0 {
+			knowledge, set, err := qualityFix(ctx, doc, fixed, stored)
+			if err != nil {
+				// 修复后的条目不能写入，例如拆分出的标签不在严格模式的词表中或不符合 schema，本条不修复
+				for _, issue := range result.Issues {
+					if issue.Fixed {
+						issue.Fixed = false
+						issue.Message += "; not fixed: " + err.Error()
+					}
+				}
+				continue
+			}
+			models = append(models, mongo.NewUpdateOneModel().SetFilter(bson.M{"_id": doc["_id"]}).SetUpdate(touchKnowledge(bson.M{"$set": set})))
+			fixedDocs = append(fixedDocs, knowledge)
+			if len(models) >= 500 {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	// 重复标题需要全部扫描完才能确定
+	for _, group := range titles {
+		if len(group) < 2 {
+			continue
+		}
+		for _, result := range group {
+			var others []string
+			for _, other := range group {
+				if other != result {
+					others = append(others, other.ID)
+				}
+			}
+			if len(result.Issues) == 0 {
+				report.Documents = append(report.Documents, result)
+			}
+			result.Issues = append(result.Issues, &model.QualityIssue{Rule: model.QualityDuplicateTitle, Field: "title", Value: result.Title, Message: "same title as " + strings.Join(others, ", ")})
+		}
+	}
+	sort.Slice(report.Documents, func(i, j int) bool { return report.Documents[i].ID < report.Documents[j].ID })
+
+	for _, rule := range qualityRules {
+		if !scan.rules[rule.ID] {
+			continue
+		}
+		summary := &model.QualityRuleSummary{QualityRule: rule}
+		for _, result := range report.Documents {
+			found := false
+			for _, issue := range result.Issues {
+				if issue.Rule != rule.ID {
+					continue
+				}
+				found = true
+				summary.Issues++
+				if issue.Fixed {
+					summary.Fixed++
+				}
+			}
+			if found {
+				summary.Documents++
+			}
+		}
+		report.Rules = append(report.Rules, summary)
+	}
+	return report, nil
+}
+
+// storedTaxonomy 修复前已经以列表保存的词表字段。存成字符串的值拆分后才第一次写入，按严格模式检查
+func storedTaxonomy(doc bson.M) *model.Knowledge {
+	stored := &model.Knowledge{}
+	for _, field := range model.TaxonomyFields {
+		if v, ok := doc[field].(bson.A); ok {
+			*taxonomyValues(stored, field) = stringValues(v)
+		}
+	}
+	return stored
+}
+
+// qualityFix 修复后的条目与其他写入一样经过词表规范化和 schema 校验，并重新计算派生字段，返回条目和需要写回的字段
+func qualityFix(ctx context.Context, doc bson.M, fixed map[string]bool, stored *model.Knowledge) (*model.Knowledge, bson.M, error) {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	var knowledge model.Knowledge
+	if err := bson.Unmarshal(data, &knowledge); err != nil {
+		return nil, nil, fmt.Errorf("other fields have invalid types: %v", err)
+	}
+	if err := applyTaxonomy(ctx, &knowledge, stored); err != nil {
+		return nil, nil, err
+	}
+	// 字符串拆成列表后可能不再符合该类型的 schema
+	if err := validateKnowledgeSchema(ctx, &knowledge); err != nil {
+		return nil, nil, err
+	}
+
+	set := deriveKnowledge(&knowledge)
+	for field := range fixed {
+		set[field] = doc[field]
+	}
+	for _, field := range model.TaxonomyFields {
+		if fixed[field] {
+			set[field] = *taxonomyValues(&knowledge, field)
+		}
+	}
+	return &knowledge, set, nil
+}
+
+// syncQualityFix 修复后的条目同步别名、IoC 指标和检测规则
+func syncQualityFix(ctx context.Context, knowledge *model.Knowledge) {
+	if err := syncAliases(ctx, knowledge); err != nil {
+		log.Println("Failed to sync aliases:", err)
+	}
+	if err := syncIndicators(ctx, knowledge.ID, knowledge.IoC); err != nil {
+		log.Println("Failed to sync indicators:", err)
+	}
+	if err := refreshDetectionRules(ctx, knowledge); err != nil {
+		log.Println("Failed to refresh detection rules:", err)
+	}
+}
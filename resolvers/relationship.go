@@ -0,0 +1,364 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mongdbs/database"
+	"mongdbs/model"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var relationshipIndexOnce sync.Once
+
+// ensureRelationshipIndexes 两个条目之间同一类型的关系只能有一条
+func ensureRelationshipIndexes() {
+	relationshipIndexOnce.Do(func() {
+		_, err := database.GetCollection("relationships").Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+			{Keys: bson.D{{Key: "source", Value: 1}, {Key: "target", Value: 1}, {Key: "type", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "target", Value: 1}}},
+		})
+		if err != nil {
+			log.Println("Failed to create relationship indexes:", err)
+		}
+	})
+}
+
+// normalizeRelationshipType 统一大小写和分隔符，related_to、relatedTo 都视为 related-to
+func normalizeRelationshipType(t string) (string, error) {
+	t = strings.ToLower(strings.TrimSpace(t))
+	t = strings.ReplaceAll(t, "_", "-")
+	if t == "relatedto" {
+		t = model.RelationshipRelatedTo
+	}
+	for _, known := range model.RelationshipTypes {
+		if t == known {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("%w: type must be one of %s", ErrInvalidInput, strings.Join(model.RelationshipTypes, ", "))
+}
+
+// relationshipEnds related-to 没有方向，两端按 ID 排序保存，避免同一关联存两条
+func relationshipEnds(relType, source, target string) (string, string) {
+	if relType == model.RelationshipRelatedTo && source > target {
+		return target, source
+	}
+	return source, target
+}
+
+// inverseRelationshipType 反向名称(如 used-by)对应的关系类型，不是反向名称时返回空
+func inverseRelationshipType(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for t, inverse := range model.RelationshipInverse {
+		if inverse == name && t != model.RelationshipRelatedTo {
+			return t
+		}
+	}
+	return ""
+}
+
+func relationshipExists(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: relationship already exists", ErrInvalidInput)
+	}
+	return err
+}
+
+func findRelationship(ctx context.Context, id string) (*model.Relationship, error) {
+	var relationship model.Relationship
+	err := database.GetCollection("relationships").FindOne(ctx, bson.M{"_id": id}).Decode(&relationship)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, errors.New("No document found with that ID")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &relationship, nil
+}
+
+// ListRelationships 按起点、终点和类型查询关系，条件为空时不限制
+func (r *queryResolver) ListRelationships(ctx context.Context, source, target, relType string) ([]*model.Relationship, error) {
+	filter := bson.M{}
+	for field, id := range map[string]string{"source": source, "target": target} {
+		if id == "" {
+			continue
+		}
+		resolved, err := resolveKnowledgeID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		filter[field] = resolved
+	}
+	if relType != "" {
+		normalized, err := normalizeRelationshipType(relType)
+		if err != nil {
+			return nil, err
+		}
+		filter["type"] = normalized
+	}
+
+	ensureRelationshipIndexes()
+	results := []*model.Relationship{}
+	cursor, err := database.GetCollection("relationships").Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "source", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// RelatedKnowledge 返回与条目有关系的全部条目，包括以它为终点的关系(反向查询)。
+// relType 可以是关系类型或反向名称(如 used-by)，direction 为 outgoing、incoming 或空
+func (r *queryResolver) RelatedKnowledge(ctx context.Context, id, relType, direction string) ([]*model.RelatedKnowledge, error) {
+	var outgoing, incoming bson.M
+	switch direction {
+	case "", model.RelationshipOutgoing, model.RelationshipIncoming:
+	default:
+		return nil, fmt.Errorf("%w: direction must be outgoing or incoming", ErrInvalidInput)
+	}
+	id, err := resolveKnowledgeID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if direction != model.RelationshipIncoming {
+		outgoing = bson.M{"source": id}
+	}
+	if direction != model.RelationshipOutgoing {
+		incoming = bson.M{"target": id}
+	}
+
+	if relType != "" {
+		if inverse := inverseRelationshipType(relType); inverse != "" {
+			// 反向名称只匹配以条目为终点的关系
+			outgoing = nil
+			if incoming != nil {
+				incoming["type"] = inverse
+			}
+		} else {
+			normalized, err := normalizeRelationshipType(relType)
+			if err != nil {
+				return nil, err
+			}
+			if outgoing != nil {
+				outgoing["type"] = normalized
+			}
+			if incoming != nil {
+				incoming["type"] = normalized
+			}
+		}
+	}
+
+	// related-to 没有方向，只查一个方向时另一端是查询条目的 related-to 也算在内
+	var symmetric bson.M
+	if direction != "" && (relType == "" || outgoing != nil && outgoing["type"] == model.RelationshipRelatedTo || incoming != nil && incoming["type"] == model.RelationshipRelatedTo) {
+		symmetric = bson.M{"type": model.RelationshipRelatedTo}
+		if direction == model.RelationshipOutgoing {
+			symmetric["target"] = id
+		} else {
+			symmetric["source"] = id
+		}
+	}
+
+	var or []bson.M
+	for _, filter := range []bson.M{outgoing, incoming, symmetric} {
+		if filter != nil {
+			or = append(or, filter)
+		}
+	}
+	results := []*model.RelatedKnowledge{}
+	if len(or) == 0 {
+		return results, nil
+	}
+
+	ensureRelationshipIndexes()
+	cursor, err := database.GetCollection("relationships").Find(ctx, bson.M{"$or": or}, options.Find().SetSort(bson.D{{Key: "type", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var relationships []*model.Relationship
+	if err := cursor.All(ctx, &relationships); err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, rel := range relationships {
+		item := &model.RelatedKnowledge{Relationship: rel, Type: rel.Type}
+		if rel.Source == id {
+			item.ID, item.Direction = rel.Target, model.RelationshipOutgoing
+		} else {
+			item.ID, item.Direction = rel.Source, model.RelationshipIncoming
+			item.Type = model.RelationshipInverse[rel.Type]
+		}
+		if rel.Type == model.RelationshipRelatedTo && direction != "" {
+			item.Direction = direction
+		}
+		ids = append(ids, item.ID)
+		results = append(results, item)
+	}
+
+	summaries, err := knowledgeSummaries(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range results {
+		if k, ok := summaries[item.ID]; ok {
+			item.Title, item.KnowledgeType = k.Title, k.KnowledgeType
+		}
+	}
+	return results, nil
+}
+
+// knowledgeSummaries 批量读取条目的标题和类型
+func knowledgeSummaries(ctx context.Context, ids []string) (map[string]*model.Knowledge, error) {
+	summaries := make(map[string]*model.Knowledge)
+	if len(ids) == 0 {
+		return summaries, nil
+	}
+	cursor, err := database.GetCollection("knowledge").Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{"title": 1, "knowledgeType": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var k model.Knowledge
+		if err := cursor.Decode(&k); err != nil {
+			return nil, err
+		}
+		summaries[k.ID] = &k
+	}
+	return summaries, cursor.Err()
+}
+
+// CreateRelationship 两端的知识条目都必须存在
+func (r *mutationResolver) CreateRelationship(ctx context.Context, input model.NewRelationship) (*model.Relationship, error) {
+	relType, err := normalizeRelationshipType(input.Type)
+	if err != nil {
+		return nil, err
+	}
+	if input.Source == "" || input.Target == "" {
+		return nil, fmt.Errorf("%w: source and target must be provided", ErrInvalidInput)
+	}
+	if input.Source == input.Target {
+		return nil, fmt.Errorf("%w: source and target must be different", ErrInvalidInput)
+	}
+	for _, id := range []*string{&input.Source, &input.Target} {
+		knowledge, err := findKnowledge(ctx, *id)
+		if err != nil {
+			return nil, err
+		}
+		*id = knowledge.ID
+	}
+	if input.Source == input.Target {
+		return nil, fmt.Errorf("%w: source and target must be different", ErrInvalidInput)
+	}
+
+	now := time.Now().UTC()
+	relationship := model.Relationship{
+		ID:          primitive.NewObjectID().Hex(),
+		Type:        relType,
+		Description: input.Description,
+		Created:     now,
+		Modified:    now,
+	}
+	relationship.Source, relationship.Target = relationshipEnds(relType, input.Source, input.Target)
+
+	ensureRelationshipIndexes()
+	if _, err := database.GetCollection("relationships").InsertOne(ctx, relationship); err != nil {
+		return nil, relationshipExists(err)
+	}
+	return &relationship, nil
+}
+
+// UpdateRelationship 修改关系类型和说明，两端不能修改
+func (r *mutationResolver) UpdateRelationship(ctx context.Context, id string, input model.UpdateRelationship) (*model.Relationship, error) {
+	relationship, err := findRelationship(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Type != "" {
+		if relationship.Type, err = normalizeRelationshipType(input.Type); err != nil {
+			return nil, err
+		}
+	}
+	relationship.Description = input.Description
+	relationship.Source, relationship.Target = relationshipEnds(relationship.Type, relationship.Source, relationship.Target)
+	relationship.Modified = time.Now().UTC()
+
+	if _, err := database.GetCollection("relationships").ReplaceOne(ctx, bson.M{"_id": id}, relationship); err != nil {
+		return nil, relationshipExists(err)
+	}
+	return relationship, nil
+}
+
+func (r *mutationResolver) DeleteRelationship(ctx context.Context, id string) (*model.DeletionStatus, error) {
+	deleteResult, err := database.GetCollection("relationships").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return &model.DeletionStatus{Success: false, Message: err.Error()}, err
+	}
+	if deleteResult.DeletedCount == 0 {
+		return &model.DeletionStatus{Success: false, Message: "No document found with that ID"}, nil
+	}
+	return &model.DeletionStatus{Success: true, Message: "Deleted successfully"}, nil
+}
+
+// removeRelationships 删除以某个知识条目为起点或终点的全部关系
+func removeRelationships(ctx context.Context, id string) error {
+	_, err := database.GetCollection("relationships").DeleteMany(ctx, bson.M{"$or": []bson.M{{"source": id}, {"target": id}}})
+	return err
+}
+
+// moveRelationships 合并条目时将关系转到保留条目上，转移后指向自身或与已有关系重复的被删除
+func moveRelationships(ctx context.Context, from, to string) error {
+	collection := database.GetCollection("relationships")
+	cursor, err := collection.Find(ctx, bson.M{"$or": []bson.M{{"source": from}, {"target": from}}})
+	if err != nil {
+		return err
+	}
+	var relationships []*model.Relationship
+	if err := cursor.All(ctx, &relationships); err != nil {
+		return err
+	}
+
+	for _, rel := range relationships {
+		source, target := rel.Source, rel.Target
+		if source == from {
+			source = to
+		}
+		if target == from {
+			target = to
+		}
+		source, target = relationshipEnds(rel.Type, source, target)
+
+		// 事务中唯一索引冲突会使整个事务失败，先检查是否已有同样的关系
+		duplicate := source == target
+		if !duplicate {
+			count, err := collection.CountDocuments(ctx, bson.M{"source": source, "target": target, "type": rel.Type})
+			if err != nil {
+				return err
+			}
+			duplicate = count > 0
+		}
+		if duplicate {
+			if _, err := collection.DeleteOne(ctx, bson.M{"_id": rel.ID}); err != nil {
+				return err
+			}
+			continue
+		}
+		update := bson.M{"$set": bson.M{"source": source, "target": target, "modified": time.Now().UTC()}}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": rel.ID}, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -4,14 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"mongdbs/database"
 	"mongdbs/model"
 	"reflect"
 	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -24,7 +27,48 @@ type MutationResolver interface {
 	CreateKnowledge(ctx context.Context, input model.NewKnowledge) (*model.Knowledge, error)
 	UpdateKnowledge(ctx context.Context, id string, input model.NewKnowledge) (*model.Knowledge, error)
 	DeleteKnowledge(ctx context.Context, id string) (*model.DeletionStatus, error)
-	BatchEditKnowledgeType(ctx context.Context, idList []string, prevType string, repType string) (*model.DeletionStatus, error)
+	BatchEditKnowledgeType(ctx context.Context, idList []string, prevType string, repType string, atomic bool) (*model.DeletionStatus, error)
+	CreateAlias(ctx context.Context, input model.NewAlias) (*model.Alias, error)
+	DeleteAlias(ctx context.Context, id string) (*model.DeletionStatus, error)
+	RebuildAliasRegistry(ctx context.Context) (*model.AliasRebuildStatus, error)
+	RefreshDerivedFields(ctx context.Context) (*model.RefreshStatus, error)
+	ImportNVDFeed(ctx context.Context, path string) (*model.ImportReport, error)
+	ImportCWECatalog(ctx context.Context, path string) (*model.ImportReport, error)
+	ImportCAPECCatalog(ctx context.Context, path string) (*model.ImportReport, error)
+	MigrateAffectedProducts(ctx context.Context) (*model.ImportReport, error)
+	ImportKEVCatalog(ctx context.Context, path string) (*model.ImportReport, error)
+	ImportEPSSScores(ctx context.Context, path string) (*model.ImportReport, error)
+	RebuildIndicators(ctx context.Context) (*model.IndicatorRebuildStatus, error)
+	ImportMISPEvents(ctx context.Context, path string) (*model.ImportReport, error)
+	CreateTAXIIClient(ctx context.Context, input model.NewTAXIIClient) (*model.TAXIIClientKey, error)
+	DeleteTAXIIClient(ctx context.Context, id string) (*model.DeletionStatus, error)
+	CreateTAXIICollection(ctx context.Context, input model.NewTAXIICollection) (*model.TAXIICollectionDef, error)
+	DeleteTAXIICollection(ctx context.Context, id string) (*model.DeletionStatus, error)
+	CreateDetectionRule(ctx context.Context, input model.NewDetectionRule) (*model.DetectionRule, error)
+	UpdateDetectionRule(ctx context.Context, id string, input model.UpdateDetectionRule) (*model.DetectionRule, error)
+	DeleteDetectionRule(ctx context.Context, id string) (*model.DeletionStatus, error)
+	CreateTypedKnowledge(ctx context.Context, kind string, data []byte) (model.TypedKnowledge, error)
+	UpdateTypedKnowledge(ctx context.Context, kind, id string, data []byte) (model.TypedKnowledge, error)
+	SaveKnowledgeSchema(ctx context.Context, knowledgeType string, data []byte) (*model.KnowledgeSchema, error)
+	DeleteKnowledgeSchema(ctx context.Context, knowledgeType string) (*model.DeletionStatus, error)
+	CreateTaxonomyTerm(ctx context.Context, input model.NewTaxonomyTerm) (*model.TaxonomyTerm, error)
+	UpdateTaxonomyTerm(ctx context.Context, id string, input model.UpdateTaxonomyTerm) (*model.TaxonomyTerm, error)
+	DeleteTaxonomyTerm(ctx context.Context, id string) (*model.DeletionStatus, error)
+	RenameTaxonomyTerm(ctx context.Context, id, name string, atomic bool) (*model.TaxonomyRewriteStatus, error)
+	MergeTaxonomyTerm(ctx context.Context, id, into string, atomic bool) (*model.TaxonomyRewriteStatus, error)
+	SetTaxonomyStrict(ctx context.Context, field string, strict bool) (*model.TaxonomySetting, error)
+	NormalizeTaxonomy(ctx context.Context, field string) (*model.TaxonomyRewriteStatus, error)
+	BatchKnowledge(ctx context.Context, req model.BatchRequest) (*model.BatchReport, error)
+	RunImport(ctx context.Context, atomic bool, run func(ctx context.Context) (*model.ImportReport, error)) (*model.ImportReport, error)
+	MigrateUp(ctx context.Context, to int) (*model.MigrationRun, error)
+	MigrateDown(ctx context.Context, to int) (*model.MigrationRun, error)
+	ScanKnowledgeQuality(ctx context.Context, req model.QualityScanRequest) (*model.QualityReport, error)
+	DetectDuplicates(ctx context.Context, threshold float64) (*model.DuplicateDetectStatus, error)
+	DismissDuplicate(ctx context.Context, id string) (*model.DuplicateCandidate, error)
+	MergeKnowledge(ctx context.Context, req model.MergeRequest) (*model.MergeResult, error)
+	CreateRelationship(ctx context.Context, input model.NewRelationship) (*model.Relationship, error)
+	UpdateRelationship(ctx context.Context, id string, input model.UpdateRelationship) (*model.Relationship, error)
+	DeleteRelationship(ctx context.Context, id string) (*model.DeletionStatus, error)
 }
 
 type QueryResolver interface {
@@ -38,9 +82,46 @@ type QueryResolver interface {
 	SearchByContent(ctx context.Context, typeArg []string, keyword string, nums int) ([]*model.Knowledge, error)
 	SearchByKeyword(ctx context.Context, typeArg []string, keyword []string, nums int) ([]*model.Knowledge, error)
 	SearchById(ctx context.Context, typeArg []string, id string) ([]*model.Knowledge, error)
+	CompareThreatActors(ctx context.Context, ids []string) (*model.ActorComparison, error)
+	ResolveAlias(ctx context.Context, name string) ([]*model.AliasResolution, error)
+	ListAliases(ctx context.Context, knowledgeID string) ([]*model.Alias, error)
+	KnowledgeWeakness(ctx context.Context, id string) (*model.WeaknessReport, error)
+	SearchByCWE(ctx context.Context, cwe []string, excludeID string, nums int) ([]*model.Knowledge, error)
+	SearchByAffectedProduct(ctx context.Context, query model.ProductQuery, nums int) ([]*model.ProductMatch, error)
+	MatchSBOM(ctx context.Context, data []byte) (*model.SBOMReport, error)
+	SearchIndicator(ctx context.Context, value string) (*model.IndicatorSearchResult, error)
+	MatchIndicators(ctx context.Context, reader io.Reader, typeArg []string) (*model.BulkIndicatorReport, error)
+	ExportMISPEvent(ctx context.Context, id string) (*model.MISPEventWrapper, error)
+	ExportMISPSearch(ctx context.Context, where *model.KnowledgeFilter, keyword []string, authors []string, nums int, nodedict string) (*model.MISPSearchResponse, error)
+	ListTAXIIClients(ctx context.Context) ([]*model.TAXIIClient, error)
+	AuthenticateTAXIIClient(ctx context.Context, id, key string) (*model.TAXIIClient, error)
+	ListTAXIICollectionDefs(ctx context.Context) ([]*model.TAXIICollectionDef, error)
+	TAXIICollections(ctx context.Context, client *model.TAXIIClient) (*model.TAXIICollections, error)
+	TAXIICollection(ctx context.Context, client *model.TAXIIClient, id string) (*model.TAXIICollection, error)
+	TAXIIObjects(ctx context.Context, client *model.TAXIIClient, id string, addedAfter *time.Time, limit int, next string, types []string) (*model.TAXIIEnvelope, error)
+	ValidateDetectionRule(ctx context.Context, input model.NewDetectionRule) (*model.DetectionRule, error)
+	ListDetectionRules(ctx context.Context, knowledgeID, ruleType string) ([]*model.DetectionRule, error)
+	TestSigmaRules(ctx context.Context, reader io.Reader, ruleIDs []string, knowledgeID string) (*model.SigmaTestReport, error)
+	SearchByActivity(ctx context.Context, from, to *time.Time, typeArg []string, nums int) ([]*model.Knowledge, error)
+	KnowledgeTimeline(ctx context.Context, ids []string, typeArg []string, from, to *time.Time, revisions bool) (*model.Timeline, error)
+	KnowledgeKinds(ctx context.Context) ([]*model.KindInfo, error)
+	TypedKnowledgeByID(ctx context.Context, id string) (model.TypedKnowledge, error)
+	ListTypedKnowledge(ctx context.Context, kind string, nums int) ([]model.TypedKnowledge, error)
+	ListKnowledgeSchemas(ctx context.Context) ([]*model.KnowledgeSchema, error)
+	KnowledgeSchema(ctx context.Context, knowledgeType string) (*model.KnowledgeSchema, error)
+	DryRunKnowledgeSchema(ctx context.Context, knowledgeType string, data []byte, nums int) (*model.SchemaDryRunReport, error)
+	Taxonomy(ctx context.Context, field string) (*model.Taxonomy, error)
+	MigrationStatus(ctx context.Context) (*model.MigrationStatus, error)
+	QualityRules(ctx context.Context) ([]*model.QualityRule, error)
+	DuplicateCandidates(ctx context.Context, status string, nums int) ([]*model.DuplicateCandidate, error)
+	KnowledgeMerges(ctx context.Context, id string) ([]*model.KnowledgeMerge, error)
+	ListRelationships(ctx context.Context, source, target, relType string) ([]*model.Relationship, error)
+	RelatedKnowledge(ctx context.Context, id, relType, direction string) ([]*model.RelatedKnowledge, error)
+	KnowledgeGraph(ctx context.Context, query model.GraphQuery) (*model.GraphResult, error)
 }
 
-func (r *mutationResolver) BatchEditKnowledgeType(ctx context.Context, idList []string, prevType string, repType string) (*model.DeletionStatus, error) {
+// BatchEditKnowledgeType atomic 时在事务中执行，任一条目失败则全部回滚
+func (r *mutationResolver) BatchEditKnowledgeType(ctx context.Context, idList []string, prevType string, repType string, atomic bool) (*model.DeletionStatus, error) {
 	collection := database.GetCollection("knowledge")
 
 	if len(idList) <= 0 {
@@ -51,48 +132,78 @@ func (r *mutationResolver) BatchEditKnowledgeType(ctx context.Context, idList []
 	}
 
 	var fail []string
-	for _, id := range idList {
-		// 查找文档
-		var knowledge model.Knowledge
-		err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&knowledge)
-		if err != nil {
-			fail = append(fail, id+": 不存在")
-			continue
-		}
+	rolledBack, err := inTransaction(ctx, atomic, func(ctx context.Context) error {
+		fail = nil // 事务遇到写冲突时会重新执行
+		for _, id := range idList {
+			// 查找文档，已被合并的条目修改保留条目
+			var knowledge model.Knowledge
+			id, err := resolveKnowledgeID(ctx, id)
+			if err == nil {
+				err = collection.FindOne(ctx, bson.M{"_id": id}).Decode(&knowledge)
+			}
+			if err != nil {
+				fail = append(fail, id+": 不存在")
+				continue
+			}
+
+			before := knowledge
+			before.KnowledgeType = append([]string(nil), knowledge.KnowledgeType...)
+
+			// 替换knowledgeType
+			found := false
+			for i, t := range knowledge.KnowledgeType {
+				if repType == t {
+					continue
+				}
+				if prevType == t {
+					knowledge.KnowledgeType[i] = repType
+					found = true
+					break
+				}
+			}
 
-		// 替换knowledgeType
-		found := false
-		for i, t := range knowledge.KnowledgeType {
-			if repType == t {
+			if !found {
+				fail = append(fail, id+": 未找到prevType")
 				continue
 			}
-			if prevType == t {
-				knowledge.KnowledgeType[i] = repType
-				found = true
-				break
+			if err := applyTaxonomy(ctx, &knowledge, &before); err != nil {
+				fail = append(fail, id+": "+err.Error())
+				continue
+			}
+			if err := validateKnowledgeSchema(ctx, &knowledge); err != nil {
+				fail = append(fail, id+": "+err.Error())
+				continue
 			}
-		}
 
-		if !found {
-			fail = append(fail, id+": 未找到prevType")
-			continue
-		}
+			// 更新文档 id 字段为_id
+			update := touchKnowledge(bson.M{"$set": bson.M{"knowledgeType": knowledge.KnowledgeType}})
+			_, err = collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+			if err != nil {
+				fail = append(fail, id+": 更新失败，"+err.Error())
+				continue
+			}
 
-		// 更新文档 id 字段为_id
-		update := bson.M{"$set": bson.M{"knowledgeType": knowledge.KnowledgeType}}
-		_, err = collection.UpdateOne(ctx, bson.M{"_id": id}, update)
-		if err != nil {
-			fail = append(fail, id+": 更新失败，"+err.Error())
-			continue
+			// 验证更新结果
+			var updatedKnowledge model.Knowledge
+			err = collection.FindOne(ctx, bson.M{"_id": id}).Decode(&updatedKnowledge)
+			if err != nil || !reflect.DeepEqual(updatedKnowledge.KnowledgeType, knowledge.KnowledgeType) {
+				result := fmt.Sprintf("[%s]", strings.Join(strings.Fields(fmt.Sprint(updatedKnowledge.KnowledgeType)), ", "))
+				fail = append(fail, id+": 更新失败，结果为："+result)
+			}
 		}
-
-		// 验证更新结果
-		var updatedKnowledge model.Knowledge
-		err = collection.FindOne(ctx, bson.M{"_id": id}).Decode(&updatedKnowledge)
-		if err != nil || !reflect.DeepEqual(updatedKnowledge.KnowledgeType, knowledge.KnowledgeType) {
-			result := fmt.Sprintf("[%s]", strings.Join(strings.Fields(fmt.Sprint(updatedKnowledge.KnowledgeType)), ", "))
-			fail = append(fail, id+": 更新失败，结果为："+result)
+		if atomic && len(fail) > 0 {
+			return errRollback
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rolledBack {
+		return &model.DeletionStatus{
+			Success: false,
+			Message: "更新失败，已全部回滚：" + strings.Join(fail, "; "),
+		}, nil
 	}
 
 	if len(fail) > 0 {
@@ -135,7 +246,16 @@ func (r *queryResolver) SearchById(ctx context.Context, typeArg []string, id str
 	}
 
 	if len(results) == 0 {
-		return results, nil
+		// 已被合并的条目返回合并后的条目
+		target, err := knowledgeRedirect(ctx, id)
+		if err != nil || target == "" {
+			return results, err
+		}
+		results, err = r.SearchById(ctx, typeArg, target)
+		for _, result := range results {
+			result.Message = "Redirected from " + id
+		}
+		return results, err
 	}
 
 	return results, nil
@@ -180,102 +300,73 @@ func (r *mutationResolver) CreateKnowledge(ctx context.Context, input model.NewK
 		input.ID = primitive.NewObjectID().Hex()
 	}
 
-	doc := model.Knowledge{
-		ID:                  input.ID,
-		Title:               input.Title,
-		Tags:                input.Tags,
-		TechniquesID:        input.TechniquesID,
-		TacticsID:           input.TacticsID,
-		KnowledgeType:       input.KnowledgeType,
-		KnowledgeSource:     input.KnowledgeSource,
-		Confidentiality:     input.Confidentiality,
-		Abstract:            input.Abstract,
-		Content:             input.Content,
-		Detection:           input.Detection,
-		Mitigations:         input.Mitigations,
-		Recommendations:     input.Recommendations,
-		Directory:           input.Directory,
-		Techniques:          input.Techniques,
-		Tactics:             input.Tactics,
-		UsedExploits:        input.UsedExploits,
-		Alias:               input.Alias,
-		VulType:             input.VulType,
-		Affiliation:         input.Affiliation,
-		UsedTools:           input.UsedTools,
-		StrategicCapability: input.StrategicCapability,
-		FirstActivity:       input.FirstActivity,
-		LatestActivity:      input.LatestActivity,
-		TargetedGeography:   input.TargetedGeography,
-		TimeLine:            input.TimeLine,
-		Scenario:            input.Scenario,
-		Motivations:         input.Motivations,
-		TargetedIndustry:    input.TargetedIndustry,
-		Preparation:         input.Preparation,
-		Alert:               input.Alert,
-		Analysis:            input.Analysis,
-		Traces:              input.Traces,
-		Containment:         input.Containment,
-		Eradication:         input.Eradication,
-		Recovery:            input.Recovery,
-		FollowUp:            input.FollowUp,
-		DisposalProcess:     input.DisposalProcess,
-		Cases:               input.Cases,
-		Cve:                 input.Cve,
-		Cnnvd:               input.Cnnvd,
-		Cwd:                 input.Cwd,
-		Cvss:                input.Cvss,
-		Bugtraq:             input.Bugtraq,
-		CvssStr:             input.CvssStr,
-		Msf:                 input.Msf,
-		Exploitdb:           input.Exploitdb,
-		IsExp:               input.IsExp,
-		Vendor:              input.Vendor,
-		AppType:             input.AppType,
-		Consequence:         input.Consequence,
-		FingerPrint:         input.FingerPrint,
-		RevisionDate:        input.RevisionDate,
-		Products:            input.Products,
-		Reference:           input.Reference,
-		Author:              input.Author,
-		UID:                 input.UID,
-		SubTechniquesID:     input.SubTechniquesID,
-		Platforms:           input.Platforms,
-		AffectedVerison:     input.AffectedVerison,
-		ThreatSeverity:      input.ThreatSeverity,
-		Solution:            input.Solution,
-		Cnvd:                input.Cnvd,
-		Cwe:                 input.Cwe,
-		AppName:             input.AppName,
-		OrganizationIds:     input.OrganizationIds,
-		IoC:                 input.IoC,
-		TiName:              input.TiName,
-		InputParameters:     input.InputParameters,
-		OutputParameters:    input.OutputParameters,
-		Success:             true, // 设置默认值
-		Message:             "Created successfully",
+	doc := knowledgeFromInput(input)
+	doc.Success = true // 设置默认值
+	doc.Message = "Created successfully"
+	doc.Created = time.Now().UTC()
+	doc.Modified = doc.Created
+	if err := normalizeKnowledge(ctx, &doc); err != nil {
+		return nil, err
 	}
+	if err := validateKnowledgeSchema(ctx, &doc); err != nil {
+		return nil, err
+	}
+	deriveKnowledge(&doc)
 
 	_, err := collection.InsertOne(ctx, doc)
 	if err != nil {
-		log.Fatal(err)
+		log.Println(err)
 		return nil, err
 	}
 
+	if err := syncAliases(ctx, &doc); err != nil {
+		log.Println("Failed to sync aliases:", err)
+	}
+	if err := syncIndicators(ctx, doc.ID, doc.IoC); err != nil {
+		log.Println("Failed to sync indicators:", err)
+	}
+
 	return &doc, nil
 }
 
 func (r *mutationResolver) UpdateKnowledge(ctx context.Context, id string, input model.NewKnowledge) (*model.Knowledge, error) {
 	collection := database.GetCollection("knowledge")
+	id, err := resolveKnowledgeID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := knowledgeFromInput(input)
+	doc.ID = id
+	var existing model.Knowledge
+	projection := bson.M{"affectedProducts": 1}
+	for _, field := range model.TaxonomyFields {
+		projection[field] = 1
+	}
+	err = collection.FindOne(ctx, bson.M{"_id": id}, options.FindOne().SetProjection(projection)).Decode(&existing)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	// 请求中没有 affectedProducts 时保留原有的结构化范围，例如 NVD 导入的版本区间；传 [] 可以清空
+	if input.AffectedProducts == nil {
+		doc.AffectedProducts = existing.AffectedProducts
+	}
+	if err := normalizeKnowledge(ctx, &doc, &existing); err != nil {
+		return nil, err
+	}
+	if err := validateKnowledgeSchema(ctx, &doc); err != nil {
+		return nil, err
+	}
 
 	filter := bson.M{"_id": id}
 	update := bson.M{
 		"$set": bson.M{
 			"title":               input.Title,
-			"tags":                input.Tags,
+			"tags":                doc.Tags,
 			"techniquesId":        input.TechniquesID,
 			"tacticsId":           input.TacticsID,
-			"knowledgeType":       input.KnowledgeType,
-			"knowledgeSource":     input.KnowledgeSource,
+			"knowledgeType":       doc.KnowledgeType,
+			"knowledgeSource":     doc.KnowledgeSource,
 			"confidentiality":     input.Confidentiality,
 			"abstract":            input.Abstract,
 			"content":             input.Content,
@@ -327,21 +418,27 @@ func (r *mutationResolver) UpdateKnowledge(ctx context.Context, id string, input
 			"author":              input.Author,
 			"subTechniquesId":     input.SubTechniquesID,
 			"platforms":           input.Platforms,
-			"affectedVersion":     input.AffectedVerison,
+			"affectedVerison":     input.AffectedVerison,
 			"threatSeverity":      input.ThreatSeverity,
 			"solution":            input.Solution,
 			"cnvd":                input.Cnvd,
 			"cwe":                 input.Cwe,
 			"appName":             input.AppName,
 			"organizationIds":     input.OrganizationIds,
-			"IoC":                 input.IoC,
-			"TiName":              input.TiName,
+			"ioc":                 input.IoC,
+			"tiName":              input.TiName,
 			"inputParameters":     input.InputParameters,
 			"outputParameters":    input.OutputParameters,
+			"affectedProducts":    doc.AffectedProducts,
 		},
 	}
 
-	_, err := collection.UpdateOne(ctx, filter, update)
+	// 同步更新派生字段
+	for field, value := range deriveKnowledge(&doc) {
+		update["$set"].(bson.M)[field] = value
+	}
+
+	_, err = collection.UpdateOne(ctx, filter, touchKnowledge(update))
 	if err != nil {
 		return nil, err
 	}
@@ -352,12 +449,26 @@ func (r *mutationResolver) UpdateKnowledge(ctx context.Context, id string, input
 		return nil, err
 	}
 
+	if err := syncAliases(ctx, &result); err != nil {
+		log.Println("Failed to sync aliases:", err)
+	}
+	if err := syncIndicators(ctx, id, input.IoC); err != nil {
+		log.Println("Failed to sync indicators:", err)
+	}
+	if err := refreshDetectionRules(ctx, &result); err != nil {
+		log.Println("Failed to refresh detection rules:", err)
+	}
+
 	return &result, nil
 }
 
 // DeleteKnowledge 实现
 func (r *mutationResolver) DeleteKnowledge(ctx context.Context, id string) (*model.DeletionStatus, error) {
 	collection := database.GetCollection("knowledge")
+	id, err := resolveKnowledgeID(ctx, id)
+	if err != nil {
+		return &model.DeletionStatus{Success: false, Message: err.Error()}, err
+	}
 
 	filter := bson.M{"_id": id} //链接_id
 	deleteResult, err := collection.DeleteOne(ctx, filter)
@@ -369,6 +480,19 @@ func (r *mutationResolver) DeleteKnowledge(ctx context.Context, id string) (*mod
 		return &model.DeletionStatus{Success: false, Message: "No document found with that ID"}, nil
 	}
 
+	if err := removeAliases(ctx, id); err != nil {
+		log.Println("Failed to remove aliases:", err)
+	}
+	if err := removeIndicators(ctx, id); err != nil {
+		log.Println("Failed to remove indicators:", err)
+	}
+	if err := removeDetectionRules(ctx, id); err != nil {
+		log.Println("Failed to remove detection rules:", err)
+	}
+	if err := removeRelationships(ctx, id); err != nil {
+		log.Println("Failed to remove relationships:", err)
+	}
+
 	return &model.DeletionStatus{Success: true, Message: "Deleted successfully"}, nil
 }
 
@@ -693,21 +817,14 @@ func (r *queryResolver) MitreBySubTechniquesID(ctx context.Context, subTechnique
 //
 // 修改二
 func (r *queryResolver) Search(ctx context.Context, where *model.KnowledgeFilter, keyword []string, authors []string, nums int, nodedict string) ([]*model.Knowledge, error) {
-	var filter bson.M
-
-	// Build filter based on KnowledgeFilter fields
-	whereJson, err := bson.Marshal(where)
+	filter, err := whereFilter(where)
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
-	if err := bson.Unmarshal(whereJson, &filter); err != nil {
-		log.Println(err)
-		return nil, err
-	}
-
 	// Add keyword search
+	var expansion *aliasExpansion
 	if len(keyword) > 0 {
 		orConditions := []bson.M{}
 
@@ -728,6 +845,18 @@ func (r *queryResolver) Search(ctx context.Context, where *model.KnowledgeFilter
 				orConditions = append(orConditions, bson.M{"abstract": bson.M{"$regex": key, "$options": "i"}})
 			}
 		}
+
+		// 通过别名注册表扩展关键字，例如搜索 "APT29" 也能命中标题为 "Cozy Bear" 的条目
+		expansion, err = expandAliases(ctx, keyword)
+		if err != nil {
+			return nil, err
+		}
+		switch nodedict {
+		case "title", "content", "abstract":
+			orConditions = append(orConditions, expansion.conditions(nodedict)...)
+		default:
+			orConditions = append(orConditions, expansion.conditions("title", "content", "abstract")...)
+		}
 		filter["$or"] = orConditions
 	}
 
@@ -736,16 +865,23 @@ func (r *queryResolver) Search(ctx context.Context, where *model.KnowledgeFilter
 		filter["author"] = bson.M{"$all": authors}
 	}
 
-	if len(where.Tags) > 0 {
-		filter["tags"] = bson.M{"$in": where.Tags}
-	}
-
 	collection := database.GetCollection("knowledge")
 
 	findOptions := options.Find()
 	if nums > 0 {
 		findOptions.SetLimit(int64(nums))
 	}
+	switch where.SortBy {
+	case "":
+	case "epss":
+		findOptions.SetSort(bson.D{{Key: "epss.score", Value: -1}})
+	case "cvss":
+		findOptions.SetSort(bson.D{{Key: "cvssInfo.baseScore", Value: -1}})
+	case "kev":
+		findOptions.SetSort(bson.D{{Key: "kev.dateAdded", Value: -1}})
+	default:
+		return nil, errors.New("invalid sort: " + where.SortBy)
+	}
 
 	cursor, err := collection.Find(ctx, filter, findOptions)
 	if err != nil {
@@ -767,9 +903,71 @@ func (r *queryResolver) Search(ctx context.Context, where *model.KnowledgeFilter
 		return nil, err
 	}
 
+	if expansion != nil {
+		expansion.annotate(results)
+	}
+
 	return results, nil
 }
 
+// whereFilter 将 KnowledgeFilter 转换为查询条件，不包括关键字和作者
+func whereFilter(where *model.KnowledgeFilter) (bson.M, error) {
+	var filter bson.M
+
+	// Build filter based on KnowledgeFilter fields
+	whereJson, err := bson.Marshal(where)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bson.Unmarshal(whereJson, &filter); err != nil {
+		return nil, err
+	}
+
+	if len(where.Tags) > 0 {
+		filter["tags"] = bson.M{"$in": where.Tags}
+	}
+
+	// CVSS 分值范围、等级过滤
+	scoreRange := bson.M{}
+	if where.CvssMin != nil {
+		scoreRange["$gte"] = *where.CvssMin
+	}
+	if where.CvssMax != nil {
+		scoreRange["$lte"] = *where.CvssMax
+	}
+	if len(scoreRange) > 0 {
+		filter["cvssInfo.baseScore"] = scoreRange
+	}
+	if len(where.Severity) > 0 {
+		filter["cvssInfo.severity"] = bson.M{"$in": where.Severity}
+	}
+	if where.CvssMismatch != nil {
+		filter["cvssInfo.mismatch"] = *where.CvssMismatch
+	}
+
+	// 在野利用、利用代码、EPSS 过滤
+	if where.KnownExploited != nil {
+		if *where.KnownExploited {
+			filter["knownExploited"] = true
+		} else {
+			filter["knownExploited"] = bson.M{"$ne": true}
+		}
+	}
+	if where.ExploitAvailable != nil {
+		if *where.ExploitAvailable {
+			filter["exploitInfo.available"] = true
+		} else {
+			filter["exploitInfo.available"] = bson.M{"$ne": true}
+		}
+	}
+	if where.EpssMin != nil {
+		filter["epss.score"] = bson.M{"$gte": *where.EpssMin}
+	}
+
+	return filter, nil
+}
+
 // func (r *queryResolver) Search(ctx context.Context, where *model.KnowledgeFilter, keyword []string, authors []string, nums int, nodedict string) ([]*model.Knowledge, error) {
 // 	var (
 // 		filterList        []bson.M
@@ -921,6 +1119,7 @@ func (r *queryResolver) SearchByKeyword(ctx context.Context, typeArg []string, k
 	if len(typeArg) > 0 {
 		filter["knowledgeType"] = bson.M{"$in": typeArg}
 	}
+	var expansion *aliasExpansion
 	if len(keyword) > 0 {
 		orConditions := []bson.M{}
 		for _, key := range keyword { // 搜索关键字的字段
@@ -929,6 +1128,13 @@ func (r *queryResolver) SearchByKeyword(ctx context.Context, typeArg []string, k
 			orConditions = append(orConditions, bson.M{"tags": key})
 			orConditions = append(orConditions, bson.M{"content": bson.M{"$regex": key, "$options": "i"}})
 		}
+
+		var err error
+		expansion, err = expandAliases(ctx, keyword)
+		if err != nil {
+			return nil, err
+		}
+		orConditions = append(orConditions, expansion.conditions("title", "content")...)
 		filter["$or"] = orConditions
 	}
 
@@ -957,6 +1163,10 @@ func (r *queryResolver) SearchByKeyword(ctx context.Context, typeArg []string, k
 		return nil, err
 	}
 
+	if expansion != nil {
+		expansion.annotate(results)
+	}
+
 	return results, nil
 }
 
@@ -0,0 +1,279 @@
+package resolvers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// parseSBOM 识别 CycloneDX 或 SPDX JSON 格式并提取组件
+func parseSBOM(data []byte) (*model.SBOMReport, []*model.SBOMComponent, error) {
+	var probe struct {
+		BOMFormat   string `json:"bomFormat"`
+		SPDXVersion string `json:"spdxVersion"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, nil, err
+	}
+
+	report := &model.SBOMReport{Severity: map[string]int{}, Findings: []*model.SBOMFinding{}}
+	var components []*model.SBOMComponent
+
+	switch {
+	case strings.EqualFold(probe.BOMFormat, "CycloneDX"):
+		var bom model.CycloneDXBOM
+		if err := json.Unmarshal(data, &bom); err != nil {
+			return nil, nil, err
+		}
+		report.Format, report.SpecVersion = "CycloneDX", bom.SpecVersion
+
+		refs := make(map[string]*model.SBOMComponent)
+		var walk func(list []model.CycloneDXComponent)
+		walk = func(list []model.CycloneDXComponent) {
+			for _, c := range list {
+				component := &model.SBOMComponent{
+					Ref:     c.BOMRef,
+					Name:    c.Name,
+					Vendor:  c.Group,
+					Version: c.Version,
+					PURL:    c.Purl,
+					CPE:     c.Cpe,
+				}
+				components = append(components, component)
+				if c.BOMRef != "" {
+					refs[c.BOMRef] = component
+				}
+				walk(c.Components)
+			}
+		}
+		walk(bom.Components)
+
+		for _, v := range bom.Vulnerabilities {
+			id := strings.ToUpper(strings.TrimSpace(v.ID))
+			if !strings.HasPrefix(id, "CVE-") {
+				continue
+			}
+			for _, a := range v.Affects {
+				if c, ok := refs[a.Ref]; ok && !containsString(c.Cves, id) {
+					c.Cves = append(c.Cves, id)
+				}
+			}
+		}
+
+	case probe.SPDXVersion != "":
+		var doc model.SPDXDocument
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, nil, err
+		}
+		report.Format, report.SpecVersion = "SPDX", doc.SPDXVersion
+
+		for _, p := range doc.Packages {
+			component := &model.SBOMComponent{
+				Ref:     p.SPDXID,
+				Name:    p.Name,
+				Version: p.VersionInfo,
+			}
+			if p.VersionInfo == "NOASSERTION" {
+				component.Version = ""
+			}
+			if i := strings.Index(p.Supplier, ":"); i >= 0 {
+				component.Vendor = strings.TrimSpace(p.Supplier[i+1:])
+			}
+			for _, ref := range p.ExternalRefs {
+				switch ref.ReferenceType {
+				case "purl":
+					component.PURL = ref.ReferenceLocator
+				case "cpe23Type", "cpe22Type":
+					if component.CPE == "" {
+						component.CPE = ref.ReferenceLocator
+					}
+				}
+			}
+			components = append(components, component)
+		}
+
+	default:
+		return nil, nil, errors.New("unsupported SBOM: expected CycloneDX or SPDX JSON")
+	}
+
+	report.Components = len(components)
+	return report, components, nil
+}
+
+// componentQuery 由组件的 CPE、purl 或名称生成受影响产品查询条件。
+// purl 的 namespace(maven groupId、npm scope 等)和 CPE 的厂商写法差别很大，不作为厂商条件
+func componentQuery(c *model.SBOMComponent) model.ProductQuery {
+	if c.CPE != "" {
+		if cpe, err := util.ParseCPE(c.CPE); err == nil && cpe.Product != "*" {
+			q := model.ProductQuery{Vendor: cpe.Vendor, Product: cpe.Product, Version: cpe.Version}
+			if q.Version == "*" || q.Version == "" {
+				q.Version = c.Version
+			}
+			return q
+		}
+	}
+	if c.PURL != "" {
+		if purl, err := util.ParsePURL(c.PURL); err == nil {
+			q := model.ProductQuery{Product: purl.Name, Version: purl.Version}
+			if q.Version == "" {
+				q.Version = c.Version
+			}
+			return q
+		}
+	}
+	return model.ProductQuery{Product: c.Name, Version: c.Version}
+}
+
+// hasExploit 判断知识条目是否记录了可用的利用方式
+func hasExploit(k *model.Knowledge) bool {
+	info := k.ExploitInfo
+	if info == nil {
+		info = deriveExploit(k.IsExp, k.Msf, k.Exploitdb)
+	}
+	return info != nil && info.Available
+}
+
+func newSBOMFinding(c *model.SBOMComponent, k *model.Knowledge, matchedBy string, verified bool) *model.SBOMFinding {
+	finding := &model.SBOMFinding{
+		Component:        c,
+		KnowledgeID:      k.ID,
+		Title:            k.Title,
+		Cve:              k.Cve,
+		Cvss:             k.Cvss,
+		MatchedBy:        matchedBy,
+		VersionVerified:  verified,
+		ExploitAvailable: hasExploit(k),
+		KnownExploited:   k.KnownExploited,
+		IsExp:            k.IsExp,
+		Msf:              k.Msf,
+		Exploitdb:        k.Exploitdb,
+		Solution:         k.Solution,
+	}
+	if k.CvssInfo != nil {
+		finding.Severity = k.CvssInfo.Severity
+	}
+	return finding
+}
+
+// matchComponentText 在没有结构化范围的知识条目中，按 products/appName 文本匹配组件名称，
+// 再用 affectedVerison 判断版本；版本描述无法解析时仍然返回，但标记为未验证版本
+func matchComponentText(ctx context.Context, c *model.SBOMComponent, q model.ProductQuery) ([]*model.SBOMFinding, error) {
+	name := strings.TrimSpace(c.Name)
+	if name == "" {
+		name = q.Product
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	pattern := `(^|[^0-9A-Za-z])` + regexp.QuoteMeta(name) + `($|[^0-9A-Za-z])`
+	filter := bson.M{
+		"affectedProducts": bson.M{"$in": []interface{}{nil, bson.A{}}},
+		"$or": []bson.M{
+			{"products": bson.M{"$regex": pattern, "$options": "i"}},
+			{"appName": bson.M{"$regex": pattern, "$options": "i"}},
+		},
+	}
+	cursor, err := database.GetCollection("knowledge").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var findings []*model.SBOMFinding
+	for cursor.Next(ctx) {
+		var result model.Knowledge
+		if err := cursor.Decode(&result); err != nil {
+			return nil, err
+		}
+
+		ranges, _ := util.ParseVersionExpr(result.AffectedVerison)
+		if q.Version == "" || len(ranges) == 0 {
+			findings = append(findings, newSBOMFinding(c, &result, model.SBOMMatchProduct, false))
+			continue
+		}
+		for _, v := range ranges {
+			if v.Contains(q.Version) {
+				findings = append(findings, newSBOMFinding(c, &result, model.SBOMMatchProduct, true))
+				break
+			}
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return findings, nil
+}
+
+// MatchSBOM 解析上传的 CycloneDX/SPDX JSON，将组件与漏洞知识匹配：
+// 先用 SBOM 中声明的 CVE，再用结构化受影响范围，最后回退到 vendor/products/affectedVerison 文本
+func (r *queryResolver) MatchSBOM(ctx context.Context, data []byte) (*model.SBOMReport, error) {
+	report, components, err := parseSBOM(data)
+	if err != nil {
+		return nil, err
+	}
+	collection := database.GetCollection("knowledge")
+	index, err := cveIndex(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range components {
+		seen := make(map[string]bool)
+		add := func(f *model.SBOMFinding) {
+			if seen[f.KnowledgeID] {
+				return
+			}
+			seen[f.KnowledgeID] = true
+			report.Findings = append(report.Findings, f)
+			severity := f.Severity
+			if severity == "" {
+				severity = "unknown"
+			}
+			report.Severity[severity]++
+		}
+
+		for _, cve := range c.Cves {
+			existing, err := findIndexedCve(ctx, collection, index, cve)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				add(newSBOMFinding(c, existing, model.SBOMMatchCVE, true))
+			}
+		}
+
+		q := componentQuery(c)
+		if q.Product != "" {
+			matches, err := r.SearchByAffectedProduct(ctx, q, 0)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range matches {
+				add(newSBOMFinding(c, m.Knowledge, model.SBOMMatchCPE, q.Version != ""))
+			}
+
+			findings, err := matchComponentText(ctx, c, q)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range findings {
+				add(f)
+			}
+		}
+
+		if len(seen) > 0 {
+			report.Affected++
+		}
+	}
+
+	return report, nil
+}
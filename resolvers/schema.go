@@ -0,0 +1,225 @@
+package resolvers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// schemaManagedFields 由服务端维护的字段：派生字段、记录时间和 KEV、EPSS 标注。
+// 创建时还没有这些字段，已有条目中有，校验前统一去掉，所有写入路径和 dryrun 校验同样的内容
+var schemaManagedFields = []string{
+	"created", "modified", "knownExploited", "kev", "epss",
+	"matchedAlias", "cvssInfo", "exploitInfo", "activity", "timelineEvents",
+}
+
+// schemaDocument 将知识条目转换为 schema 校验的 JSON 对象，字段名与接口一致。
+// 空字符串、空数组视为未填写，这样 required 才有意义
+func schemaDocument(doc *model.Knowledge) (map[string]interface{}, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+
+	delete(m, "success")
+	delete(m, "message")
+	for _, field := range schemaManagedFields {
+		delete(m, field)
+	}
+	for field, value := range m {
+		switch v := value.(type) {
+		case nil:
+			delete(m, field)
+		case string:
+			if strings.TrimSpace(v) == "" {
+				delete(m, field)
+			}
+		case []interface{}:
+			if len(v) == 0 {
+				delete(m, field)
+			}
+		}
+	}
+	return m, nil
+}
+
+func loadKnowledgeSchema(ctx context.Context, knowledgeType string) (*model.KnowledgeSchema, error) {
+	var schema model.KnowledgeSchema
+	err := database.GetCollection("knowledge_schemas").FindOne(ctx, bson.M{"_id": knowledgeType}).Decode(&schema)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	schema.Schema = json.RawMessage(schema.Source)
+	return &schema, nil
+}
+
+// validateKnowledgeSchema 按条目的每个 knowledgeType 校验，没有定义 schema 的类型不做限制
+func validateKnowledgeSchema(ctx context.Context, doc *model.Knowledge) error {
+	var m map[string]interface{}
+	for _, knowledgeType := range doc.KnowledgeType {
+		stored, err := loadKnowledgeSchema(ctx, knowledgeType)
+		if err != nil {
+			return err
+		}
+		if stored == nil {
+			continue
+		}
+		schema, err := util.CompileSchema(stored.Schema)
+		if err != nil {
+			return fmt.Errorf("stored schema for %s: %v", knowledgeType, err)
+		}
+		if m == nil {
+			if m, err = schemaDocument(doc); err != nil {
+				return err
+			}
+		}
+		if errs := schema.Validate(m); len(errs) > 0 {
+			return &model.SchemaValidationError{KnowledgeType: knowledgeType, Errors: errs}
+		}
+	}
+	return nil
+}
+
+// ListKnowledgeSchemas 返回全部 knowledgeType 的 schema
+func (r *queryResolver) ListKnowledgeSchemas(ctx context.Context) ([]*model.KnowledgeSchema, error) {
+	cursor, err := database.GetCollection("knowledge_schemas").Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	schemas := []*model.KnowledgeSchema{}
+	for cursor.Next(ctx) {
+		var schema model.KnowledgeSchema
+		if err := cursor.Decode(&schema); err != nil {
+			return nil, err
+		}
+		schema.Schema = json.RawMessage(schema.Source)
+		schemas = append(schemas, &schema)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return schemas, nil
+}
+
+// KnowledgeSchema 返回某一 knowledgeType 的 schema
+func (r *queryResolver) KnowledgeSchema(ctx context.Context, knowledgeType string) (*model.KnowledgeSchema, error) {
+	schema, err := loadKnowledgeSchema(ctx, knowledgeType)
+	if err != nil {
+		return nil, err
+	}
+	if schema == nil {
+		return nil, errors.New("No document found with that ID")
+	}
+	return schema, nil
+}
+
+// DryRunKnowledgeSchema 用 schema 校验该 knowledgeType 的全部已有条目，返回会被拒绝的条目。
+// schema 为空时使用已保存的 schema
+func (r *queryResolver) DryRunKnowledgeSchema(ctx context.Context, knowledgeType string, data []byte, nums int) (*model.SchemaDryRunReport, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		stored, err := loadKnowledgeSchema(ctx, knowledgeType)
+		if err != nil {
+			return nil, err
+		}
+		if stored == nil {
+			return nil, fmt.Errorf("%w: no schema saved for %s, submit one to dry-run", ErrInvalidInput, knowledgeType)
+		}
+		data = stored.Schema
+	}
+	schema, err := util.CompileSchema(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	cursor, err := database.GetCollection("knowledge").Find(ctx, bson.M{"knowledgeType": knowledgeType}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	report := &model.SchemaDryRunReport{KnowledgeType: knowledgeType, Documents: []*model.SchemaViolation{}}
+	for cursor.Next(ctx) {
+		var knowledge model.Knowledge
+		if err := cursor.Decode(&knowledge); err != nil {
+			return nil, err
+		}
+		report.Scanned++
+
+		m, err := schemaDocument(&knowledge)
+		if err != nil {
+			return nil, err
+		}
+		errs := schema.Validate(m)
+		if len(errs) == 0 {
+			continue
+		}
+		report.Violating++
+		if nums <= 0 || len(report.Documents) < nums {
+			report.Documents = append(report.Documents, &model.SchemaViolation{ID: knowledge.ID, Title: knowledge.Title, Errors: errs})
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// SaveKnowledgeSchema 保存某一 knowledgeType 的 schema，之后的创建和修改按新 schema 校验，已有条目不受影响
+func (r *mutationResolver) SaveKnowledgeSchema(ctx context.Context, knowledgeType string, data []byte) (*model.KnowledgeSchema, error) {
+	knowledgeType = strings.TrimSpace(knowledgeType)
+	if knowledgeType == "" {
+		return nil, fmt.Errorf("%w: knowledgeType is required", ErrInvalidInput)
+	}
+	if _, err := util.CompileSchema(data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	schema := &model.KnowledgeSchema{
+		KnowledgeType: knowledgeType,
+		Source:        compact.String(),
+		Schema:        json.RawMessage(compact.String()),
+		Modified:      time.Now().UTC(),
+	}
+	_, err := database.GetCollection("knowledge_schemas").ReplaceOne(ctx, bson.M{"_id": knowledgeType}, schema, options.Replace().SetUpsert(true))
+	if err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+func (r *mutationResolver) DeleteKnowledgeSchema(ctx context.Context, knowledgeType string) (*model.DeletionStatus, error) {
+	deleteResult, err := database.GetCollection("knowledge_schemas").DeleteOne(ctx, bson.M{"_id": knowledgeType})
+	if err != nil {
+		return &model.DeletionStatus{Success: false, Message: err.Error()}, err
+	}
+	if deleteResult.DeletedCount == 0 {
+		return &model.DeletionStatus{Success: false, Message: "No schema found for that knowledgeType"}, nil
+	}
+	return &model.DeletionStatus{Success: true, Message: "Deleted successfully"}, nil
+}
@@ -0,0 +1,108 @@
+package resolvers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type sampleEvent struct {
+	line  int
+	event map[string]interface{}
+}
+
+// readSampleEvents 读取 JSON lines 格式的样本事件，空行跳过，无法解析的行记录行号
+func readSampleEvents(reader io.Reader, report *model.SigmaTestReport) ([]sampleEvent, error) {
+	var events []sampleEvent
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var event map[string]interface{}
+		dec := json.NewDecoder(strings.NewReader(line))
+		dec.UseNumber()
+		if err := dec.Decode(&event); err != nil || event == nil {
+			report.InvalidLines = append(report.InvalidLines, n)
+			continue
+		}
+		events = append(events, sampleEvent{line: n, event: event})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// TestSigmaRules 用样本事件测试已保存的 Sigma 规则，返回每条规则命中的事件行号。
+// ruleIDs、knowledgeID 都为空时测试全部 Sigma 规则
+func (r *queryResolver) TestSigmaRules(ctx context.Context, reader io.Reader, ruleIDs []string, knowledgeID string) (*model.SigmaTestReport, error) {
+	report := &model.SigmaTestReport{InvalidLines: []int{}, Results: []*model.SigmaRuleResult{}}
+
+	events, err := readSampleEvents(reader, report)
+	if err != nil {
+		return nil, err
+	}
+	report.Events = len(events)
+
+	filter := bson.M{"type": util.RuleSigma}
+	if len(ruleIDs) > 0 {
+		filter["_id"] = bson.M{"$in": ruleIDs}
+	}
+	if knowledgeID != "" {
+		id, err := resolveKnowledgeID(ctx, knowledgeID)
+		if err != nil {
+			return nil, err
+		}
+		filter["knowledgeId"] = id
+	}
+	var rules []*model.DetectionRule
+	cursor, err := database.GetCollection("detection_rules").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	report.Rules = len(rules)
+
+	for _, rule := range rules {
+		result := &model.SigmaRuleResult{
+			RuleID:      rule.ID,
+			KnowledgeID: rule.KnowledgeID,
+			Name:        rule.Name,
+			Lines:       []int{},
+		}
+		report.Results = append(report.Results, result)
+
+		compiled, err := util.CompileSigma(rule.Rule)
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		for _, e := range events {
+			if compiled.Match(e.event) {
+				result.Lines = append(result.Lines, e.line)
+			}
+		}
+		result.Matched = len(result.Lines)
+		if result.Matched > 0 {
+			report.MatchedRules++
+		}
+	}
+
+	sort.SliceStable(report.Results, func(i, j int) bool {
+		return report.Results[i].Matched > report.Results[j].Matched
+	})
+	return report, nil
+}
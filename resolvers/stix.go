@@ -0,0 +1,205 @@
+package resolvers
+
+import (
+	"fmt"
+	"mongdbs/model"
+	"mongdbs/util"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	stixTimeFormat = "2006-01-02T15:04:05.000Z"
+	customSTIXType = "x-mongdbs-knowledge"
+)
+
+// tlpMarkings TLP 2.0 对应的 STIX marking-definition
+var tlpMarkings = map[string]string{
+	util.TLPClear:       "marking-definition--94868c89-83c2-464b-929b-a1a8aa3c8487",
+	util.TLPGreen:       "marking-definition--bab4a63c-aed9-4cf5-a766-dfca5abac2bb",
+	util.TLPAmber:       "marking-definition--55d920b0-5e8b-4f79-9ee9-91f868d9b421",
+	util.TLPAmberStrict: "marking-definition--939a9414-2ddd-4d32-a0cd-375ea402b003",
+	util.TLPRed:         "marking-definition--e828b379-4e03-4974-9ac4-e53a884c97c1",
+}
+
+// stixTypeRules 知识类型中的关键字对应的 STIX 对象类型，按顺序匹配
+var stixTypeRules = []struct {
+	keywords []string
+	stixType string
+}{
+	{[]string{"漏洞", "vulnerability"}, "vulnerability"},
+	{[]string{"组织", "actor", "intrusion"}, "intrusion-set"},
+	{[]string{"活动", "campaign"}, "campaign"},
+	{[]string{"恶意", "木马", "病毒", "malware"}, "malware"},
+	{[]string{"工具", "tool"}, "tool"},
+	{[]string{"技术", "technique", "att&ck"}, "attack-pattern"},
+}
+
+// stixIndicatorPatterns 指标类型对应的 STIX 模式
+var stixIndicatorPatterns = map[string]string{
+	util.IndicatorIPv4:     "ipv4-addr:value",
+	util.IndicatorIPv6:     "ipv6-addr:value",
+	util.IndicatorCIDR:     "ipv4-addr:value",
+	util.IndicatorDomain:   "domain-name:value",
+	util.IndicatorURL:      "url:value",
+	util.IndicatorEmail:    "email-addr:value",
+	util.IndicatorMD5:      "file:hashes.MD5",
+	util.IndicatorSHA1:     "file:hashes.'SHA-1'",
+	util.IndicatorSHA256:   "file:hashes.'SHA-256'",
+	util.IndicatorFilePath: "file:name",
+	util.IndicatorRegistry: "windows-registry-key:key",
+}
+
+// stixType 根据知识类型选择主对象的 STIX 类型，无法对应时作为 report
+func stixType(k *model.Knowledge) string {
+	for _, t := range k.KnowledgeType {
+		t = strings.ToLower(t)
+		for _, rule := range stixTypeRules {
+			for _, keyword := range rule.keywords {
+				if strings.Contains(t, keyword) {
+					return rule.stixType
+				}
+			}
+		}
+	}
+	return "report"
+}
+
+func stixTime(t time.Time) string {
+	if t.IsZero() {
+		t = time.Unix(0, 0)
+	}
+	return t.UTC().Format(stixTimeFormat)
+}
+
+func stixPatternValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v)
+}
+
+// knowledgeSTIX 将知识条目转换为 STIX 对象：主对象、IoC 对应的 indicator、ATT&CK 技术对应的
+// attack-pattern 以及它们之间的 relationship。对象 id 由知识条目 id 生成，多次导出保持不变
+func knowledgeSTIX(k *model.Knowledge) []*model.STIXObject {
+	base := knowledgeUUID(k.ID)
+	created, modified := stixTime(k.Created), stixTime(k.Modified)
+	if k.Created.IsZero() {
+		created = modified
+	}
+	var markings []string
+	if marking, ok := tlpMarkings[util.NormalizeTLP(k.Confidentiality)]; ok {
+		markings = []string{marking}
+	}
+	newObject := func(objectType, key string) *model.STIXObject {
+		return &model.STIXObject{
+			Type:              objectType,
+			SpecVersion:       "2.1",
+			ID:                objectType + "--" + uuid.NewSHA1(base, []byte(objectType+"|"+key)).String(),
+			Created:           created,
+			Modified:          modified,
+			ObjectMarkingRefs: markings,
+		}
+	}
+
+	mainType := stixType(k)
+	main := newObject(mainType, "")
+	main.ID = mainType + "--" + base.String()
+	main.Name = k.Title
+	main.Description = k.Abstract
+	if main.Description == "" {
+		main.Description = k.Content
+	}
+	main.Labels = k.Tags
+	switch mainType {
+	case "intrusion-set", "campaign", "malware", "tool":
+		main.Aliases = util.SplitList(k.Alias)
+	}
+	if mainType == "malware" {
+		isFamily := true
+		main.IsFamily = &isFamily
+	}
+	for _, cve := range util.ExtractCVEIDs(k.Cve) {
+		main.ExternalReferences = append(main.ExternalReferences, model.STIXExternalReference{SourceName: "cve", ExternalID: cve})
+	}
+	for _, cwe := range util.ExtractCWEIDs(k.Cwe) {
+		main.ExternalReferences = append(main.ExternalReferences, model.STIXExternalReference{SourceName: "cwe", ExternalID: cwe})
+	}
+	if mainType == "vulnerability" && len(util.ExtractCVEIDs(k.Cve)) > 0 && main.Name == "" {
+		main.Name = util.ExtractCVEIDs(k.Cve)[0]
+	}
+
+	objects := []*model.STIXObject{main}
+	var refs []string
+	relate := func(relationshipType, source, target string) {
+		rel := newObject("relationship", relationshipType+"|"+source+"|"+target)
+		rel.RelationshipType, rel.SourceRef, rel.TargetRef = relationshipType, source, target
+		objects = append(objects, rel)
+		refs = append(refs, rel.ID)
+	}
+	// indicator 可以 indicates 的目标类型
+	indicates := map[string]bool{"intrusion-set": true, "campaign": true, "malware": true, "tool": true, "attack-pattern": true}
+	uses := map[string]bool{"intrusion-set": true, "campaign": true, "malware": true, "tool": true}
+
+	for _, indicator := range util.ExtractIndicators(k.IoC) {
+		path, ok := stixIndicatorPatterns[indicator.Type]
+		if !ok {
+			continue
+		}
+		if indicator.Type == util.IndicatorCIDR && strings.Contains(indicator.Value, ":") {
+			path = "ipv6-addr:value"
+		}
+		obj := newObject("indicator", indicator.Type+"|"+indicator.Value)
+		obj.Name = indicator.Value
+		obj.PatternType = "stix"
+		obj.Pattern = fmt.Sprintf("[%s = '%s']", path, stixPatternValue(indicator.Value))
+		obj.ValidFrom = created
+		objects = append(objects, obj)
+		refs = append(refs, obj.ID)
+		if indicates[mainType] {
+			relate("indicates", obj.ID, main.ID)
+		}
+	}
+
+	for _, ids := range [][]string{k.TechniquesID, k.SubTechniquesID} {
+		for _, id := range ids {
+			if id == "" {
+				continue
+			}
+			if mainType == "attack-pattern" {
+				// 知识条目本身就是 ATT&CK 技术
+				main.ExternalReferences = append(main.ExternalReferences, model.STIXExternalReference{SourceName: "mitre-attack", ExternalID: id})
+				continue
+			}
+			// 同一技术在不同知识条目中使用相同的 id，便于客户端合并
+			obj := &model.STIXObject{
+				Type:        "attack-pattern",
+				SpecVersion: "2.1",
+				ID:          "attack-pattern--" + uuid.NewSHA1(knowledgeNamespace, []byte("attack-pattern|"+id)).String(),
+				Created:     created,
+				Modified:    modified,
+				Name:        id,
+				ExternalReferences: []model.STIXExternalReference{
+					{SourceName: "mitre-attack", ExternalID: id, URL: "https://attack.mitre.org/techniques/" + strings.ReplaceAll(id, ".", "/")},
+				},
+			}
+			objects = append(objects, obj)
+			refs = append(refs, obj.ID)
+			if uses[mainType] {
+				relate("uses", main.ID, obj.ID)
+			}
+		}
+	}
+
+	if mainType == "report" {
+		if len(refs) > 0 {
+			main.Published = modified
+			main.ObjectRefs = refs
+		} else {
+			// report 必须引用至少一个对象，没有 IoC 和技术的条目作为自定义对象导出
+			main.Type = customSTIXType
+			main.ID = customSTIXType + "--" + base.String()
+		}
+	}
+
+	return objects
+}
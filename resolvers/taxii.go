@@ -0,0 +1,329 @@
+package resolvers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	taxiiDefaultLimit = 100
+	taxiiMaxLimit     = 1000
+)
+
+var (
+	ErrCollectionNotFound  = errors.New("collection not found")
+	ErrCollectionForbidden = errors.New("client is not allowed to read this collection")
+	ErrInvalidNext         = errors.New("invalid next parameter")
+)
+
+func hashTAXIIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateTAXIIClient 创建 TAXII 客户端并生成访问密钥，密钥只在创建时返回
+func (r *mutationResolver) CreateTAXIIClient(ctx context.Context, input model.NewTAXIIClient) (*model.TAXIIClientKey, error) {
+	clearance := util.NormalizeTLP(input.Clearance)
+	if input.Name == "" || clearance == "" {
+		return nil, errors.New("name and a valid TLP clearance must be provided")
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	key := hex.EncodeToString(secret)
+
+	client := &model.TAXIIClient{
+		ID:        uuid.New().String(),
+		Name:      input.Name,
+		KeyHash:   hashTAXIIKey(key),
+		Clearance: clearance,
+		Created:   time.Now().UTC(),
+	}
+	if _, err := database.GetCollection("taxii_clients").InsertOne(ctx, client); err != nil {
+		return nil, err
+	}
+	return &model.TAXIIClientKey{TAXIIClient: client, Key: key}, nil
+}
+
+func (r *queryResolver) ListTAXIIClients(ctx context.Context) ([]*model.TAXIIClient, error) {
+	results := []*model.TAXIIClient{}
+	cursor, err := database.GetCollection("taxii_clients").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *mutationResolver) DeleteTAXIIClient(ctx context.Context, id string) (*model.DeletionStatus, error) {
+	deleteResult, err := database.GetCollection("taxii_clients").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return &model.DeletionStatus{Success: false, Message: err.Error()}, err
+	}
+	if deleteResult.DeletedCount == 0 {
+		return &model.DeletionStatus{Success: false, Message: "No client found with that ID"}, nil
+	}
+	return &model.DeletionStatus{Success: true, Message: "Deleted successfully"}, nil
+}
+
+// AuthenticateTAXIIClient 校验客户端 id 和密钥，不匹配时返回 nil
+func (r *queryResolver) AuthenticateTAXIIClient(ctx context.Context, id, key string) (*model.TAXIIClient, error) {
+	var client model.TAXIIClient
+	err := database.GetCollection("taxii_clients").FindOne(ctx, bson.M{"_id": id}).Decode(&client)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(client.KeyHash), []byte(hashTAXIIKey(key))) != 1 {
+		return nil, nil
+	}
+	return &client, nil
+}
+
+// CreateTAXIICollection 定义一个 TAXII 集合，tlp 为空时视为 TLP:CLEAR
+func (r *mutationResolver) CreateTAXIICollection(ctx context.Context, input model.NewTAXIICollection) (*model.TAXIICollectionDef, error) {
+	if input.Title == "" {
+		return nil, errors.New("title must be provided")
+	}
+	tlp := util.TLPClear
+	if input.TLP != "" {
+		if tlp = util.NormalizeTLP(input.TLP); tlp == "" {
+			return nil, errors.New("invalid tlp: " + input.TLP)
+		}
+	}
+
+	def := &model.TAXIICollectionDef{
+		ID:            uuid.New().String(),
+		Title:         input.Title,
+		Description:   input.Description,
+		KnowledgeType: input.KnowledgeType,
+		Tags:          input.Tags,
+		Keyword:       input.Keyword,
+		TLP:           tlp,
+	}
+	if _, err := database.GetCollection("taxii_collections").InsertOne(ctx, def); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+func (r *queryResolver) ListTAXIICollectionDefs(ctx context.Context) ([]*model.TAXIICollectionDef, error) {
+	results := []*model.TAXIICollectionDef{}
+	cursor, err := database.GetCollection("taxii_collections").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *mutationResolver) DeleteTAXIICollection(ctx context.Context, id string) (*model.DeletionStatus, error) {
+	deleteResult, err := database.GetCollection("taxii_collections").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return &model.DeletionStatus{Success: false, Message: err.Error()}, err
+	}
+	if deleteResult.DeletedCount == 0 {
+		return &model.DeletionStatus{Success: false, Message: "No collection found with that ID"}, nil
+	}
+	return &model.DeletionStatus{Success: true, Message: "Deleted successfully"}, nil
+}
+
+func taxiiCollection(def *model.TAXIICollectionDef, client *model.TAXIIClient) *model.TAXIICollection {
+	return &model.TAXIICollection{
+		ID:          def.ID,
+		Title:       def.Title,
+		Description: def.Description,
+		CanRead:     util.TLPAllows(client.Clearance, def.TLP),
+		CanWrite:    false,
+		MediaTypes:  []string{model.STIXMediaType},
+	}
+}
+
+// TAXIICollections 列出全部集合，can_read 取决于客户端授权是否达到集合的 TLP 等级
+func (r *queryResolver) TAXIICollections(ctx context.Context, client *model.TAXIIClient) (*model.TAXIICollections, error) {
+	defs, err := r.ListTAXIICollectionDefs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.TAXIICollections{Collections: []*model.TAXIICollection{}}
+	for _, def := range defs {
+		result.Collections = append(result.Collections, taxiiCollection(def, client))
+	}
+	return result, nil
+}
+
+func findTAXIICollection(ctx context.Context, id string) (*model.TAXIICollectionDef, error) {
+	var def model.TAXIICollectionDef
+	err := database.GetCollection("taxii_collections").FindOne(ctx, bson.M{"_id": id}).Decode(&def)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCollectionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+func (r *queryResolver) TAXIICollection(ctx context.Context, client *model.TAXIIClient, id string) (*model.TAXIICollection, error) {
+	def, err := findTAXIICollection(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return taxiiCollection(def, client), nil
+}
+
+// collectionFilter 由集合定义生成知识条目查询条件，并排除 confidentiality 超过集合 TLP 等级的条目
+func collectionFilter(ctx context.Context, def *model.TAXIICollectionDef) (bson.M, error) {
+	filter := bson.M{}
+	if len(def.KnowledgeType) > 0 {
+		filter["knowledgeType"] = bson.M{"$in": def.KnowledgeType}
+	}
+	if len(def.Tags) > 0 {
+		filter["tags"] = bson.M{"$in": def.Tags}
+	}
+	if len(def.Keyword) > 0 {
+		var orConditions []bson.M
+		for _, key := range def.Keyword {
+			pattern := regexp.QuoteMeta(key)
+			for _, field := range []string{"title", "abstract", "content"} {
+				orConditions = append(orConditions, bson.M{field: bson.M{"$regex": pattern, "$options": "i"}})
+			}
+		}
+		filter["$or"] = orConditions
+	}
+
+	// confidentiality 是自由文本，先取出全部取值再按 TLP 等级筛选
+	values, err := database.GetCollection("knowledge").Distinct(ctx, "confidentiality", bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	allowed := []interface{}{nil, ""}
+	for _, v := range values {
+		if s, ok := v.(string); ok && s != "" && util.TLPAllows(def.TLP, s) {
+			allowed = append(allowed, s)
+		}
+	}
+	filter["confidentiality"] = bson.M{"$in": allowed}
+
+	return filter, nil
+}
+
+// encodeTAXIICursor 生成 next 参数，编码上一页最后一条的修改时间和 id
+func encodeTAXIICursor(t time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(t.UnixNano(), 10) + ":" + id))
+}
+
+func decodeTAXIICursor(next string) (time.Time, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(next)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidNext
+	}
+	nanos, id, ok := strings.Cut(string(data), ":")
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if !ok || err != nil {
+		return time.Time{}, "", ErrInvalidNext
+	}
+	return time.Unix(0, n).UTC(), id, nil
+}
+
+// TAXIIObjects 按修改时间分页返回集合中的 STIX 对象。分页以知识条目为单位，
+// 每个条目转换出的对象(主对象、indicator、relationship 等)总在同一页
+func (r *queryResolver) TAXIIObjects(ctx context.Context, client *model.TAXIIClient, id string, addedAfter *time.Time, limit int, next string, types []string) (*model.TAXIIEnvelope, error) {
+	def, err := findTAXIICollection(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !util.TLPAllows(client.Clearance, def.TLP) {
+		return nil, ErrCollectionForbidden
+	}
+
+	filter, err := collectionFilter(ctx, def)
+	if err != nil {
+		return nil, err
+	}
+	// 没有记录时间的早期数据需要先通过 /api/knowledge/derive 回填
+	conditions := []bson.M{{"modified": bson.M{"$exists": true}}}
+	if addedAfter != nil {
+		conditions = append(conditions, bson.M{"modified": bson.M{"$gt": *addedAfter}})
+	}
+	if next != "" {
+		t, lastID, err := decodeTAXIICursor(next)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, bson.M{"$or": []bson.M{
+			{"modified": bson.M{"$gt": t}},
+			{"modified": t, "_id": bson.M{"$gt": lastID}},
+		}})
+	}
+	filter["$and"] = conditions
+
+	if limit <= 0 {
+		limit = taxiiDefaultLimit
+	}
+	if limit > taxiiMaxLimit {
+		limit = taxiiMaxLimit
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "modified", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit + 1))
+
+	var knowledge []*model.Knowledge
+	cursor, err := database.GetCollection("knowledge").Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &knowledge); err != nil {
+		return nil, err
+	}
+
+	envelope := &model.TAXIIEnvelope{Objects: []*model.STIXObject{}}
+	if len(knowledge) > limit {
+		knowledge = knowledge[:limit]
+		last := knowledge[len(knowledge)-1]
+		envelope.More = true
+		envelope.Next = encodeTAXIICursor(last.Modified, last.ID)
+	}
+	if len(knowledge) > 0 {
+		envelope.DateAddedFirst = knowledge[0].Modified
+		envelope.DateAddedLast = knowledge[len(knowledge)-1].Modified
+	}
+
+	seen := make(map[string]bool)
+	for _, k := range knowledge {
+		for _, obj := range knowledgeSTIX(k) {
+			if seen[obj.ID] || (len(types) > 0 && !containsString(types, obj.Type)) {
+				continue
+			}
+			seen[obj.ID] = true
+			envelope.Objects = append(envelope.Objects, obj)
+		}
+	}
+
+	return envelope, nil
+}
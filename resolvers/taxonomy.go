@@ -0,0 +1,574 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mongdbs/database"
+	"mongdbs/model"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var taxonomyIndexOnce sync.Once
+
+// ensureTaxonomyIndexes 同一字段内任何名称、英文名、同义词都不能重复
+func ensureTaxonomyIndexes() {
+	taxonomyIndexOnce.Do(func() {
+		_, err := database.GetCollection("taxonomy_terms").Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+			{Keys: bson.D{{Key: "field", Value: 1}, {Key: "keys", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "field", Value: 1}, {Key: "parent", Value: 1}}},
+		})
+		if err != nil {
+			log.Println("Failed to create taxonomy indexes:", err)
+		}
+	})
+}
+
+func checkTaxonomyField(field string) error {
+	for _, f := range model.TaxonomyFields {
+		if f == field {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: field must be one of %s", ErrInvalidInput, strings.Join(model.TaxonomyFields, ", "))
+}
+
+// taxonomyValues 返回知识条目中受词表管理的字段
+func taxonomyValues(doc *model.Knowledge, field string) *[]string {
+	switch field {
+	case model.TaxonomyKnowledgeType:
+		return &doc.KnowledgeType
+	case model.TaxonomyTags:
+		return &doc.Tags
+	case model.TaxonomyKnowledgeSource:
+		return &doc.KnowledgeSource
+	}
+	return nil
+}
+
+// cleanTermNames 去掉首尾空白、空值和重复值
+func cleanTermNames(names []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, name)
+	}
+	return out
+}
+
+// setTermKeys 整理词条的名称并计算匹配用的 keys，同义词中与名称相同的去掉
+func setTermKeys(term *model.TaxonomyTerm) {
+	term.Name = strings.TrimSpace(term.Name)
+	term.NameEn = strings.TrimSpace(term.NameEn)
+	names := map[string]bool{strings.ToLower(term.Name): true, strings.ToLower(term.NameEn): true}
+	var synonyms []string
+	for _, s := range cleanTermNames(term.Synonyms) {
+		if !names[strings.ToLower(s)] {
+			synonyms = append(synonyms, s)
+		}
+	}
+	term.Synonyms = synonyms
+	term.Keys = nil
+	for _, s := range cleanTermNames(append([]string{term.Name, term.NameEn}, term.Synonyms...)) {
+		term.Keys = append(term.Keys, strings.ToLower(s))
+	}
+}
+
+// canonicalValues 将值替换为词条名称并去重，返回不在词表中的值
+func canonicalValues(values []string, names map[string]string) ([]string, []string) {
+	var out, unknown []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if name, ok := names[strings.ToLower(value)]; ok {
+			value = name
+		} else {
+			unknown = append(unknown, value)
+		}
+		if !seen[value] {
+			seen[value] = true
+			out = append(out, value)
+		}
+	}
+	return out, unknown
+}
+
+func termNames(terms []*model.TaxonomyTerm) map[string]string {
+	names := make(map[string]string)
+	for _, term := range terms {
+		for _, key := range term.Keys {
+			names[key] = term.Name
+		}
+	}
+	return names
+}
+
+func findTaxonomyTerms(ctx context.Context, filter bson.M) ([]*model.TaxonomyTerm, error) {
+	cursor, err := database.GetCollection("taxonomy_terms").Find(ctx, filter, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var terms []*model.TaxonomyTerm
+	if err := cursor.All(ctx, &terms); err != nil {
+		return nil, err
+	}
+	return terms, nil
+}
+
+func findTaxonomyTerm(ctx context.Context, id string) (*model.TaxonomyTerm, error) {
+	var term model.TaxonomyTerm
+	err := database.GetCollection("taxonomy_terms").FindOne(ctx, bson.M{"_id": id}).Decode(&term)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, errors.New("No term found with that ID")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &term, nil
+}
+
+func taxonomyStrict(ctx context.Context, field string) (bool, error) {
+	var setting model.TaxonomySetting
+	err := database.GetCollection("taxonomy_settings").FindOne(ctx, bson.M{"_id": field}).Decode(&setting)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	return setting.Strict, err
+}
+
+// applyTaxonomy 写入知识前将 knowledgeType、tags、knowledgeSource 规范化为词条名称，
+// 严格模式的字段出现词表之外的值时报错。stored 为修改前的条目，其中已有的词表外的值是开启严格模式前的历史数据，
+// 只改动其他字段的写入不因为这些值被拒绝，只检查本次新写入的值
+func applyTaxonomy(ctx context.Context, doc *model.Knowledge, stored ...*model.Knowledge) error {
+	for _, field := range model.TaxonomyFields {
+		values := taxonomyValues(doc, field)
+		if len(*values) == 0 {
+			continue
+		}
+		var keys []string
+		for _, value := range *values {
+			keys = append(keys, strings.ToLower(strings.TrimSpace(value)))
+		}
+		terms, err := findTaxonomyTerms(ctx, bson.M{"field": field, "keys": bson.M{"$in": keys}})
+		if err != nil {
+			return err
+		}
+
+		canonical, unknown := canonicalValues(*values, termNames(terms))
+		unknown = newTaxonomyValues(field, unknown, stored)
+		if len(unknown) > 0 {
+			strict, err := taxonomyStrict(ctx, field)
+			if err != nil {
+				return err
+			}
+			if strict {
+				return &model.TaxonomyViolationError{Field: field, Values: unknown}
+			}
+		}
+		*values = canonical
+	}
+	return nil
+}
+
+// newTaxonomyValues 去掉修改前的条目中已经存在的值
+func newTaxonomyValues(field string, values []string, stored []*model.Knowledge) []string {
+	existing := make(map[string]bool)
+	for _, doc := range stored {
+		if doc == nil {
+			continue
+		}
+		for _, value := range *taxonomyValues(doc, field) {
+			existing[strings.ToLower(strings.TrimSpace(value))] = true
+		}
+	}
+	var out []string
+	for _, value := range values {
+		if !existing[strings.ToLower(value)] {
+			out = append(out, value)
+		}
+	}
+	return out
+}
+
+// checkTermParent 上级词条必须属于同一字段，且不能形成环
+func checkTermParent(ctx context.Context, term *model.TaxonomyTerm) error {
+	for parentID := term.Parent; parentID != ""; {
+		if parentID == term.ID {
+			return fmt.Errorf("%w: parent would create a cycle", ErrInvalidInput)
+		}
+		parent, err := findTaxonomyTerm(ctx, parentID)
+		if err != nil {
+			return fmt.Errorf("%w: parent %s: %v", ErrInvalidInput, parentID, err)
+		}
+		if parent.Field != term.Field {
+			return fmt.Errorf("%w: parent belongs to %s", ErrInvalidInput, parent.Field)
+		}
+		parentID = parent.Parent
+	}
+	return nil
+}
+
+func termConflict(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: name, nameEn or synonym is already used by another term", ErrInvalidInput)
+	}
+	return err
+}
+
+// rewriteTaxonomy 将 filter 命中的知识条目中 field 的值按 names 改写为词条名称
+func rewriteTaxonomy(ctx context.Context, field string, names map[string]string, filter bson.M, status *model.TaxonomyRewriteStatus) error {
+	collection := database.GetCollection("knowledge")
+	cursor, err := collection.Find(ctx, filter, options.Find().SetProjection(bson.M{field: 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var models []mongo.WriteModel
+	flush := func() error {
+		if len(models) == 0 {
+			return nil
+		}
+		result, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if result != nil {
+			status.Modified += int(result.ModifiedCount)
+		}
+		models = models[:0]
+		return err
+	}
+
+	for cursor.Next(ctx) {
+		var doc model.Knowledge
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		status.Scanned++
+
+		values := *taxonomyValues(&doc, field)
+		canonical, unknown := canonicalValues(values, names)
+		if status.Unknown != nil {
+			for _, value := range unknown {
+				status.Unknown[value]++
+			}
+		}
+		if strings.Join(canonical, "\x00") == strings.Join(values, "\x00") {
+			continue
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": doc.ID}).
+			SetUpdate(touchKnowledge(bson.M{"$set": bson.M{field: canonical}})))
+		if len(models) >= 500 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// rewriteTermDocuments 改写使用了词条任一名称的知识条目
+func rewriteTermDocuments(ctx context.Context, term *model.TaxonomyTerm, status *model.TaxonomyRewriteStatus) error {
+	var patterns []interface{}
+	for _, key := range term.Keys {
+		patterns = append(patterns, primitive.Regex{Pattern: "^" + regexp.QuoteMeta(key) + "$", Options: "i"})
+	}
+	return rewriteTaxonomy(ctx, term.Field, termNames([]*model.TaxonomyTerm{term}), bson.M{term.Field: bson.M{"$in": patterns}}, status)
+}
+
+// Taxonomy 返回字段的词表，按上下级组织为树
+func (r *queryResolver) Taxonomy(ctx context.Context, field string) (*model.Taxonomy, error) {
+	if err := checkTaxonomyField(field); err != nil {
+		return nil, err
+	}
+	strict, err := taxonomyStrict(ctx, field)
+	if err != nil {
+		return nil, err
+	}
+	terms, err := findTaxonomyTerms(ctx, bson.M{"field": field})
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string]*model.TaxonomyNode)
+	for _, term := range terms {
+		nodes[term.ID] = &model.TaxonomyNode{TaxonomyTerm: term}
+	}
+	taxonomy := &model.Taxonomy{Field: field, Strict: strict, Terms: []*model.TaxonomyNode{}}
+	for _, term := range terms {
+		if parent, ok := nodes[term.Parent]; ok {
+			parent.Children = append(parent.Children, nodes[term.ID])
+		} else {
+			taxonomy.Terms = append(taxonomy.Terms, nodes[term.ID])
+		}
+	}
+	return taxonomy, nil
+}
+
+func (r *mutationResolver) CreateTaxonomyTerm(ctx context.Context, input model.NewTaxonomyTerm) (*model.TaxonomyTerm, error) {
+	if err := checkTaxonomyField(input.Field); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	term := &model.TaxonomyTerm{
+		ID:          primitive.NewObjectID().Hex(),
+		Field:       input.Field,
+		Name:        input.Name,
+		NameEn:      input.NameEn,
+		Parent:      input.Parent,
+		Synonyms:    input.Synonyms,
+		Description: input.Description,
+		Created:     now,
+		Modified:    now,
+	}
+	setTermKeys(term)
+	if term.Name == "" {
+		return nil, fmt.Errorf("%w: name must be provided", ErrInvalidInput)
+	}
+	if err := checkTermParent(ctx, term); err != nil {
+		return nil, err
+	}
+
+	ensureTaxonomyIndexes()
+	if _, err := database.GetCollection("taxonomy_terms").InsertOne(ctx, term); err != nil {
+		return nil, termConflict(err)
+	}
+	return term, nil
+}
+
+// UpdateTaxonomyTerm 修改英文名、上级、同义词和说明。新增的同义词不会改写已有条目，需要时调用规范化
+func (r *mutationResolver) UpdateTaxonomyTerm(ctx context.Context, id string, input model.UpdateTaxonomyTerm) (*model.TaxonomyTerm, error) {
+	term, err := findTaxonomyTerm(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	term.NameEn = input.NameEn
+	term.Parent = input.Parent
+	term.Synonyms = input.Synonyms
+	term.Description = input.Description
+	term.Modified = time.Now().UTC()
+	setTermKeys(term)
+	if err := checkTermParent(ctx, term); err != nil {
+		return nil, err
+	}
+
+	if _, err := database.GetCollection("taxonomy_terms").ReplaceOne(ctx, bson.M{"_id": id}, term); err != nil {
+		return nil, termConflict(err)
+	}
+	return term, nil
+}
+
+// DeleteTaxonomyTerm 删除没有下级的词条，知识条目中的值保持不变
+func (r *mutationResolver) DeleteTaxonomyTerm(ctx context.Context, id string) (*model.DeletionStatus, error) {
+	collection := database.GetCollection("taxonomy_terms")
+	children, err := collection.CountDocuments(ctx, bson.M{"parent": id})
+	if err != nil {
+		return nil, err
+	}
+	if children > 0 {
+		return &model.DeletionStatus{Success: false, Message: "term has child terms, move or delete them first"}, nil
+	}
+
+	deleteResult, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return &model.DeletionStatus{Success: false, Message: err.Error()}, err
+	}
+	if deleteResult.DeletedCount == 0 {
+		return &model.DeletionStatus{Success: false, Message: "No term found with that ID"}, nil
+	}
+	return &model.DeletionStatus{Success: true, Message: "Deleted successfully"}, nil
+}
+
+// RenameTaxonomyTerm 修改词条名称，原名称保留为同义词，并改写所有使用该词条的知识条目。
+// knowledgeType 的 schema 随之改名。atomic 时在事务中执行，任何一步失败都不留下修改
+func (r *mutationResolver) RenameTaxonomyTerm(ctx context.Context, id, name string, atomic bool) (*model.TaxonomyRewriteStatus, error) {
+	var status *model.TaxonomyRewriteStatus
+	_, err := inTransaction(ctx, atomic, func(ctx context.Context) error {
+		var err error
+		status, err = renameTaxonomyTerm(ctx, id, name, atomic)
+		return err
+	})
+	if err != nil && atomic {
+		return nil, err
+	}
+	return status, err
+}
+
+func renameTaxonomyTerm(ctx context.Context, id, name string, atomic bool) (*model.TaxonomyRewriteStatus, error) {
+	term, err := findTaxonomyTerm(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name must be provided", ErrInvalidInput)
+	}
+
+	oldName := term.Name
+	term.Synonyms = append(term.Synonyms, oldName)
+	term.Name = name
+	term.Modified = time.Now().UTC()
+	setTermKeys(term)
+	if _, err := database.GetCollection("taxonomy_terms").ReplaceOne(ctx, bson.M{"_id": id}, term); err != nil {
+		return nil, termConflict(err)
+	}
+
+	if term.Field == model.TaxonomyKnowledgeType && oldName != name {
+		if err := renameKnowledgeSchema(ctx, oldName, name); err != nil {
+			if atomic {
+				return nil, err
+			}
+			log.Println("Failed to rename knowledge schema:", err)
+		}
+	}
+
+	status := &model.TaxonomyRewriteStatus{Field: term.Field, Term: term}
+	if err := rewriteTermDocuments(ctx, term, status); err != nil {
+		return status, err
+	}
+	return status, nil
+}
+
+// renameKnowledgeSchema 新名称还没有 schema 时，将原名称的 schema 移过去
+func renameKnowledgeSchema(ctx context.Context, from, to string) error {
+	schema, err := loadKnowledgeSchema(ctx, from)
+	if err != nil || schema == nil {
+		return err
+	}
+	existing, err := loadKnowledgeSchema(ctx, to)
+	if err != nil || existing != nil {
+		return err
+	}
+
+	collection := database.GetCollection("knowledge_schemas")
+	schema.KnowledgeType = to
+	schema.Modified = time.Now().UTC()
+	if _, err := collection.InsertOne(ctx, schema); err != nil {
+		return err
+	}
+	_, err = collection.DeleteOne(ctx, bson.M{"_id": from})
+	return err
+}
+
+// MergeTaxonomyTerm 将词条并入另一个词条：名称、英文名、同义词并入目标的同义词，下级移到目标下，
+// 知识条目中的值改写为目标名称。atomic 时在事务中执行，任何一步失败都不留下修改
+func (r *mutationResolver) MergeTaxonomyTerm(ctx context.Context, id, into string, atomic bool) (*model.TaxonomyRewriteStatus, error) {
+	var status *model.TaxonomyRewriteStatus
+	_, err := inTransaction(ctx, atomic, func(ctx context.Context) error {
+		var err error
+		status, err = mergeTaxonomyTerm(ctx, id, into)
+		return err
+	})
+	if err != nil && atomic {
+		return nil, err
+	}
+	return status, err
+}
+
+func mergeTaxonomyTerm(ctx context.Context, id, into string) (*model.TaxonomyRewriteStatus, error) {
+	if id == into {
+		return nil, fmt.Errorf("%w: cannot merge a term into itself", ErrInvalidInput)
+	}
+	source, err := findTaxonomyTerm(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	target, err := findTaxonomyTerm(ctx, into)
+	if err != nil {
+		return nil, err
+	}
+	if source.Field != target.Field {
+		return nil, fmt.Errorf("%w: cannot merge a %s term into a %s term", ErrInvalidInput, source.Field, target.Field)
+	}
+
+	collection := database.GetCollection("taxonomy_terms")
+	// 目标原本在被合并词条下时，移到被合并词条的上级
+	if target.Parent == source.ID {
+		target.Parent = source.Parent
+	}
+	// 先把被合并词条的 keys 换成占位值，释放名称的唯一索引，目标保存成功后再删除；
+	// 目标保存失败时恢复原来的 keys，非事务执行也不会丢掉被合并的词条
+	placeholder := bson.A{"\x00merging:" + source.ID}
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": source.ID}, bson.M{"$set": bson.M{"keys": placeholder}}); err != nil {
+		return nil, err
+	}
+	target.Synonyms = append(target.Synonyms, source.Name, source.NameEn)
+	target.Synonyms = append(target.Synonyms, source.Synonyms...)
+	if target.Description == "" {
+		target.Description = source.Description
+	}
+	target.Modified = time.Now().UTC()
+	setTermKeys(target)
+	if _, err := collection.ReplaceOne(ctx, bson.M{"_id": target.ID}, target); err != nil {
+		if _, restoreErr := collection.UpdateOne(ctx, bson.M{"_id": source.ID}, bson.M{"$set": bson.M{"keys": source.Keys}}); restoreErr != nil {
+			log.Println("Failed to restore merged term keys:", restoreErr)
+		}
+		return nil, termConflict(err)
+	}
+	if _, err := collection.UpdateMany(ctx, bson.M{"parent": source.ID, "_id": bson.M{"$ne": target.ID}}, bson.M{"$set": bson.M{"parent": target.ID}}); err != nil {
+		return nil, err
+	}
+	if _, err := collection.DeleteOne(ctx, bson.M{"_id": source.ID}); err != nil {
+		return nil, err
+	}
+
+	status := &model.TaxonomyRewriteStatus{Field: target.Field, Term: target}
+	if err := rewriteTermDocuments(ctx, target, status); err != nil {
+		return status, err
+	}
+	return status, nil
+}
+
+// SetTaxonomyStrict 设置字段是否只允许词表中的值
+func (r *mutationResolver) SetTaxonomyStrict(ctx context.Context, field string, strict bool) (*model.TaxonomySetting, error) {
+	if err := checkTaxonomyField(field); err != nil {
+		return nil, err
+	}
+	setting := &model.TaxonomySetting{Field: field, Strict: strict}
+	_, err := database.GetCollection("taxonomy_settings").ReplaceOne(ctx, bson.M{"_id": field}, setting, options.Replace().SetUpsert(true))
+	if err != nil {
+		return nil, err
+	}
+	return setting, nil
+}
+
+// NormalizeTaxonomy 将全部知识条目中字段的值规范化为词条名称，并统计词表之外的值，
+// 用于开启严格模式前清理历史数据
+func (r *mutationResolver) NormalizeTaxonomy(ctx context.Context, field string) (*model.TaxonomyRewriteStatus, error) {
+	if err := checkTaxonomyField(field); err != nil {
+		return nil, err
+	}
+	terms, err := findTaxonomyTerms(ctx, bson.M{"field": field})
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.TaxonomyRewriteStatus{Field: field, Unknown: map[string]int{}}
+	filter := bson.M{field: bson.M{"$exists": true, "$ne": bson.A{}}}
+	if err := rewriteTaxonomy(ctx, field, termNames(terms), filter, status); err != nil {
+		return status, err
+	}
+	return status, nil
+}
@@ -0,0 +1,110 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+	"mongdbs/database"
+	"mongdbs/model"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// activityFilter 活跃范围与 [from, to] 有重叠，任一端为 nil 表示不限。持续至今的范围没有结束日期
+func activityFilter(from, to *time.Time) bson.M {
+	filter := bson.M{"activity": bson.M{"$ne": nil}}
+	if to != nil {
+		filter["activity.start"] = bson.M{"$lte": *to}
+	}
+	if from != nil {
+		filter["$or"] = []bson.M{
+			{"activity.end": bson.M{"$gte": *from}},
+			{"activity.ongoing": true},
+		}
+	}
+	return filter
+}
+
+// SearchByActivity 查询在指定时间范围内活跃的知识条目(如 2023 年活跃的威胁组织)，按首次活动时间排序
+func (r *queryResolver) SearchByActivity(ctx context.Context, from, to *time.Time, typeArg []string, nums int) ([]*model.Knowledge, error) {
+	if from == nil && to == nil {
+		return nil, errors.New("from or to must be provided")
+	}
+	collection := database.GetCollection("knowledge")
+
+	filter := activityFilter(from, to)
+	if len(typeArg) > 0 {
+		filter["knowledgeType"] = bson.M{"$in": typeArg}
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "activity.start", Value: 1}, {Key: "_id", Value: 1}})
+	if nums > 0 {
+		findOptions.SetLimit(int64(nums))
+	}
+
+	results := []*model.Knowledge{}
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// KnowledgeTimeline 返回指定知识条目(或某类知识)的时间线事件，按开始时间排序，用于绘制时间轴。
+// from、to 限定事件的时间范围；revisions 为 false 时不包含修订日期
+func (r *queryResolver) KnowledgeTimeline(ctx context.Context, ids []string, typeArg []string, from, to *time.Time, revisions bool) (*model.Timeline, error) {
+	if len(ids) == 0 && len(typeArg) == 0 {
+		return nil, errors.New("id or type must be provided")
+	}
+	collection := database.GetCollection("knowledge")
+
+	filter := bson.M{"timelineEvents.0": bson.M{"$exists": true}}
+	if len(ids) > 0 {
+		ids, err := resolveKnowledgeIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		filter["_id"] = bson.M{"$in": ids}
+	}
+	if len(typeArg) > 0 {
+		filter["knowledgeType"] = bson.M{"$in": typeArg}
+	}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"title": 1, "timelineEvents": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var knowledge []*model.Knowledge
+	if err := cursor.All(ctx, &knowledge); err != nil {
+		return nil, err
+	}
+
+	timeline := &model.Timeline{Events: []*model.TimelineItem{}}
+	for _, k := range knowledge {
+		for _, e := range k.TimelineEvents {
+			if !revisions && e.Source == model.TimelineRevision {
+				continue
+			}
+			if (from != nil && e.End.Before(*from)) || (to != nil && e.Start.After(*to)) {
+				continue
+			}
+			timeline.Events = append(timeline.Events, &model.TimelineItem{KnowledgeID: k.ID, Title: k.Title, TimelineEvent: e})
+		}
+	}
+
+	sort.SliceStable(timeline.Events, func(i, j int) bool {
+		a, b := timeline.Events[i], timeline.Events[j]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		if !a.End.Equal(b.End) {
+			return a.End.Before(b.End)
+		}
+		return a.KnowledgeID < b.KnowledgeID
+	})
+	return timeline, nil
+}
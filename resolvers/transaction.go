@@ -0,0 +1,46 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+	"mongdbs/database"
+	"mongdbs/model"
+)
+
+// errRollback 事务中的函数返回它表示放弃本次写入，结果照常返回给调用方
+var errRollback = errors.New("transaction rolled back")
+
+// inTransaction atomic 时在事务中执行 fn，否则直接执行。fn 返回 errRollback 时回滚并返回 rolledBack，
+// 返回其他错误时回滚并返回该错误。
+// 事务默认最长 60 秒，数据量很大的导入应分批进行
+func inTransaction(ctx context.Context, atomic bool, fn func(ctx context.Context) error) (rolledBack bool, err error) {
+	if !atomic {
+		return false, fn(ctx)
+	}
+	err = database.WithTransaction(ctx, fn)
+	if errors.Is(err, errRollback) {
+		return true, nil
+	}
+	return false, err
+}
+
+// RunImport 执行一次导入，atomic 时在事务中执行，有任何条目失败则全部回滚
+func (r *mutationResolver) RunImport(ctx context.Context, atomic bool, run func(ctx context.Context) (*model.ImportReport, error)) (*model.ImportReport, error) {
+	var report *model.ImportReport
+	rolledBack, err := inTransaction(ctx, atomic, func(ctx context.Context) error {
+		var err error
+		report, err = run(ctx)
+		if err == nil && atomic && report.Failed > 0 {
+			return errRollback
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rolledBack {
+		report.Success = false
+		report.RolledBack = true
+	}
+	return report, nil
+}
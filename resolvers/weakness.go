@@ -0,0 +1,291 @@
+package resolvers
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mongdbs/database"
+	"mongdbs/model"
+	"mongdbs/util"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// decodeXMLElements 流式遍历 XML 文件，对每个名为 name 的元素调用 fn
+func decodeXMLElements(path, name string, fn func(dec *xml.Decoder, start *xml.StartElement) error) error {
+	file, err := util.OpenDataFile(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	dec := xml.NewDecoder(file)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == name {
+			if err := fn(dec, &start); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// upsertReference 写入参考数据集合，根据结果记录新建、更新或未变化
+func upsertReference(ctx context.Context, collection *mongo.Collection, id string, doc interface{}) *model.ImportItem {
+	item := &model.ImportItem{Key: id}
+	result, err := collection.ReplaceOne(ctx, bson.M{"_id": id}, doc, options.Replace().SetUpsert(true))
+	switch {
+	case err != nil:
+		item.Action, item.Error = model.ImportFailed, err.Error()
+	case result.UpsertedCount > 0:
+		item.Action = model.ImportCreated
+	case result.ModifiedCount > 0:
+		item.Action = model.ImportUpdated
+	default:
+		item.Action = model.ImportUnchanged
+	}
+	return item
+}
+
+// ImportCWECatalog 导入本地的 CWE XML 目录(cwec_v4.x.xml)到 cwe 集合
+func (r *mutationResolver) ImportCWECatalog(ctx context.Context, path string) (*model.ImportReport, error) {
+	collection := database.GetCollection("cwe")
+	report := &model.ImportReport{Success: true, Source: path}
+
+	err := decodeXMLElements(path, "Weakness", func(dec *xml.Decoder, start *xml.StartElement) error {
+		var w model.CWEXMLWeakness
+		if err := dec.DecodeElement(&w, start); err != nil {
+			return err
+		}
+		id := util.NormalizeCWE(w.ID)
+		if id == "" {
+			report.Add(&model.ImportItem{Key: w.ID, Action: model.ImportSkipped, Error: "invalid CWE id"})
+			return nil
+		}
+
+		weakness := model.Weakness{
+			ID:                  id,
+			Name:                w.Name,
+			Abstraction:         w.Abstraction,
+			Status:              w.Status,
+			Description:         util.StripMarkup(w.Description),
+			ExtendedDescription: util.StripMarkup(w.ExtendedDescription.Inner),
+		}
+		for _, rel := range w.RelatedWeaknesses {
+			if parent := util.NormalizeCWE(rel.CWEID); rel.Nature == "ChildOf" && parent != "" && !containsString(weakness.Parents, parent) {
+				weakness.Parents = append(weakness.Parents, parent)
+			}
+		}
+		for _, rel := range w.RelatedAttackPatterns {
+			if capec := util.NormalizeCAPEC(rel.CAPECID); capec != "" && !containsString(weakness.AttackPatterns, capec) {
+				weakness.AttackPatterns = append(weakness.AttackPatterns, capec)
+			}
+		}
+
+		report.Add(upsertReference(ctx, collection, id, weakness))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// ImportCAPECCatalog 导入本地的 CAPEC XML 目录(capec_v3.x.xml)到 capec 集合
+func (r *mutationResolver) ImportCAPECCatalog(ctx context.Context, path string) (*model.ImportReport, error) {
+	collection := database.GetCollection("capec")
+	report := &model.ImportReport{Success: true, Source: path}
+
+	err := decodeXMLElements(path, "Attack_Pattern", func(dec *xml.Decoder, start *xml.StartElement) error {
+		var p model.CAPECXMLAttackPattern
+		if err := dec.DecodeElement(&p, start); err != nil {
+			return err
+		}
+		id := util.NormalizeCAPEC(p.ID)
+		if id == "" {
+			report.Add(&model.ImportItem{Key: p.ID, Action: model.ImportSkipped, Error: "invalid CAPEC id"})
+			return nil
+		}
+
+		pattern := model.AttackPattern{
+			ID:          id,
+			Name:        p.Name,
+			Abstraction: p.Abstraction,
+			Status:      p.Status,
+			Description: util.StripMarkup(p.Description.Inner),
+			Likelihood:  p.Likelihood,
+			Severity:    p.Severity,
+		}
+		for _, rel := range p.RelatedWeaknesses {
+			if cwe := util.NormalizeCWE(rel.CWEID); cwe != "" && !containsString(pattern.Weaknesses, cwe) {
+				pattern.Weaknesses = append(pattern.Weaknesses, cwe)
+			}
+		}
+		for _, m := range p.TaxonomyMappings {
+			if m.TaxonomyName != "ATTACK" {
+				continue
+			}
+			technique := strings.ToUpper(strings.TrimSpace(m.EntryID))
+			if technique == "" {
+				continue
+			}
+			if !strings.HasPrefix(technique, "T") {
+				technique = "T" + technique
+			}
+			if !containsString(pattern.Techniques, technique) {
+				pattern.Techniques = append(pattern.Techniques, technique)
+			}
+		}
+
+		report.Add(upsertReference(ctx, collection, id, pattern))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// KnowledgeWeakness 返回知识条目 cwe 字段对应的 CWE 详情、相关 CAPEC 攻击模式及其映射的 ATT&CK 技术
+func (r *queryResolver) KnowledgeWeakness(ctx context.Context, id string) (*model.WeaknessReport, error) {
+	knowledge, err := findKnowledge(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.WeaknessReport{
+		KnowledgeID:        knowledge.ID,
+		Title:              knowledge.Title,
+		Weaknesses:         []*model.Weakness{},
+		AttackPatterns:     []*model.AttackPattern{},
+		Techniques:         []string{},
+		TechniqueKnowledge: []*model.Knowledge{},
+	}
+
+	cweIDs := util.ExtractCWEIDs(knowledge.Cwe)
+	if len(cweIDs) == 0 {
+		return report, nil
+	}
+
+	cursor, err := database.GetCollection("cwe").Find(ctx, bson.M{"_id": bson.M{"$in": cweIDs}})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &report.Weaknesses); err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool)
+	capecIDs := []string{}
+	for _, w := range report.Weaknesses {
+		found[w.ID] = true
+		capecIDs = append(capecIDs, w.AttackPatterns...)
+	}
+	for _, id := range cweIDs {
+		if !found[id] {
+			report.Missing = append(report.Missing, id)
+		}
+	}
+
+	// CWE 和 CAPEC 两边都可能声明关联，两个方向都查
+	filter := bson.M{"$or": []bson.M{
+		{"_id": bson.M{"$in": capecIDs}},
+		{"weaknesses": bson.M{"$in": cweIDs}},
+	}}
+	cursor, err = database.GetCollection("capec").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &report.AttackPatterns); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range report.AttackPatterns {
+		for _, t := range p.Techniques {
+			if !seen[t] {
+				seen[t] = true
+				report.Techniques = append(report.Techniques, t)
+			}
+		}
+	}
+	sort.Strings(report.Techniques)
+
+	if len(report.Techniques) > 0 {
+		techniques, err := r.MitreByTechniquesID(ctx, report.Techniques)
+		if err != nil {
+			return nil, err
+		}
+		if techniques != nil {
+			report.TechniqueKnowledge = techniques
+		}
+	}
+
+	return report, nil
+}
+
+// SearchByCWE 反向查找 cwe 字段包含指定 CWE 的全部知识条目
+func (r *queryResolver) SearchByCWE(ctx context.Context, cwe []string, excludeID string, nums int) ([]*model.Knowledge, error) {
+	collection := database.GetCollection("knowledge")
+
+	var orConditions []bson.M
+	for _, c := range cwe {
+		id := util.NormalizeCWE(c)
+		if id == "" {
+			continue
+		}
+		number := strings.TrimPrefix(id, "CWE-")
+		orConditions = append(orConditions,
+			bson.M{"cwe": bson.M{"$regex": `(?i)cwe[-_ ]?0*` + number + `([^0-9]|$)`}},
+			bson.M{"cwe": bson.M{"$regex": `^\s*` + number + `\s*$`}},
+		)
+	}
+	if len(orConditions) == 0 {
+		return nil, errors.New("cwe must be provided")
+	}
+
+	filter := bson.M{"$or": orConditions}
+	if excludeID != "" {
+		filter["_id"] = bson.M{"$ne": excludeID}
+	}
+
+	findOptions := options.Find()
+	if nums > 0 {
+		findOptions.SetLimit(int64(nums))
+	}
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []*model.Knowledge
+	for cursor.Next(ctx) {
+		var result model.Knowledge
+		err := cursor.Decode(&result)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, &result)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"io"
+	"mongdbs/resolvers"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var SBOM_MAX_SIZE int64 = 50 << 20 // 上传 SBOM 文件的大小上限
+
+// curl -X POST http://localhost:8085/api/sbom/match -F "sbom=@bom.cdx.json"
+// 也可以直接提交 JSON：curl -X POST http://localhost:8085/api/sbom/match -H "Content-Type: application/json" --data-binary @spdx.json
+func matchSBOMHandler(c *gin.Context) {
+	var reader io.Reader = c.Request.Body
+	if file, _, err := c.Request.FormFile("sbom"); err == nil {
+		defer file.Close()
+		reader = file
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, SBOM_MAX_SIZE+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if int64(len(data)) > SBOM_MAX_SIZE {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "SBOM file is too large"})
+		return
+	}
+	if len(data) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sbom must be provided"})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	report, err := resolver.Query().MatchSBOM(ctx, data)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
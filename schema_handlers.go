@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mongdbs/model"
+	"mongdbs/resolvers"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// schemaError 不符合 knowledgeType 的 schema 或严格模式的词表时返回 400 和全部问题，其他错误返回 500
+func schemaError(c *gin.Context, err error) {
+	var invalid *model.SchemaValidationError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "knowledgeType": invalid.KnowledgeType, "errors": invalid.Errors})
+		return
+	}
+	var outside *model.TaxonomyViolationError
+	if errors.As(err, &outside) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": outside.Field, "values": outside.Values})
+		return
+	}
+	if errors.Is(err, resolvers.ErrInvalidInput) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// curl -X GET http://localhost:8085/api/schemas
+func listKnowledgeSchemasHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	schemas, err := resolver.Query().ListKnowledgeSchemas(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schemas)
+}
+
+// curl -X GET http://localhost:8085/api/schemas/漏洞
+func knowledgeSchemaHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	schema, err := resolver.Query().KnowledgeSchema(ctx, c.Param("type"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schema)
+}
+
+// curl -X PUT http://localhost:8085/api/schemas/漏洞 -H "Content-Type: application/json" -d '{"required": ["cve"], "properties": {"cve": {"type": "string", "format": "cve"}, "title": {"maxLength": 200}}}'
+// 保存前可以先用 /api/schemas/:type/dryrun 查看已有条目中有哪些不符合
+func saveKnowledgeSchemaHandler(c *gin.Context) {
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	schema, err := resolver.Mutation().SaveKnowledgeSchema(ctx, c.Param("type"), data)
+	if err != nil {
+		schemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schema)
+}
+
+func deleteKnowledgeSchemaHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	deletionStatus, err := resolver.Mutation().DeleteKnowledgeSchema(ctx, c.Param("type"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deletionStatus)
+}
+
+// curl -X POST "http://localhost:8085/api/schemas/处置预案/dryrun?nums=20" -H "Content-Type: application/json" -d '{"required": ["containment"]}'
+// 不提交 schema 时使用已保存的 schema
+func dryRunKnowledgeSchemaHandler(c *gin.Context) {
+	nums, err := strconv.Atoi(c.DefaultQuery("nums", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid nums parameter"})
+		return
+	}
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	report, err := resolver.Query().DryRunKnowledgeSchema(ctx, c.Param("type"), data, nums)
+	if err != nil {
+		schemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"mongdbs/model"
+	"mongdbs/resolvers"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const taxiiClientKey = "taxiiClient"
+
+// taxiiJSON 以 TAXII 媒体类型返回
+func taxiiJSON(c *gin.Context, status int, obj interface{}) {
+	c.Header("Content-Type", model.TAXIIMediaType)
+	c.JSON(status, obj)
+}
+
+// taxiiError 按 TAXII 2.1 的错误消息格式返回
+func taxiiError(c *gin.Context, status int, title string, err error) {
+	body := gin.H{"title": title, "http_status": strconv.Itoa(status)}
+	if err != nil {
+		body["description"] = err.Error()
+	}
+	taxiiJSON(c, status, body)
+	c.Abort()
+}
+
+// taxiiAuth 通过 Basic 认证识别客户端，用户名为客户端 id，密码为创建客户端时返回的 key
+func taxiiAuth(c *gin.Context) {
+	id, key, ok := c.Request.BasicAuth()
+	if !ok {
+		c.Header("WWW-Authenticate", `Basic realm="taxii"`)
+		taxiiError(c, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	client, err := resolver.Query().AuthenticateTAXIIClient(ctx, id, key)
+	if err != nil {
+		taxiiError(c, http.StatusInternalServerError, "Internal error", err)
+		return
+	}
+	if client == nil {
+		c.Header("WWW-Authenticate", `Basic realm="taxii"`)
+		taxiiError(c, http.StatusUnauthorized, "Invalid credentials", nil)
+		return
+	}
+
+	c.Set(taxiiClientKey, client)
+	c.Next()
+}
+
+func taxiiClient(c *gin.Context) *model.TAXIIClient {
+	return c.MustGet(taxiiClientKey).(*model.TAXIIClient)
+}
+
+func taxiiResolverError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, resolvers.ErrCollectionNotFound):
+		taxiiError(c, http.StatusNotFound, "Collection not found", err)
+	case errors.Is(err, resolvers.ErrCollectionForbidden):
+		taxiiError(c, http.StatusForbidden, "Access denied", err)
+	case errors.Is(err, resolvers.ErrInvalidNext):
+		taxiiError(c, http.StatusBadRequest, "Invalid parameter", err)
+	default:
+		taxiiError(c, http.StatusInternalServerError, "Internal error", err)
+	}
+}
+
+func taxiiBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// curl -u "<client id>:<key>" http://localhost:8085/taxii2/
+func taxiiDiscoveryHandler(c *gin.Context) {
+	apiRoot := taxiiBaseURL(c) + "/taxii2/api/"
+	taxiiJSON(c, http.StatusOK, model.TAXIIDiscovery{
+		Title:       "Knowledge TAXII Server",
+		Description: "Curated threat intelligence from the knowledge base",
+		Default:     apiRoot,
+		APIRoots:    []string{apiRoot},
+	})
+}
+
+func taxiiAPIRootHandler(c *gin.Context) {
+	taxiiJSON(c, http.StatusOK, model.TAXIIAPIRoot{
+		Title:            "Knowledge",
+		Versions:         []string{model.TAXIIMediaType},
+		MaxContentLength: 10 << 20,
+	})
+}
+
+// curl -u "<client id>:<key>" http://localhost:8085/taxii2/api/collections/
+func taxiiCollectionsHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	collections, err := resolver.Query().TAXIICollections(ctx, taxiiClient(c))
+	if err != nil {
+		taxiiResolverError(c, err)
+		return
+	}
+
+	taxiiJSON(c, http.StatusOK, collections)
+}
+
+func taxiiCollectionHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	collection, err := resolver.Query().TAXIICollection(ctx, taxiiClient(c), c.Param("id"))
+	if err != nil {
+		taxiiResolverError(c, err)
+		return
+	}
+
+	taxiiJSON(c, http.StatusOK, collection)
+}
+
+// curl -u "<client id>:<key>" "http://localhost:8085/taxii2/api/collections/<id>/objects/?added_after=2024-01-01T00:00:00Z&limit=100&match[type]=indicator"
+func taxiiObjectsHandler(c *gin.Context) {
+	var addedAfter *time.Time
+	if v := c.Query("added_after"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			taxiiError(c, http.StatusBadRequest, "Invalid parameter", errors.New("invalid added_after parameter"))
+			return
+		}
+		addedAfter = &t
+	}
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			taxiiError(c, http.StatusBadRequest, "Invalid parameter", errors.New("invalid limit parameter"))
+			return
+		}
+		limit = n
+	}
+	types := c.QueryArray("match[type]")
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	envelope, err := resolver.Query().TAXIIObjects(ctx, taxiiClient(c), c.Param("id"), addedAfter, limit, c.Query("next"), types)
+	if err != nil {
+		taxiiResolverError(c, err)
+		return
+	}
+
+	if !envelope.DateAddedFirst.IsZero() {
+		c.Header("X-TAXII-Date-Added-First", envelope.DateAddedFirst.UTC().Format(time.RFC3339Nano))
+		c.Header("X-TAXII-Date-Added-Last", envelope.DateAddedLast.UTC().Format(time.RFC3339Nano))
+	}
+	taxiiJSON(c, http.StatusOK, envelope)
+}
+
+// curl -X POST http://localhost:8085/api/taxii/clients -H "Content-Type: application/json" -d '{"name": "siem", "clearance": "TLP:AMBER"}'
+func createTAXIIClientHandler(c *gin.Context) {
+	var input model.NewTAXIIClient
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	client, err := resolver.Mutation().CreateTAXIIClient(ctx, input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, client)
+}
+
+func listTAXIIClientsHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	clients, err := resolver.Query().ListTAXIIClients(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, clients)
+}
+
+func deleteTAXIIClientHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	deletionStatus, err := resolver.Mutation().DeleteTAXIIClient(ctx, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deletionStatus)
+}
+
+// curl -X POST http://localhost:8085/api/taxii/collections -H "Content-Type: application/json" -d '{"title": "威胁组织", "knowledgeType": ["威胁组织"], "tlp": "TLP:GREEN"}'
+func createTAXIICollectionHandler(c *gin.Context) {
+	var input model.NewTAXIICollection
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	collection, err := resolver.Mutation().CreateTAXIICollection(ctx, input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+func listTAXIICollectionsHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	collections, err := resolver.Query().ListTAXIICollectionDefs(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, collections)
+}
+
+func deleteTAXIICollectionHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	deletionStatus, err := resolver.Mutation().DeleteTAXIICollection(ctx, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deletionStatus)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"mongdbs/model"
+	"mongdbs/resolvers"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// curl -X GET "http://localhost:8085/api/taxonomy?field=knowledgeType"
+func taxonomyHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	taxonomy, err := resolver.Query().Taxonomy(ctx, c.Query("field"))
+	if err != nil {
+		schemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, taxonomy)
+}
+
+// curl -X PUT http://localhost:8085/api/taxonomy/settings -H "Content-Type: application/json" -d '{"field": "knowledgeType", "strict": true}'
+// 开启严格模式前建议先调用 /api/taxonomy/normalize 查看词表之外的值
+func setTaxonomyStrictHandler(c *gin.Context) {
+	var input model.TaxonomySetting
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	setting, err := resolver.Mutation().SetTaxonomyStrict(ctx, input.Field, input.Strict)
+	if err != nil {
+		schemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, setting)
+}
+
+// curl -X POST "http://localhost:8085/api/taxonomy/normalize?field=tags"
+func normalizeTaxonomyHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	status, err := resolver.Mutation().NormalizeTaxonomy(ctx, c.Query("field"))
+	if err != nil {
+		schemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// curl -X POST http://localhost:8085/api/taxonomy/terms -H "Content-Type: application/json" -d '{"field": "knowledgeType", "name": "漏洞", "nameEn": "Vulnerability", "synonyms": ["Vuln"]}'
+func createTaxonomyTermHandler(c *gin.Context) {
+	var input model.NewTaxonomyTerm
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	term, err := resolver.Mutation().CreateTaxonomyTerm(ctx, input)
+	if err != nil {
+		schemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, term)
+}
+
+func updateTaxonomyTermHandler(c *gin.Context) {
+	var input model.UpdateTaxonomyTerm
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	term, err := resolver.Mutation().UpdateTaxonomyTerm(ctx, c.Param("id"), input)
+	if err != nil {
+		schemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, term)
+}
+
+func deleteTaxonomyTermHandler(c *gin.Context) {
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	deletionStatus, err := resolver.Mutation().DeleteTaxonomyTerm(ctx, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deletionStatus)
+}
+
+// curl -X POST http://localhost:8085/api/taxonomy/terms/<id>/rename -H "Content-Type: application/json" -d '{"name": "安全漏洞", "atomic": true}'
+// atomic 为 true 时词条、schema 和知识条目的修改在同一事务中完成，需要 MongoDB 以副本集方式运行
+func renameTaxonomyTermHandler(c *gin.Context) {
+	var req struct {
+		Name   string `json:"name"`
+		Atomic bool   `json:"atomic"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	status, err := resolver.Mutation().RenameTaxonomyTerm(ctx, c.Param("id"), req.Name, req.Atomic)
+	if err != nil {
+		schemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// curl -X POST http://localhost:8085/api/taxonomy/terms/<id>/merge -H "Content-Type: application/json" -d '{"into": "<target id>", "atomic": true}'
+func mergeTaxonomyTermHandler(c *gin.Context) {
+	var req struct {
+		Into   string `json:"into"`
+		Atomic bool   `json:"atomic"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	status, err := resolver.Mutation().MergeTaxonomyTerm(ctx, c.Param("id"), req.Into, req.Atomic)
+	if err != nil {
+		schemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"mongdbs/resolvers"
+	"mongdbs/util"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseDateRange 解析 from、to 参数，支持 "2019"、"2019-Q3"、"2019年7月" 等部分日期；
+// from 取范围的开始，to 取范围的最后一天。in=2023 相当于 from=2023&to=2023
+func parseDateRange(c *gin.Context) (*time.Time, *time.Time, error) {
+	fromStr, toStr := c.Query("from"), c.Query("to")
+	if in := c.Query("in"); in != "" {
+		fromStr, toStr = in, in
+	}
+
+	var from, to *time.Time
+	if fromStr != "" {
+		d, ok := util.ParseDate(fromStr)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid date %q", fromStr)
+		}
+		from = &d.Start
+	}
+	if toStr != "" {
+		d, ok := util.ParseDate(toStr)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid date %q", toStr)
+		}
+		to = &d.End
+	}
+	return from, to, nil
+}
+
+// curl -X GET "http://localhost:8085/api/knowledge/active?in=2023&type=威胁组织"
+// curl -X GET "http://localhost:8085/api/knowledge/active?from=2019-Q3&to=2020年底"
+func searchByActivityHandler(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if from == nil && to == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "in, from or to must be provided"})
+		return
+	}
+	nums, err := strconv.Atoi(c.DefaultQuery("nums", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid nums parameter"})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	results, err := resolver.Query().SearchByActivity(ctx, from, to, c.QueryArray("type"), nums)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// curl -X GET "http://localhost:8085/api/knowledge/timeline?id=5&id=6"
+// curl -X GET "http://localhost:8085/api/knowledge/timeline?type=威胁组织&from=2020&revisions=true"
+func knowledgeTimelineHandler(c *gin.Context) {
+	ids, typeArgs := c.QueryArray("id"), c.QueryArray("type")
+	if len(ids) == 0 && len(typeArgs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id or type must be provided"})
+		return
+	}
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	revisions := c.Query("revisions") == "true"
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	timeline, err := resolver.Query().KnowledgeTimeline(ctx, ids, typeArgs, from, to, revisions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, timeline)
+}
@@ -0,0 +1,109 @@
+package util
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// CPE CPE 2.3 格式化字符串中的各个属性
+type CPE struct {
+	Part      string
+	Vendor    string
+	Product   string
+	Version   string
+	Update    string
+	Edition   string
+	Language  string
+	SwEdition string
+	TargetSw  string
+	TargetHw  string
+	Other     string
+}
+
+// splitCPE 按未转义的冒号拆分
+func splitCPE(s string) []string {
+	var parts []string
+	var b strings.Builder
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ':':
+			parts = append(parts, b.String())
+			b.Reset()
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return append(parts, b.String())
+}
+
+// ParseCPE 解析 cpe:2.3:part:vendor:product:version:... 格式的字符串，缺省的属性视为 "*"。
+// 同时兼容 CPE 2.2 的 cpe:/a:vendor:product:version 写法
+func ParseCPE(s string) (*CPE, error) {
+	s = strings.TrimSpace(s)
+	var parts []string
+	switch lower := strings.ToLower(s); {
+	case strings.HasPrefix(lower, "cpe:2.3:"):
+		parts = splitCPE(s)[2:]
+	case strings.HasPrefix(lower, "cpe:/"):
+		for _, p := range strings.Split(s[len("cpe:/"):], ":") {
+			if decoded, err := url.PathUnescape(p); err == nil {
+				p = decoded
+			}
+			parts = append(parts, p)
+		}
+	default:
+		return nil, fmt.Errorf("invalid cpe 2.3 string: %q", s)
+	}
+
+	for len(parts) < 11 {
+		parts = append(parts, "*")
+	}
+	if len(parts) > 11 {
+		return nil, fmt.Errorf("invalid cpe 2.3 string: %q", s)
+	}
+	for i, p := range parts {
+		if p == "" {
+			parts[i] = "*"
+		}
+	}
+
+	cpe := &CPE{
+		Part:      strings.ToLower(parts[0]),
+		Vendor:    strings.ToLower(parts[1]),
+		Product:   strings.ToLower(parts[2]),
+		Version:   parts[3],
+		Update:    parts[4],
+		Edition:   parts[5],
+		Language:  parts[6],
+		SwEdition: parts[7],
+		TargetSw:  parts[8],
+		TargetHw:  parts[9],
+		Other:     parts[10],
+	}
+	switch cpe.Part {
+	case "a", "o", "h", "*":
+	default:
+		return nil, fmt.Errorf("invalid cpe part %q", cpe.Part)
+	}
+	return cpe, nil
+}
+
+// String 返回 CPE 2.3 格式化字符串
+func (c *CPE) String() string {
+	escape := func(v string) string {
+		if v == "*" || v == "-" {
+			return v
+		}
+		return strings.ReplaceAll(v, ":", "\\:")
+	}
+	return strings.Join([]string{"cpe", "2.3", c.Part, escape(c.Vendor), escape(c.Product), escape(c.Version),
+		escape(c.Update), escape(c.Edition), escape(c.Language), escape(c.SwEdition), escape(c.TargetSw),
+		escape(c.TargetHw), escape(c.Other)}, ":")
+}
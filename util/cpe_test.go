@@ -0,0 +1,59 @@
+package util
+
+import "testing"
+
+func TestParseCPE(t *testing.T) {
+	tests := []struct {
+		in   string
+		want CPE
+	}{
+		{"cpe:2.3:a:apache:log4j:2.14.1:*:*:*:*:*:*:*",
+			CPE{"a", "apache", "log4j", "2.14.1", "*", "*", "*", "*", "*", "*", "*"}},
+		{"cpe:2.3:o:Microsoft:Windows_10:1809",
+			CPE{"o", "microsoft", "windows_10", "1809", "*", "*", "*", "*", "*", "*", "*"}},
+		{"cpe:2.3:a:vendor:prod\\:uct:1.0:-:*:*:*:*:*:*",
+			CPE{"a", "vendor", "prod:uct", "1.0", "-", "*", "*", "*", "*", "*", "*"}},
+		{"cpe:/a:openssl:openssl:1.1.1k",
+			CPE{"a", "openssl", "openssl", "1.1.1k", "*", "*", "*", "*", "*", "*", "*"}},
+		{"cpe:/a:acme:web%20server:2.0",
+			CPE{"a", "acme", "web server", "2.0", "*", "*", "*", "*", "*", "*", "*"}},
+		{"cpe:2.3:a:nginx:nginx::*:*:*:*:*:*:*",
+			CPE{"a", "nginx", "nginx", "*", "*", "*", "*", "*", "*", "*", "*"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseCPE(tt.in)
+		if err != nil {
+			t.Errorf("ParseCPE(%q) error: %v", tt.in, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("ParseCPE(%q) = %+v, want %+v", tt.in, *got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "apache:log4j", "cpe:2.3:x:a:b:1", "cpe:2.3:a:b:c:1:*:*:*:*:*:*:*:extra"} {
+		if got, err := ParseCPE(in); err == nil {
+			t.Errorf("ParseCPE(%q) = %+v, want error", in, got)
+		}
+	}
+}
+
+func TestCPEString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"cpe:2.3:a:apache:log4j:2.14.1:*:*:*:*:*:*:*", "cpe:2.3:a:apache:log4j:2.14.1:*:*:*:*:*:*:*"},
+		{"cpe:/a:openssl:openssl:1.1.1k", "cpe:2.3:a:openssl:openssl:1.1.1k:*:*:*:*:*:*:*"},
+		{"cpe:2.3:a:vendor:prod\\:uct:1.0:-", "cpe:2.3:a:vendor:prod\\:uct:1.0:-:*:*:*:*:*:*"},
+	}
+	for _, tt := range tests {
+		c, err := ParseCPE(tt.in)
+		if err != nil {
+			t.Fatalf("ParseCPE(%q) error: %v", tt.in, err)
+		}
+		if got := c.String(); got != tt.want {
+			t.Errorf("ParseCPE(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package util
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CVSS 版本
+const (
+	CVSSv2  = "2.0"
+	CVSSv30 = "3.0"
+	CVSSv31 = "3.1"
+	CVSSv40 = "4.0"
+)
+
+// CVSSVector 解析后的 CVSS 向量
+type CVSSVector struct {
+	Version string
+	Metrics map[string]string
+	order   []string
+}
+
+type metricDef struct {
+	name     string
+	values   map[string]float64
+	required bool
+}
+
+var cvss2Metrics = []metricDef{
+	{"AV", map[string]float64{"L": 0.395, "A": 0.646, "N": 1.0}, true},
+	{"AC", map[string]float64{"H": 0.35, "M": 0.61, "L": 0.71}, true},
+	{"Au", map[string]float64{"M": 0.45, "S": 0.56, "N": 0.704}, true},
+	{"C", map[string]float64{"N": 0, "P": 0.275, "C": 0.660}, true},
+	{"I", map[string]float64{"N": 0, "P": 0.275, "C": 0.660}, true},
+	{"A", map[string]float64{"N": 0, "P": 0.275, "C": 0.660}, true},
+	{"E", map[string]float64{"U": 0, "POC": 0, "F": 0, "H": 0, "ND": 0}, false},
+	{"RL", map[string]float64{"OF": 0, "TF": 0, "W": 0, "U": 0, "ND": 0}, false},
+	{"RC", map[string]float64{"UC": 0, "UR": 0, "C": 0, "ND": 0}, false},
+	{"CDP", map[string]float64{"N": 0, "L": 0, "LM": 0, "MH": 0, "H": 0, "ND": 0}, false},
+	{"TD", map[string]float64{"N": 0, "L": 0, "M": 0, "H": 0, "ND": 0}, false},
+	{"CR", map[string]float64{"L": 0, "M": 0, "H": 0, "ND": 0}, false},
+	{"IR", map[string]float64{"L": 0, "M": 0, "H": 0, "ND": 0}, false},
+	{"AR", map[string]float64{"L": 0, "M": 0, "H": 0, "ND": 0}, false},
+}
+
+// PR 在 Scope:Changed 时取值不同，在计算时单独处理
+var cvss3Metrics = []metricDef{
+	{"AV", map[string]float64{"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2}, true},
+	{"AC", map[string]float64{"L": 0.77, "H": 0.44}, true},
+	{"PR", map[string]float64{"N": 0.85, "L": 0.62, "H": 0.27}, true},
+	{"UI", map[string]float64{"N": 0.85, "R": 0.62}, true},
+	{"S", map[string]float64{"U": 0, "C": 0}, true},
+	{"C", map[string]float64{"H": 0.56, "L": 0.22, "N": 0}, true},
+	{"I", map[string]float64{"H": 0.56, "L": 0.22, "N": 0}, true},
+	{"A", map[string]float64{"H": 0.56, "L": 0.22, "N": 0}, true},
+	{"E", map[string]float64{"X": 0, "U": 0, "P": 0, "F": 0, "H": 0}, false},
+	{"RL", map[string]float64{"X": 0, "O": 0, "T": 0, "W": 0, "U": 0}, false},
+	{"RC", map[string]float64{"X": 0, "U": 0, "R": 0, "C": 0}, false},
+	{"CR", map[string]float64{"X": 0, "L": 0, "M": 0, "H": 0}, false},
+	{"IR", map[string]float64{"X": 0, "L": 0, "M": 0, "H": 0}, false},
+	{"AR", map[string]float64{"X": 0, "L": 0, "M": 0, "H": 0}, false},
+	{"MAV", map[string]float64{"X": 0, "N": 0, "A": 0, "L": 0, "P": 0}, false},
+	{"MAC", map[string]float64{"X": 0, "L": 0, "H": 0}, false},
+	{"MPR", map[string]float64{"X": 0, "N": 0, "L": 0, "H": 0}, false},
+	{"MUI", map[string]float64{"X": 0, "N": 0, "R": 0}, false},
+	{"MS", map[string]float64{"X": 0, "U": 0, "C": 0}, false},
+	{"MC", map[string]float64{"X": 0, "H": 0, "L": 0, "N": 0}, false},
+	{"MI", map[string]float64{"X": 0, "H": 0, "L": 0, "N": 0}, false},
+	{"MA", map[string]float64{"X": 0, "H": 0, "L": 0, "N": 0}, false},
+}
+
+func valuesOf(values ...string) map[string]float64 {
+	m := make(map[string]float64, len(values))
+	for _, v := range values {
+		m[v] = 0
+	}
+	return m
+}
+
+// CVSS 4.0 的分值由 MacroVector 评分表计算，这里只校验取值
+var cvss4Metrics = []metricDef{
+	{"AV", valuesOf("N", "A", "L", "P"), true},
+	{"AC", valuesOf("L", "H"), true},
+	{"AT", valuesOf("N", "P"), true},
+	{"PR", valuesOf("N", "L", "H"), true},
+	{"UI", valuesOf("N", "P", "A"), true},
+	{"VC", valuesOf("H", "L", "N"), true},
+	{"VI", valuesOf("H", "L", "N"), true},
+	{"VA", valuesOf("H", "L", "N"), true},
+	{"SC", valuesOf("H", "L", "N"), true},
+	{"SI", valuesOf("H", "L", "N"), true},
+	{"SA", valuesOf("H", "L", "N"), true},
+	{"E", valuesOf("X", "A", "P", "U"), false},
+	{"CR", valuesOf("X", "H", "M", "L"), false},
+	{"IR", valuesOf("X", "H", "M", "L"), false},
+	{"AR", valuesOf("X", "H", "M", "L"), false},
+	{"MAV", valuesOf("X", "N", "A", "L", "P"), false},
+	{"MAC", valuesOf("X", "L", "H"), false},
+	{"MAT", valuesOf("X", "N", "P"), false},
+	{"MPR", valuesOf("X", "N", "L", "H"), false},
+	{"MUI", valuesOf("X", "N", "P", "A"), false},
+	{"MVC", valuesOf("X", "H", "L", "N"), false},
+	{"MVI", valuesOf("X", "H", "L", "N"), false},
+	{"MVA", valuesOf("X", "H", "L", "N"), false},
+	{"MSC", valuesOf("X", "H", "L", "N"), false},
+	{"MSI", valuesOf("X", "S", "H", "L", "N"), false},
+	{"MSA", valuesOf("X", "S", "H", "L", "N"), false},
+	{"S", valuesOf("X", "N", "P"), false},
+	{"AU", valuesOf("X", "N", "Y"), false},
+	{"R", valuesOf("X", "A", "U", "I"), false},
+	{"V", valuesOf("X", "D", "C"), false},
+	{"RE", valuesOf("X", "L", "M", "H"), false},
+	{"U", valuesOf("X", "Clear", "Green", "Amber", "Red"), false},
+}
+
+// LooksLikeCVSSVector 粗略判断字符串是否是 CVSS 向量
+func LooksLikeCVSSVector(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(strings.ToUpper(s), "CVSS:") || strings.Contains(s, "AV:")
+}
+
+// ParseCVSS 解析 CVSS v2、v3.0/3.1、v4.0 向量字符串
+func ParseCVSS(vector string) (*CVSSVector, error) {
+	vector = strings.TrimSpace(CleanString(vector))
+	vector = strings.TrimSuffix(strings.TrimPrefix(vector, "("), ")")
+	if vector == "" {
+		return nil, fmt.Errorf("empty cvss vector")
+	}
+
+	version := CVSSv2
+	defs := cvss2Metrics
+	parts := strings.Split(vector, "/")
+	if strings.HasPrefix(strings.ToUpper(parts[0]), "CVSS:") {
+		switch strings.TrimSpace(parts[0][len("CVSS:"):]) {
+		case "2.0":
+		case "3.0":
+			version, defs = CVSSv30, cvss3Metrics
+		case "3.1":
+			version, defs = CVSSv31, cvss3Metrics
+		case "4.0":
+			version, defs = CVSSv40, cvss4Metrics
+		default:
+			return nil, fmt.Errorf("unsupported cvss version: %s", parts[0])
+		}
+		parts = parts[1:]
+	}
+
+	known := make(map[string]metricDef, len(defs))
+	for _, def := range defs {
+		known[def.name] = def
+	}
+
+	v := &CVSSVector{Version: version, Metrics: make(map[string]string)}
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid cvss metric %q", part)
+		}
+		def, ok := known[kv[0]]
+		if !ok {
+			return nil, fmt.Errorf("unknown cvss %s metric %q", version, kv[0])
+		}
+		if _, ok := def.values[kv[1]]; !ok {
+			return nil, fmt.Errorf("invalid value %q for cvss %s metric %s", kv[1], version, kv[0])
+		}
+		if _, dup := v.Metrics[kv[0]]; dup {
+			return nil, fmt.Errorf("duplicate cvss metric %s", kv[0])
+		}
+		v.Metrics[kv[0]] = kv[1]
+		v.order = append(v.order, kv[0])
+	}
+
+	for _, def := range defs {
+		if _, ok := v.Metrics[def.name]; def.required && !ok {
+			return nil, fmt.Errorf("missing cvss %s base metric %s", version, def.name)
+		}
+	}
+
+	return v, nil
+}
+
+// String 返回规范化后的向量字符串
+func (v *CVSSVector) String() string {
+	parts := make([]string, 0, len(v.order)+1)
+	if v.Version != CVSSv2 {
+		parts = append(parts, "CVSS:"+v.Version)
+	}
+	for _, name := range v.order {
+		parts = append(parts, name+":"+v.Metrics[name])
+	}
+	return strings.Join(parts, "/")
+}
+
+// Score 计算基础分，v4.0 包括向量中填写的威胁和环境指标
+func (v *CVSSVector) Score() (score float64, ok bool) {
+	switch v.Version {
+	case CVSSv2:
+		return v.score2(), true
+	case CVSSv30, CVSSv31:
+		return v.score3(), true
+	case CVSSv40:
+		return v.score4(), true
+	}
+	return 0, false
+}
+
+func (v *CVSSVector) weight(defs []metricDef, name string) float64 {
+	for _, def := range defs {
+		if def.name == name {
+			return def.values[v.Metrics[name]]
+		}
+	}
+	return 0
+}
+
+func (v *CVSSVector) score2() float64 {
+	w := func(name string) float64 { return v.weight(cvss2Metrics, name) }
+
+	impact := 10.41 * (1 - (1-w("C"))*(1-w("I"))*(1-w("A")))
+	exploitability := 20 * w("AV") * w("AC") * w("Au")
+	f := 1.176
+	if impact == 0 {
+		f = 0
+	}
+	return math.Round(((0.6*impact)+(0.4*exploitability)-1.5)*f*10) / 10
+}
+
+func (v *CVSSVector) score3() float64 {
+	w := func(name string) float64 { return v.weight(cvss3Metrics, name) }
+	changed := v.Metrics["S"] == "C"
+
+	pr := w("PR")
+	if changed {
+		switch v.Metrics["PR"] {
+		case "L":
+			pr = 0.68
+		case "H":
+			pr = 0.5
+		}
+	}
+
+	iss := 1 - (1-w("C"))*(1-w("I"))*(1-w("A"))
+	var impact float64
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	} else {
+		impact = 6.42 * iss
+	}
+	exploitability := 8.22 * w("AV") * w("AC") * pr * w("UI")
+
+	if impact <= 0 {
+		return 0
+	}
+	if changed {
+		return v.roundUp(math.Min(1.08*(impact+exploitability), 10))
+	}
+	return v.roundUp(math.Min(impact+exploitability, 10))
+}
+
+// roundUp 按规范向上取一位小数，3.1 版本修正了浮点误差带来的问题
+func (v *CVSSVector) roundUp(x float64) float64 {
+	if v.Version == CVSSv30 {
+		return math.Ceil(x*10) / 10
+	}
+	i := int64(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return (math.Floor(float64(i)/10000) + 1) / 10
+}
+
+// MacroVector 返回 CVSS 4.0 的 EQ1-EQ6 等价类，例如 "001100"
+func (v *CVSSVector) MacroVector() string {
+	if v.Version != CVSSv40 {
+		return ""
+	}
+	m := v.metric4
+
+	var eq1, eq2, eq3, eq4, eq5, eq6 int
+	switch {
+	case m("AV") == "N" && m("PR") == "N" && m("UI") == "N":
+		eq1 = 0
+	case (m("AV") == "N" || m("PR") == "N" || m("UI") == "N") && m("AV") != "P":
+		eq1 = 1
+	default:
+		eq1 = 2
+	}
+
+	if m("AC") == "L" && m("AT") == "N" {
+		eq2 = 0
+	} else {
+		eq2 = 1
+	}
+
+	switch {
+	case m("VC") == "H" && m("VI") == "H":
+		eq3 = 0
+	case m("VC") == "H" || m("VI") == "H" || m("VA") == "H":
+		eq3 = 1
+	default:
+		eq3 = 2
+	}
+
+	switch {
+	case m("MSI") == "S" || m("MSA") == "S":
+		eq4 = 0
+	case m("SC") == "H" || m("SI") == "H" || m("SA") == "H":
+		eq4 = 1
+	default:
+		eq4 = 2
+	}
+
+	switch m("E") {
+	case "A", "X":
+		eq5 = 0
+	case "P":
+		eq5 = 1
+	default:
+		eq5 = 2
+	}
+
+	if (m("CR") == "H" && m("VC") == "H") || (m("IR") == "H" && m("VI") == "H") || (m("AR") == "H" && m("VA") == "H") {
+		eq6 = 0
+	} else {
+		eq6 = 1
+	}
+
+	return fmt.Sprintf("%d%d%d%d%d%d", eq1, eq2, eq3, eq4, eq5, eq6)
+}
+
+// CVSSSeverity 根据版本返回定性等级，v2 没有 None/Critical
+func CVSSSeverity(version string, score float64) string {
+	if version == CVSSv2 {
+		switch {
+		case score >= 7.0:
+			return "High"
+		case score >= 4.0:
+			return "Medium"
+		default:
+			return "Low"
+		}
+	}
+	switch {
+	case score >= 9.0:
+		return "Critical"
+	case score >= 7.0:
+		return "High"
+	case score >= 4.0:
+		return "Medium"
+	case score > 0:
+		return "Low"
+	default:
+		return "None"
+	}
+}
+
+var scorePattern = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// ParseCVSSScore 从录入的 cvss 字段(如 "9.8"、"9.8 CRITICAL")中取出分值
+func ParseCVSSScore(s string) (float64, bool) {
+	match := scorePattern.FindString(s)
+	if match == "" {
+		return 0, false
+	}
+	score, err := strconv.ParseFloat(match, 64)
+	if err != nil || score < 0 || score > 10 {
+		return 0, false
+	}
+	return score, true
+}
@@ -0,0 +1,72 @@
+package util
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	cwePattern    = regexp.MustCompile(`(?i)CWE[-_ ]?(\d+)`)
+	numberPattern = regexp.MustCompile(`^\d+$`)
+	tagPattern    = regexp.MustCompile(`<[^>]+>`)
+)
+
+// cweID 去掉编号的前导零，编号为 0 时不是有效的 CWE，返回空字符串
+func cweID(number string) string {
+	number = strings.TrimLeft(number, "0")
+	if number == "" {
+		return ""
+	}
+	return "CWE-" + number
+}
+
+// NormalizeCWE 将 "cwe-79"、"CWE79"、"79" 统一为 "CWE-79"，无法识别时返回空字符串
+func NormalizeCWE(s string) string {
+	s = strings.TrimSpace(s)
+	if numberPattern.MatchString(s) {
+		return cweID(s)
+	}
+	if m := cwePattern.FindStringSubmatch(s); m != nil {
+		return cweID(m[1])
+	}
+	return ""
+}
+
+// ExtractCWEIDs 从自由文本(如知识条目的 cwe 字段)中提取全部 CWE 编号
+func ExtractCWEIDs(text string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, m := range cwePattern.FindAllStringSubmatch(text, -1) {
+		add(cweID(m[1]))
+	}
+	if len(ids) == 0 {
+		for _, field := range SplitList(text) {
+			if numberPattern.MatchString(field) {
+				add(NormalizeCWE(field))
+			}
+		}
+	}
+	return ids
+}
+
+// NormalizeCAPEC 将 "63"、"capec-63" 统一为 "CAPEC-63"
+func NormalizeCAPEC(s string) string {
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "CAPEC-")
+	if !numberPattern.MatchString(s) {
+		return ""
+	}
+	return "CAPEC-" + s
+}
+
+// StripMarkup 去除目录文件描述中的 XHTML 标签并压缩空白
+func StripMarkup(s string) string {
+	s = html.UnescapeString(tagPattern.ReplaceAllString(s, " "))
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,61 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeCWE(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"CWE-79", "CWE-79"},
+		{"cwe-79", "CWE-79"},
+		{"CWE79", "CWE-79"},
+		{"cwe_79", "CWE-79"},
+		{"79", "CWE-79"},
+		{" 079 ", "CWE-79"},
+		{"NVD-CWE-Other", ""},
+		{"0", ""},
+		{"CWE-000", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeCWE(tt.in); got != tt.want {
+			t.Errorf("NormalizeCWE(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExtractCWEIDs(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"CWE-79, CWE-89", []string{"CWE-79", "CWE-89"}},
+		{"CWE-79 CWE-79", []string{"CWE-79"}},
+		{"79,89", []string{"CWE-79", "CWE-89"}},
+		{"CWE-0", nil},
+		{"NVD-CWE-noinfo", nil},
+	}
+	for _, tt := range tests {
+		if got := ExtractCWEIDs(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExtractCWEIDs(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeCAPEC(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"63", "CAPEC-63"},
+		{"capec-63", "CAPEC-63"},
+		{"CAPEC-x", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeCAPEC(tt.in); got != tt.want {
+			t.Errorf("NormalizeCAPEC(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
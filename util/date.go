@@ -0,0 +1,306 @@
+package util
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 日期精度
+const (
+	PrecisionDay     = "day"
+	PrecisionMonth   = "month"
+	PrecisionQuarter = "quarter"
+	PrecisionHalf    = "half"
+	PrecisionYear    = "year"
+	PrecisionPeriod  = "period" // 年初、7月下旬、late 2019 这类大致时段
+)
+
+// Date 解析出的日期范围，部分日期(如 "2019"、"2019-Q3")表示为起止两天，End 为范围内的最后一天
+type Date struct {
+	Start     time.Time
+	End       time.Time
+	Precision string
+}
+
+// dateRunes 逐字符规范化：全角和中文数字转为阿拉伯数字，全角标点转为半角，字母转小写。
+// 保持一一对应，匹配结果可以按字符数映射回原文
+var dateRunes = map[rune]rune{
+	'〇': '0', '零': '0', '一': '1', '二': '2', '三': '3', '四': '4',
+	'五': '5', '六': '6', '七': '7', '八': '8', '九': '9',
+	'－': '-', '／': '/', '．': '.', '：': ':', '，': ',', '　': ' ',
+}
+
+func normalizeDateText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if v, ok := dateRunes[r]; ok {
+			return v
+		}
+		if r >= '０' && r <= '９' {
+			return '0' + (r - '０')
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+const (
+	dateNum   = `(\d?十\d?|\d{1,2})`
+	datePart  = `(上旬|中旬|下旬|初|底|末)` // 单独的 "中" 容易与后文连读(如 "2019年中国")，只接受 "中旬"、"年中"
+	dateMonth = `(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?`
+)
+
+type datePattern struct {
+	re    *regexp.Regexp
+	parse func(m []string) (Date, bool)
+}
+
+var datePatterns = []datePattern{
+	// 2019-07-15、2019/7/15、2019.07.15、2019年7月15日
+	{regexp.MustCompile(`^(\d{4})\s*[-/.年]\s*` + dateNum + `\s*[-/.月]\s*` + dateNum + `\s*[日号]?`), func(m []string) (Date, bool) {
+		return dayDate(atoi(m[1]), dateNumber(m[2]), dateNumber(m[3]))
+	}},
+	// 20190715
+	{regexp.MustCompile(`^(\d{4})(\d{2})(\d{2})`), func(m []string) (Date, bool) {
+		return dayDate(atoi(m[1]), atoi(m[2]), atoi(m[3]))
+	}},
+	// 2019-07、2019年7月、2019年7月下旬
+	{regexp.MustCompile(`^(\d{4})\s*[-/.年]\s*` + dateNum + `(?:\s*月份?\s*` + datePart + `?)?`), func(m []string) (Date, bool) {
+		return monthDate(atoi(m[1]), dateNumber(m[2]), m[3])
+	}},
+	// 2019-Q3、2019Q3、2019年第三季度、2019年Q3
+	{regexp.MustCompile(`^(\d{4})\s*年?\s*-?\s*(?:q|第)\s*([1-4])\s*(?:季度|季)?`), func(m []string) (Date, bool) {
+		return rangeDate(atoi(m[1]), (atoi(m[2])-1)*3+1, 3, PrecisionQuarter)
+	}},
+	// Q3 2019
+	{regexp.MustCompile(`^q([1-4])\s*[-/ ]?\s*(\d{4})`), func(m []string) (Date, bool) {
+		return rangeDate(atoi(m[2]), (atoi(m[1])-1)*3+1, 3, PrecisionQuarter)
+	}},
+	// 2019-H1、2019年上半年
+	{regexp.MustCompile(`^(\d{4})\s*年?\s*-?\s*(?:h([12])|([上下])半年)`), func(m []string) (Date, bool) {
+		month := 1
+		if m[2] == "2" || m[3] == "下" {
+			month = 7
+		}
+		return rangeDate(atoi(m[1]), month, 6, PrecisionHalf)
+	}},
+	// 2019年、2019年初、2019年底
+	{regexp.MustCompile(`^(\d{4})\s*年\s*(?:年(初|中|底|末)|` + datePart + `)?`), func(m []string) (Date, bool) {
+		if m[2] != "" {
+			return yearDate(atoi(m[1]), m[2])
+		}
+		return yearDate(atoi(m[1]), m[3])
+	}},
+	// July 15, 2019
+	{regexp.MustCompile(`^` + dateMonth + `\s+(\d{1,2})(?:st|nd|rd|th)?,?\s+(\d{4})`), func(m []string) (Date, bool) {
+		return dayDate(atoi(m[3]), englishMonth(m[1]), atoi(m[2]))
+	}},
+	// 15 July 2019
+	{regexp.MustCompile(`^(\d{1,2})\s+` + dateMonth + `,?\s+(\d{4})`), func(m []string) (Date, bool) {
+		return dayDate(atoi(m[3]), englishMonth(m[2]), atoi(m[1]))
+	}},
+	// July 2019
+	{regexp.MustCompile(`^` + dateMonth + `,?\s+(\d{4})`), func(m []string) (Date, bool) {
+		return monthDate(atoi(m[2]), englishMonth(m[1]), "")
+	}},
+	// early 2019、mid-2019、late 2019
+	{regexp.MustCompile(`^(early|mid|late)[- ]?(\d{4})`), func(m []string) (Date, bool) {
+		return yearDate(atoi(m[2]), map[string]string{"early": "初", "mid": "中", "late": "底"}[m[1]])
+	}},
+	// 2019
+	{regexp.MustCompile(`^(\d{4})`), func(m []string) (Date, bool) {
+		return yearDate(atoi(m[1]), "")
+	}},
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// dateNumber 解析月、日中的数字，支持 "十"、"十5"、"2十3" 这类中文写法(已逐字转换)
+func dateNumber(s string) int {
+	tens, ones, ok := strings.Cut(s, "十")
+	if !ok {
+		return atoi(s)
+	}
+	n := 10
+	if tens != "" {
+		n = atoi(tens) * 10
+	}
+	return n + atoi(ones)
+}
+
+func englishMonth(s string) int {
+	return strings.Index("janfebmaraprmayjunjulaugsepoctnovdec", s[:3])/3 + 1
+}
+
+func validYear(year int) bool {
+	return year >= 1900 && year <= 2100
+}
+
+func dayDate(year, month, day int) (Date, bool) {
+	if !validYear(year) || month < 1 || month > 12 || day < 1 {
+		return Date{}, false
+	}
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if t.Month() != time.Month(month) {
+		return Date{}, false
+	}
+	return Date{Start: t, End: t, Precision: PrecisionDay}, true
+}
+
+// rangeDate 从 month 开始、跨 months 个月的范围
+func rangeDate(year, month, months int, precision string) (Date, bool) {
+	if !validYear(year) || month < 1 || month > 12 {
+		return Date{}, false
+	}
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	return Date{Start: start, End: start.AddDate(0, months, -1), Precision: precision}, true
+}
+
+func monthDate(year, month int, part string) (Date, bool) {
+	d, ok := rangeDate(year, month, 1, PrecisionMonth)
+	if !ok || part == "" {
+		return d, ok
+	}
+	d.Precision = PrecisionPeriod
+	switch part {
+	case "上旬", "初":
+		d.End = d.Start.AddDate(0, 0, 9)
+	case "中旬", "中":
+		d.Start, d.End = d.Start.AddDate(0, 0, 10), d.Start.AddDate(0, 0, 19)
+	default:
+		d.Start = d.Start.AddDate(0, 0, 20)
+	}
+	return d, true
+}
+
+func yearDate(year int, part string) (Date, bool) {
+	switch part {
+	case "":
+		return rangeDate(year, 1, 12, PrecisionYear)
+	case "初", "上旬":
+		return rangeDate(year, 1, 3, PrecisionPeriod)
+	case "中", "中旬":
+		return rangeDate(year, 4, 6, PrecisionPeriod)
+	default:
+		return rangeDate(year, 10, 3, PrecisionPeriod)
+	}
+}
+
+// matchDatePrefix 匹配规范化文本开头的日期，返回匹配到的字节长度；多个格式都能匹配时取最长的
+func matchDatePrefix(s string) (Date, int, bool) {
+	var best Date
+	bestLen := 0
+	for _, p := range datePatterns {
+		m := p.re.FindStringSubmatch(s)
+		if m == nil || len(m[0]) <= bestLen {
+			continue
+		}
+		// 数字不能紧接着数字，避免 "201907" 被当作年份 2019
+		if rest := s[len(m[0]):]; rest != "" && isDigit(rest[0]) {
+			continue
+		}
+		if d, ok := p.parse(m); ok {
+			best, bestLen = d, len(m[0])
+		}
+	}
+	return best, bestLen, bestLen > 0
+}
+
+// ParseDate 解析整个字符串为日期，支持 RFC3339、ISO、中文、英文以及年份、季度、半年等部分日期
+func ParseDate(s string) (Date, bool) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return Date{Start: t, End: t, Precision: PrecisionDay}, true
+	}
+	norm := normalizeDateText(s)
+	d, n, ok := matchDatePrefix(norm)
+	if !ok || strings.TrimSpace(norm[n:]) != "" {
+		return Date{}, false
+	}
+	return d, true
+}
+
+// FindDate 查找文本中第一个日期，如 "至少自2015年起活跃"
+func FindDate(s string) (Date, bool) {
+	norm := normalizeDateText(s)
+	for i := 0; i < len(norm); {
+		if i == 0 || !isDigit(norm[i-1]) {
+			if d, _, ok := matchDatePrefix(norm[i:]); ok {
+				return d, true
+			}
+		}
+		_, size := utf8.DecodeRuneInString(norm[i:])
+		i += size
+	}
+	return Date{}, false
+}
+
+// ongoingPattern 表示仍在持续的写法，如 "至今"、"2019 - present"、"ongoing"
+var ongoingPattern = regexp.MustCompile(`至今|迄今|今|现在|目前|\b(present|ongoing|now|current|currently|today)\b`)
+
+// Ongoing 文本是否表示持续到现在，用于最近活动时间这类字段
+func Ongoing(s string) bool {
+	return ongoingPattern.MatchString(normalizeDateText(s))
+}
+
+// TimelineEntry 时间线文本中的一条记录。"2021年至今 持续活动" 这类记录 Ongoing 为 true，
+// 表示从 Date 开始一直持续到现在
+type TimelineEntry struct {
+	Date        Date
+	Text        string // 原文中的日期写法
+	Description string
+	Ongoing     bool
+}
+
+var (
+	timelineBullet  = regexp.MustCompile(`^(?:[-*•·]\s*|\d{1,2}\s*[.、)）]\s+)`)
+	timelineSep     = " \t:：,，、-–—)）]】"
+	timelineOngoing = regexp.MustCompile(`^\s*(?:[-~–—至到]\s*)?(?:至今|迄今|现在|目前|(?:present|ongoing|now|today)\b)`)
+)
+
+// ParseTimeline 按行解析时间线文本，每行以日期开头；不以日期开头的行并入上一条记录的描述
+func ParseTimeline(s string) []TimelineEntry {
+	var entries []TimelineEntry
+	for _, line := range strings.FieldsFunc(s, func(r rune) bool { return r == '\n' || r == ';' || r == '；' }) {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(timelineBullet.ReplaceAllString(line, ""))
+		line = strings.TrimLeft(line, "[【(（")
+		if line == "" {
+			continue
+		}
+
+		norm := normalizeDateText(line)
+		d, n, ok := matchDatePrefix(norm)
+		if !ok {
+			if len(entries) > 0 {
+				last := &entries[len(entries)-1]
+				last.Description = strings.TrimSpace(last.Description + "；" + line)
+			}
+			continue
+		}
+
+		// 日期后紧跟 "至今"、"- present" 时一并作为日期写法
+		ongoing := false
+		if m := timelineOngoing.FindString(norm[n:]); m != "" {
+			n += len(m)
+			ongoing = true
+		}
+
+		// 规范化是逐字符的，按字符数取回原文中的日期部分
+		runes := []rune(line)
+		count := utf8.RuneCountInString(norm[:n])
+		entries = append(entries, TimelineEntry{
+			Date:        d,
+			Text:        strings.TrimSpace(string(runes[:count])),
+			Description: strings.TrimSpace(strings.TrimLeft(string(runes[count:]), timelineSep)),
+			Ongoing:     ongoing,
+		})
+	}
+	return entries
+}
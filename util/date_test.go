@@ -0,0 +1,124 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func day(year, month, d int) time.Time {
+	return time.Date(year, time.Month(month), d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in        string
+		start     time.Time
+		end       time.Time
+		precision string
+	}{
+		{"2019-07-15", day(2019, 7, 15), day(2019, 7, 15), PrecisionDay},
+		{"2019/7/15", day(2019, 7, 15), day(2019, 7, 15), PrecisionDay},
+		{"2019年7月15日", day(2019, 7, 15), day(2019, 7, 15), PrecisionDay},
+		{"二〇一九年七月十五日", day(2019, 7, 15), day(2019, 7, 15), PrecisionDay},
+		{"２０１９－０７－１５", day(2019, 7, 15), day(2019, 7, 15), PrecisionDay},
+		{"20190715", day(2019, 7, 15), day(2019, 7, 15), PrecisionDay},
+		{"2019-07-15T08:30:00Z", day(2019, 7, 15), day(2019, 7, 15), PrecisionDay},
+		{"July 15, 2019", day(2019, 7, 15), day(2019, 7, 15), PrecisionDay},
+		{"15 July 2019", day(2019, 7, 15), day(2019, 7, 15), PrecisionDay},
+		{"2019-07", day(2019, 7, 1), day(2019, 7, 31), PrecisionMonth},
+		{"July 2019", day(2019, 7, 1), day(2019, 7, 31), PrecisionMonth},
+		{"2019年7月下旬", day(2019, 7, 21), day(2019, 7, 31), PrecisionPeriod},
+		{"2019-Q3", day(2019, 7, 1), day(2019, 9, 30), PrecisionQuarter},
+		{"2019年第三季度", day(2019, 7, 1), day(2019, 9, 30), PrecisionQuarter},
+		{"Q3 2019", day(2019, 7, 1), day(2019, 9, 30), PrecisionQuarter},
+		{"2019年上半年", day(2019, 1, 1), day(2019, 6, 30), PrecisionHalf},
+		{"2019-H2", day(2019, 7, 1), day(2019, 12, 31), PrecisionHalf},
+		{"2019年初", day(2019, 1, 1), day(2019, 3, 31), PrecisionPeriod},
+		{"late 2019", day(2019, 10, 1), day(2019, 12, 31), PrecisionPeriod},
+		{"2019", day(2019, 1, 1), day(2019, 12, 31), PrecisionYear},
+		{"2019年", day(2019, 1, 1), day(2019, 12, 31), PrecisionYear},
+	}
+	for _, tt := range tests {
+		d, ok := ParseDate(tt.in)
+		if !ok {
+			t.Errorf("ParseDate(%q) failed", tt.in)
+			continue
+		}
+		if !d.Start.Equal(tt.start) || !d.End.Equal(tt.end) || d.Precision != tt.precision {
+			t.Errorf("ParseDate(%q) = %v..%v %s, want %v..%v %s", tt.in, d.Start, d.End, d.Precision, tt.start, tt.end, tt.precision)
+		}
+	}
+
+	for _, in := range []string{"", "unknown", "2019-02-30", "201907", "1800", "2019年中国"} {
+		if d, ok := ParseDate(in); ok {
+			t.Errorf("ParseDate(%q) = %v, want failure", in, d)
+		}
+	}
+}
+
+func TestFindDate(t *testing.T) {
+	tests := []struct {
+		in    string
+		start time.Time
+		ok    bool
+	}{
+		{"至少自2015年起活跃", day(2015, 1, 1), true},
+		{"first seen in July 2019 campaigns", day(2019, 7, 1), true},
+		{"build 201907", time.Time{}, false},
+		{"至今", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		d, ok := FindDate(tt.in)
+		if ok != tt.ok || !d.Start.Equal(tt.start) {
+			t.Errorf("FindDate(%q) = %v, %v; want %v, %v", tt.in, d.Start, ok, tt.start, tt.ok)
+		}
+	}
+}
+
+func TestOngoing(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"至今", true},
+		{"2019年至今", true},
+		{"2019 - present", true},
+		{"Ongoing", true},
+		{"目前仍在活跃", true},
+		{"2023-05", false},
+		{"known since 2019", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := Ongoing(tt.in); got != tt.want {
+			t.Errorf("Ongoing(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseTimeline(t *testing.T) {
+	text := "- 2019年7月 首次发现\n" +
+		"补充说明\n" +
+		"2. 2020-Q1：攻击能源行业；2021年至今 持续活动\n" +
+		"[2022-03-01] 更换 C2\n" +
+		"2018 - present: long running campaign"
+
+	want := []TimelineEntry{
+		{Date: Date{Start: day(2019, 7, 1), End: day(2019, 7, 31), Precision: PrecisionMonth}, Text: "2019年7月", Description: "首次发现；补充说明"},
+		{Date: Date{Start: day(2020, 1, 1), End: day(2020, 3, 31), Precision: PrecisionQuarter}, Text: "2020-Q1", Description: "攻击能源行业"},
+		{Date: Date{Start: day(2021, 1, 1), End: day(2021, 12, 31), Precision: PrecisionYear}, Text: "2021年至今", Description: "持续活动", Ongoing: true},
+		{Date: Date{Start: day(2022, 3, 1), End: day(2022, 3, 1), Precision: PrecisionDay}, Text: "2022-03-01", Description: "更换 C2"},
+		{Date: Date{Start: day(2018, 1, 1), End: day(2018, 12, 31), Precision: PrecisionYear}, Text: "2018 - present", Description: "long running campaign", Ongoing: true},
+	}
+	got := ParseTimeline(text)
+	if len(got) != len(want) {
+		t.Fatalf("ParseTimeline returned %d entries, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if !g.Date.Start.Equal(w.Date.Start) || !g.Date.End.Equal(w.Date.End) || g.Date.Precision != w.Date.Precision ||
+			g.Text != w.Text || g.Description != w.Description || g.Ongoing != w.Ongoing {
+			t.Errorf("entry %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
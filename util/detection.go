@@ -0,0 +1,615 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 检测规则类型
+const (
+	RuleSigma    = "sigma"
+	RuleYARA     = "yara"
+	RuleSnort    = "snort"
+	RuleSuricata = "suricata"
+	RuleKQL      = "kql"
+	RuleSPL      = "spl"
+)
+
+var ruleTypes = map[string]string{
+	"sigma": RuleSigma, "yara": RuleYARA, "yar": RuleYARA,
+	"snort": RuleSnort, "suricata": RuleSuricata,
+	"kql": RuleKQL, "kusto": RuleKQL, "spl": RuleSPL, "splunk": RuleSPL,
+}
+
+// NormalizeRuleType 规范化规则类型名称，无法识别时返回空字符串
+func NormalizeRuleType(s string) string {
+	return ruleTypes[strings.ToLower(strings.TrimSpace(s))]
+}
+
+var techniqueRe = regexp.MustCompile(`(?i)\bT\d{4}(?:\.\d{3})?\b`)
+
+// ExtractTechniqueIDs 提取文本中出现的 ATT&CK 技术编号，如 T1059、T1059.001
+func ExtractTechniqueIDs(s string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, m := range techniqueRe.FindAllString(s, -1) {
+		id := strings.ToUpper(m)
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// RuleCheck 规则校验结果，Errors 为空表示语法正确；Techniques 为规则声明的 ATT&CK 技术
+type RuleCheck struct {
+	Name       string
+	Errors     []string
+	Techniques []string
+}
+
+func (c *RuleCheck) errorf(format string, args ...interface{}) {
+	c.Errors = append(c.Errors, fmt.Sprintf(format, args...))
+}
+
+// ValidateRule 按规则类型校验语法并提取声明的 ATT&CK 技术
+func ValidateRule(ruleType, text string) *RuleCheck {
+	check := &RuleCheck{}
+	if strings.TrimSpace(text) == "" {
+		check.errorf("rule is empty")
+		return check
+	}
+
+	switch NormalizeRuleType(ruleType) {
+	case RuleSigma:
+		validateSigma(check, text)
+	case RuleYARA:
+		validateYARA(check, text)
+	case RuleSnort, RuleSuricata:
+		validateSnort(check, text)
+	case RuleKQL:
+		validateQuery(check, text, "//")
+	case RuleSPL:
+		validateQuery(check, text, "```")
+	default:
+		check.errorf("unsupported rule type %q", ruleType)
+	}
+	return check
+}
+
+// Sigma
+
+type sigmaRule struct {
+	Title     string                 `yaml:"title"`
+	ID        string                 `yaml:"id"`
+	Status    string                 `yaml:"status"`
+	Tags      []string               `yaml:"tags"`
+	Level     string                 `yaml:"level"`
+	LogSource map[string]interface{} `yaml:"logsource"`
+	Detection map[string]interface{} `yaml:"detection"`
+}
+
+var (
+	sigmaStatus = map[string]bool{"stable": true, "test": true, "experimental": true, "deprecated": true, "unsupported": true}
+	sigmaLevel  = map[string]bool{"informational": true, "low": true, "medium": true, "high": true, "critical": true}
+	sigmaWords  = map[string]bool{"and": true, "or": true, "not": true, "of": true, "them": true, "all": true}
+	sigmaIdent  = regexp.MustCompile(`^[A-Za-z0-9_*]+$`)
+	sigmaTagRe  = regexp.MustCompile(`^attack\.t\d{4}(?:\.\d{3})?$`)
+)
+
+// SigmaTechnique 将 Sigma 标签 attack.t1059.001 转换为技术编号
+func SigmaTechnique(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if !sigmaTagRe.MatchString(tag) {
+		return ""
+	}
+	return strings.ToUpper(strings.TrimPrefix(tag, "attack."))
+}
+
+func validateSigma(check *RuleCheck, text string) {
+	dec := yaml.NewDecoder(strings.NewReader(text))
+	for n := 1; ; n++ {
+		var rule sigmaRule
+		err := dec.Decode(&rule)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				if n == 1 {
+					check.errorf("rule is empty")
+				}
+				return
+			}
+			check.errorf("yaml: %v", err)
+			return
+		}
+
+		prefix := ""
+		if n > 1 {
+			prefix = fmt.Sprintf("document %d: ", n)
+		}
+		if rule.Title == "" {
+			check.errorf("%stitle is required", prefix)
+		} else if check.Name == "" {
+			check.Name = rule.Title
+		}
+		if rule.Status != "" && !sigmaStatus[rule.Status] {
+			check.errorf("%sinvalid status %q", prefix, rule.Status)
+		}
+		if rule.Level != "" && !sigmaLevel[rule.Level] {
+			check.errorf("%sinvalid level %q", prefix, rule.Level)
+		}
+		if len(rule.LogSource) == 0 {
+			check.errorf("%slogsource is required", prefix)
+		}
+		checkSigmaDetection(check, prefix, rule.Detection)
+
+		for _, tag := range rule.Tags {
+			if id := SigmaTechnique(tag); id != "" && !containsFold(check.Techniques, id) {
+				check.Techniques = append(check.Techniques, id)
+			}
+		}
+	}
+}
+
+// checkSigmaDetection 检查 condition 引用的检索标识都在 detection 中定义，支持 selection* 这样的通配
+func checkSigmaDetection(check *RuleCheck, prefix string, detection map[string]interface{}) {
+	if len(detection) == 0 {
+		check.errorf("%sdetection is required", prefix)
+		return
+	}
+
+	var conditions []string
+	switch c := detection["condition"].(type) {
+	case string:
+		conditions = []string{c}
+	case []interface{}:
+		for _, v := range c {
+			if s, ok := v.(string); ok {
+				conditions = append(conditions, s)
+			}
+		}
+	}
+	if len(conditions) == 0 {
+		check.errorf("%sdetection.condition is required", prefix)
+		return
+	}
+
+	var names []string
+	for name := range detection {
+		if name != "condition" && name != "timeframe" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, condition := range conditions {
+		if strings.Contains(condition, "|") {
+			// 聚合表达式(count() by ...)已被弃用，只检查管道前的部分
+			condition = condition[:strings.Index(condition, "|")]
+		}
+		if strings.Count(condition, "(") != strings.Count(condition, ")") {
+			check.errorf("%sunbalanced parentheses in condition %q", prefix, condition)
+		}
+		fields := strings.Fields(strings.NewReplacer("(", " ", ")", " ").Replace(condition))
+		for _, field := range fields {
+			if sigmaWords[strings.ToLower(field)] || isNumber(field) {
+				continue
+			}
+			if !sigmaIdent.MatchString(field) {
+				check.errorf("%sinvalid identifier %q in condition", prefix, field)
+				continue
+			}
+			if !sigmaReferenced(field, names) {
+				check.errorf("%scondition references undefined search %q", prefix, field)
+			}
+		}
+	}
+}
+
+func sigmaReferenced(ident string, names []string) bool {
+	pattern := "^" + strings.ReplaceAll(regexp.QuoteMeta(ident), `\*`, ".*") + "$"
+	re := regexp.MustCompile(pattern)
+	for _, name := range names {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// YARA
+
+var (
+	yaraRuleRe = regexp.MustCompile(`^(?:(?:private|global)\s+)*rule\s+([A-Za-z_][A-Za-z0-9_]*)\s*(?::\s*([A-Za-z0-9_ \t]+))?\s*\{`)
+	yaraSecRe  = regexp.MustCompile(`(?m)^\s*(meta|strings|condition)\s*:`)
+	yaraVarRe  = regexp.MustCompile(`(?m)^\s*(\$[A-Za-z0-9_]*)\s*=`)
+	yaraRefRe  = regexp.MustCompile(`[$#@!]([A-Za-z0-9_]*\*?)`)
+)
+
+// stripYARAComments 去掉注释并将字符串、正则字面量替换为空格，保留换行以便报告行号
+func stripYARAComments(text string) (string, error) {
+	var b strings.Builder
+	line := 1
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\n':
+			line++
+			b.WriteByte(c)
+		case c == '/' && i+1 < len(text) && text[i+1] == '/':
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(text) && text[i+1] == '*':
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				return "", fmt.Errorf("line %d: unterminated comment", line)
+			}
+			comment := text[i : i+2+end+2]
+			line += strings.Count(comment, "\n")
+			b.WriteString(strings.Repeat("\n", strings.Count(comment, "\n")))
+			i += len(comment) - 1
+		case c == '"':
+			j := i + 1
+			for ; j < len(text) && text[j] != '"' && text[j] != '\n'; j++ {
+				if text[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(text) || text[j] != '"' {
+				return "", fmt.Errorf("line %d: unterminated string", line)
+			}
+			b.WriteString(`""`)
+			i = j
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+func validateYARA(check *RuleCheck, text string) {
+	// 技术编号一般写在 meta 中，如 mitre_attack = "T1059.001"，在去掉字符串之前提取
+	check.Techniques = ExtractTechniqueIDs(text)
+
+	stripped, err := stripYARAComments(text)
+	if err != nil {
+		check.errorf("%v", err)
+		return
+	}
+
+	seen := make(map[string]bool)
+	rest := stripped
+	for {
+		rest = strings.TrimLeft(rest, " \t\r\n")
+		if rest == "" {
+			break
+		}
+		if strings.HasPrefix(rest, "import ") || strings.HasPrefix(rest, "include ") {
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				break
+			}
+			rest = rest[end:]
+			continue
+		}
+
+		m := yaraRuleRe.FindStringSubmatch(rest)
+		if m == nil {
+			line := rest
+			if end := strings.IndexByte(line, '\n'); end >= 0 {
+				line = line[:end]
+			}
+			check.errorf("expected rule declaration near %q", strings.TrimSpace(line))
+			return
+		}
+		name := m[1]
+		if seen[name] {
+			check.errorf("duplicate rule name %q", name)
+		}
+		seen[name] = true
+		if check.Name == "" {
+			check.Name = name
+		}
+
+		// 找到与规则开头的 { 匹配的 }
+		open := len(m[0]) - 1
+		depth, end := 0, -1
+		for i := open; i < len(rest); i++ {
+			if rest[i] == '{' {
+				depth++
+			} else if rest[i] == '}' {
+				depth--
+				if depth == 0 {
+					end = i
+					break
+				}
+			}
+		}
+		if end < 0 {
+			check.errorf("rule %s: missing closing brace", name)
+			return
+		}
+		checkYARABody(check, name, rest[open+1:end])
+		rest = rest[end+1:]
+	}
+
+	if len(seen) == 0 {
+		check.errorf("no rule declaration found")
+	}
+}
+
+// checkYARABody 检查规则体包含 condition，且 condition 中引用的字符串变量都已定义
+func checkYARABody(check *RuleCheck, name, body string) {
+	locs := yaraSecRe.FindAllStringSubmatchIndex(body, -1)
+	sections := make(map[string]string)
+	for i, loc := range locs {
+		section := body[loc[2]:loc[3]]
+		end := len(body)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		if _, ok := sections[section]; ok {
+			check.errorf("rule %s: duplicate %s section", name, section)
+		}
+		sections[section] = body[loc[1]:end]
+	}
+
+	condition, ok := sections["condition"]
+	if !ok || strings.TrimSpace(condition) == "" {
+		check.errorf("rule %s: condition section is required", name)
+		return
+	}
+	if strings.Count(condition, "(") != strings.Count(condition, ")") {
+		check.errorf("rule %s: unbalanced parentheses in condition", name)
+	}
+
+	var vars []string
+	for _, m := range yaraVarRe.FindAllStringSubmatch(sections["strings"], -1) {
+		if m[1] != "$" && containsFold(vars, m[1][1:]) {
+			check.errorf("rule %s: duplicate string %s", name, m[1])
+		}
+		vars = append(vars, m[1][1:])
+	}
+	for _, m := range yaraRefRe.FindAllStringSubmatch(condition, -1) {
+		ref := m[1]
+		if ref == "" || ref == "*" {
+			// $ 在 for..of 中指代当前字符串，"them" 和 ($*) 指代全部字符串
+			continue
+		}
+		if strings.HasSuffix(ref, "*") {
+			prefix := strings.TrimSuffix(ref, "*")
+			found := false
+			for _, v := range vars {
+				if strings.HasPrefix(v, prefix) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				check.errorf("rule %s: no strings match $%s", name, ref)
+			}
+			continue
+		}
+		if !containsFold(vars, ref) {
+			check.errorf("rule %s: undefined string $%s", name, ref)
+		}
+	}
+}
+
+// Snort / Suricata
+
+var (
+	snortActions = map[string]bool{
+		"alert": true, "log": true, "pass": true, "drop": true, "reject": true, "sdrop": true,
+		"rejectsrc": true, "rejectdst": true, "rejectboth": true,
+	}
+	snortDirections = map[string]bool{"->": true, "<>": true, "=>": true}
+	snortOptionRe   = regexp.MustCompile(`^[a-z][a-z0-9_.]*$`)
+)
+
+// splitSnortOptions 按分号切分规则选项，忽略引号内和转义的分号
+func splitSnortOptions(s string) ([]string, error) {
+	var options []string
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			b.WriteByte(c)
+			b.WriteByte(s[i+1])
+			i++
+		case c == '"':
+			quoted = !quoted
+			b.WriteByte(c)
+		case c == ';' && !quoted:
+			options = append(options, strings.TrimSpace(b.String()))
+			b.Reset()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quoted string")
+	}
+	// 最后一个选项后的分号可以省略
+	if rest := strings.TrimSpace(b.String()); rest != "" {
+		options = append(options, rest)
+	}
+	return options, nil
+}
+
+func validateSnort(check *RuleCheck, text string) {
+	sids := make(map[string]bool)
+	rules := 0
+	for n, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rules++
+		prefix := fmt.Sprintf("line %d: ", n+1)
+
+		open := strings.IndexByte(line, '(')
+		if open < 0 || !strings.HasSuffix(line, ")") {
+			check.errorf("%srule options must be enclosed in parentheses", prefix)
+			continue
+		}
+		header := strings.Fields(line[:open])
+		if len(header) != 7 && !(len(header) == 2 && snortActions[strings.ToLower(header[0])]) {
+			// Snort 3 和 Suricata 支持只写动作和协议的简化头部
+			check.errorf("%sheader must be: action proto src_ip src_port direction dst_ip dst_port", prefix)
+			continue
+		}
+		if !snortActions[strings.ToLower(header[0])] {
+			check.errorf("%sunknown action %q", prefix, header[0])
+		}
+		if len(header) == 7 && !snortDirections[header[4]] {
+			check.errorf("%sinvalid direction %q", prefix, header[4])
+		}
+
+		options, err := splitSnortOptions(line[open+1 : len(line)-1])
+		if err != nil {
+			check.errorf("%s%v", prefix, err)
+			continue
+		}
+		sid, msg := "", ""
+		for _, option := range options {
+			key, value, _ := strings.Cut(option, ":")
+			key = strings.TrimSpace(key)
+			if !snortOptionRe.MatchString(key) {
+				check.errorf("%sinvalid option %q", prefix, option)
+				continue
+			}
+			value = strings.TrimSpace(value)
+			switch key {
+			case "sid":
+				sid = value
+			case "msg":
+				msg = strings.Trim(value, `"`)
+			case "metadata", "reference":
+				for _, id := range ExtractTechniqueIDs(value) {
+					if !containsFold(check.Techniques, id) {
+						check.Techniques = append(check.Techniques, id)
+					}
+				}
+			}
+		}
+		if sid == "" || !isNumber(sid) {
+			check.errorf("%ssid option is required and must be numeric", prefix)
+		} else if sids[sid] {
+			check.errorf("%sduplicate sid %s", prefix, sid)
+		}
+		sids[sid] = true
+		if check.Name == "" {
+			check.Name = msg
+		}
+	}
+	if rules == 0 {
+		check.errorf("no rule found")
+	}
+}
+
+// KQL / SPL
+
+// validateQuery 检查查询语句的括号和引号是否配对、管道是否为空；技术编号从注释中提取
+func validateQuery(check *RuleCheck, text, comment string) {
+	var stack []byte
+	pairs := map[byte]byte{')': '(', ']': '[', '}': '{'}
+	var code strings.Builder
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case strings.HasPrefix(text[i:], comment):
+			var end int
+			if comment == "//" {
+				end = strings.IndexByte(text[i:], '\n')
+			} else {
+				end = strings.Index(text[i+len(comment):], comment)
+				if end >= 0 {
+					end += 2 * len(comment)
+				}
+			}
+			if end < 0 {
+				if comment != "//" {
+					check.errorf("unterminated comment")
+				}
+				end = len(text) - i
+			}
+			for _, id := range ExtractTechniqueIDs(text[i : i+end]) {
+				if !containsFold(check.Techniques, id) {
+					check.Techniques = append(check.Techniques, id)
+				}
+			}
+			if check.Name == "" && comment == "//" && i == 0 {
+				check.Name = strings.TrimSpace(text[len(comment):end])
+			}
+			i += end - 1
+		case c == '"' || c == '\'':
+			j := i + 1
+			for ; j < len(text) && text[j] != c; j++ {
+				if text[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(text) {
+				check.errorf("unterminated string literal")
+				return
+			}
+			code.WriteString(`""`)
+			i = j
+		case c == '(' || c == '[' || c == '{':
+			stack = append(stack, c)
+			code.WriteByte(c)
+		case c == ')' || c == ']' || c == '}':
+			if len(stack) == 0 || stack[len(stack)-1] != pairs[c] {
+				check.errorf("unexpected %q", string(c))
+				return
+			}
+			stack = stack[:len(stack)-1]
+			code.WriteByte(c)
+		default:
+			code.WriteByte(c)
+		}
+	}
+	if len(stack) > 0 {
+		check.errorf("unclosed %q", string(stack[len(stack)-1]))
+	}
+
+	stages := strings.Split(code.String(), "|")
+	for i, stage := range stages {
+		if strings.TrimSpace(stage) != "" {
+			continue
+		}
+		switch {
+		case len(stages) == 1:
+			check.errorf("query is empty")
+		case i > 0:
+			check.errorf("empty pipeline stage")
+		case comment == "//":
+			// SPL 允许以 | 开头(如 | tstats)，KQL 必须以表名或表达式开头
+			check.errorf("query must start with a table or expression")
+		default:
+			continue
+		}
+		return
+	}
+}
+
+func containsFold(list []string, value string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
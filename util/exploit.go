@@ -0,0 +1,74 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	cvePattern       = regexp.MustCompile(`(?i)CVE-\d{4}-\d{4,}`)
+	msfModulePattern = regexp.MustCompile(`\b(?:exploit|auxiliary|post)/[A-Za-z0-9_./-]*[A-Za-z0-9_]`)
+	edbPattern       = regexp.MustCompile(`(?i)(?:EDB[-_ ]?(?:ID)?[\s:#-]*|exploit-db\.com/exploits/|exploitdb[\s:#-]+)(\d+)`)
+	edbListPattern   = regexp.MustCompile(`^[\d\s,;，；、]+$`)
+	digitsPattern    = regexp.MustCompile(`\d+`)
+)
+
+// ExtractCVEIDs 从自由文本中提取全部 CVE 编号，统一为大写
+func ExtractCVEIDs(text string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, m := range cvePattern.FindAllString(text, -1) {
+		id := strings.ToUpper(m)
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// ExtractMsfModules 从 msf 字段中提取 Metasploit 模块路径，例如 exploit/multi/http/struts2_content_type_ognl
+func ExtractMsfModules(text string) []string {
+	var modules []string
+	seen := make(map[string]bool)
+	for _, m := range msfModulePattern.FindAllString(text, -1) {
+		m = strings.TrimSuffix(m, ".rb")
+		if !seen[m] {
+			seen[m] = true
+			modules = append(modules, m)
+		}
+	}
+	return modules
+}
+
+// ExtractExploitDBIDs 从 exploitdb 字段中提取 Exploit-DB 编号，支持 "EDB-ID: 12345"、
+// exploit-db.com 链接以及只包含编号的列表
+func ExtractExploitDBIDs(text string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	add := func(id string) {
+		id = strings.TrimLeft(id, "0")
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, m := range edbPattern.FindAllStringSubmatch(text, -1) {
+		add(m[1])
+	}
+	if len(ids) == 0 && edbListPattern.MatchString(text) {
+		for _, m := range digitsPattern.FindAllString(text, -1) {
+			add(m)
+		}
+	}
+	return ids
+}
+
+// IsTruthy 判断自由文本形式的是/否字段
+func IsTruthy(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "true", "yes", "y", "是", "有":
+		return true
+	}
+	return false
+}
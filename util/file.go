@@ -0,0 +1,36 @@
+package util
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+)
+
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+// OpenDataFile 打开本地数据文件，.gz 结尾的文件自动解压
+func OpenDataFile(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(strings.ToLower(path), ".gz") {
+		return file, nil
+	}
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &gzipFile{Reader: reader, file: file}, nil
+}
@@ -0,0 +1,224 @@
+package util
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// 指标类型
+const (
+	IndicatorIPv4     = "ipv4"
+	IndicatorIPv6     = "ipv6"
+	IndicatorCIDR     = "cidr"
+	IndicatorDomain   = "domain"
+	IndicatorURL      = "url"
+	IndicatorEmail    = "email"
+	IndicatorMD5      = "md5"
+	IndicatorSHA1     = "sha1"
+	IndicatorSHA256   = "sha256"
+	IndicatorFilePath = "filepath"
+	IndicatorRegistry = "registry"
+)
+
+// Indicator 从文本中提取出的规范化指标
+type Indicator struct {
+	Type  string
+	Value string
+}
+
+var (
+	refangReplacer = strings.NewReplacer(
+		"[.]", ".", "(.)", ".", "{.}", ".", "[dot]", ".", "(dot)", ".", "{dot}", ".", "[点]", ".",
+		"[:]", ":", "[://]", "://", "[/]", "/",
+		"[@]", "@", "(@)", "@", "[at]", "@", "(at)", "@",
+	)
+	hxxpPattern = regexp.MustCompile(`(?i)\b(?:hxxp|hxtp|htxp|meow)(s?)(\[?:\]?//)`)
+
+	urlPattern      = regexp.MustCompile(`(?i)\b(?:https?|ftp)://[^\s"'<>\x60]+`)
+	emailPattern    = regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@(?:[a-z0-9-]+\.)+[a-z]{2,24}\b`)
+	registryPattern = regexp.MustCompile(`(?i)\b(?:HKEY_LOCAL_MACHINE|HKEY_CURRENT_USER|HKEY_CLASSES_ROOT|HKEY_USERS|HKEY_CURRENT_CONFIG|HKLM|HKCU|HKCR|HKU|HKCC)\\[^\s"'<>\x60]+`)
+	winPathPattern  = regexp.MustCompile(`(?i)(?:\b[a-z]:|%[a-z_]+%)\\[^\s"'<>|*?\x60]+`)
+	unixPathPattern = regexp.MustCompile(`(?:^|[\s"'(=])(/(?:tmp|etc|var|usr|home|opt|root|bin|sbin|dev|proc|lib|Library|Users|Applications|System)(?:/[^\s"'<>\x60]*)?)`)
+	cidrPattern     = regexp.MustCompile(`(?i)[0-9a-f:.]+/\d{1,3}\b`)
+	ipv4Pattern     = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	ipv6Pattern     = regexp.MustCompile(`(?i)[0-9a-f]{0,4}(?::[0-9a-f]{0,4}){2,7}`)
+	hashPattern     = regexp.MustCompile(`(?i)\b[0-9a-f]{32,64}\b`)
+	domainPattern   = regexp.MustCompile(`(?i)\b(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,24}\b`)
+)
+
+// fileExtensions 形如 "payload.exe" 的文件名不视为域名
+var fileExtensions = map[string]bool{
+	"exe": true, "dll": true, "sys": true, "bat": true, "cmd": true, "ps1": true, "vbs": true, "js": true,
+	"jar": true, "txt": true, "log": true, "doc": true, "docx": true, "xls": true, "xlsx": true, "ppt": true,
+	"pptx": true, "pdf": true, "rar": true, "7z": true, "gz": true, "tar": true, "php": true, "asp": true,
+	"aspx": true, "jsp": true, "html": true, "htm": true, "py": true, "dat": true, "tmp": true, "bin": true,
+	"lnk": true, "hta": true, "msi": true, "ini": true, "cfg": true, "conf": true, "xml": true, "json": true,
+	"yml": true, "yaml": true, "png": true, "jpg": true, "gif": true, "elf": true, "so": true, "dmp": true,
+}
+
+var registryHives = map[string]string{
+	"HKLM": "HKEY_LOCAL_MACHINE",
+	"HKCU": "HKEY_CURRENT_USER",
+	"HKCR": "HKEY_CLASSES_ROOT",
+	"HKU":  "HKEY_USERS",
+	"HKCC": "HKEY_CURRENT_CONFIG",
+}
+
+// Refang 还原去武装化的写法，例如 hxxp://evil[.]com -> http://evil.com
+func Refang(text string) string {
+	// 先还原 [://] 这类括号，否则 hxxp 的替换会留下多余的 "]"
+	text = refangReplacer.Replace(text)
+	return hxxpPattern.ReplaceAllStringFunc(text, func(m string) string {
+		sub := hxxpPattern.FindStringSubmatch(m)
+		return "http" + strings.ToLower(sub[1]) + "://"
+	})
+}
+
+func trimIndicator(s string) string {
+	return strings.TrimRight(s, ".,;:!?)]}>'\"，。；：）】")
+}
+
+// normalizeDomain 校验并规范化域名，文件名和不合法的结果返回空字符串
+func normalizeDomain(s string) string {
+	s = strings.ToLower(strings.TrimSuffix(trimIndicator(s), "."))
+	i := strings.LastIndex(s, ".")
+	if i < 0 || fileExtensions[s[i+1:]] || net.ParseIP(s) != nil {
+		return ""
+	}
+	if !domainPattern.MatchString(s) || domainPattern.FindString(s) != s {
+		return ""
+	}
+	return s
+}
+
+func normalizeURL(s string) string {
+	s = trimIndicator(s)
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	return u.String()
+}
+
+func normalizeRegistry(s string) string {
+	s = trimIndicator(s)
+	hive, rest, _ := strings.Cut(s, `\`)
+	if full, ok := registryHives[strings.ToUpper(hive)]; ok {
+		hive = full
+	}
+	// 注册表不区分大小写
+	return strings.ToUpper(hive) + `\` + strings.ToLower(strings.TrimRight(rest, `\`))
+}
+
+// NormalizeIndicator 识别单个指标的类型并返回规范化的值，用于查询
+func NormalizeIndicator(value string) (Indicator, bool) {
+	value = strings.TrimSpace(Refang(value))
+	if value == "" {
+		return Indicator{}, false
+	}
+	if indicators := ExtractIndicators(value); len(indicators) > 0 {
+		// 整段文本本身就是一个指标时，优先返回覆盖范围最大的类型(如 URL 而不是其中的域名)
+		return indicators[0], true
+	}
+	return Indicator{}, false
+}
+
+// ExtractIndicators 从自由文本(如知识条目的 IoC 字段)中提取 IP、CIDR、域名、URL、邮箱、
+// 哈希、文件路径和注册表键，结果已去重并规范化
+func ExtractIndicators(text string) []Indicator {
+	text = Refang(CleanString(text))
+
+	var indicators []Indicator
+	seen := make(map[string]bool)
+	add := func(t, v string) {
+		if v == "" || seen[t+"|"+v] {
+			return
+		}
+		seen[t+"|"+v] = true
+		indicators = append(indicators, Indicator{Type: t, Value: v})
+	}
+	// 已识别的部分替换为空格，避免 URL 中的路径被当作文件路径、邮箱的域名被重复提取
+	blank := func(pattern *regexp.Regexp, fn func(string)) {
+		text = pattern.ReplaceAllStringFunc(text, func(m string) string {
+			fn(m)
+			return strings.Repeat(" ", len(m))
+		})
+	}
+
+	blank(urlPattern, func(m string) {
+		if v := normalizeURL(m); v != "" {
+			add(IndicatorURL, v)
+			u, _ := url.Parse(v)
+			if ip := net.ParseIP(u.Hostname()); ip != nil {
+				add(ipType(ip), ip.String())
+			} else {
+				add(IndicatorDomain, normalizeDomain(u.Hostname()))
+			}
+		}
+	})
+	blank(emailPattern, func(m string) {
+		add(IndicatorEmail, strings.ToLower(m))
+	})
+	blank(registryPattern, func(m string) {
+		add(IndicatorRegistry, normalizeRegistry(m))
+	})
+	blank(winPathPattern, func(m string) {
+		// Windows 路径不区分大小写
+		add(IndicatorFilePath, strings.ToLower(trimIndicator(m)))
+	})
+	for _, m := range unixPathPattern.FindAllStringSubmatch(text, -1) {
+		add(IndicatorFilePath, trimIndicator(m[1]))
+	}
+	text = unixPathPattern.ReplaceAllStringFunc(text, func(m string) string {
+		return strings.Repeat(" ", len(m))
+	})
+	blank(cidrPattern, func(m string) {
+		if _, network, err := net.ParseCIDR(m); err == nil {
+			add(IndicatorCIDR, network.String())
+		}
+	})
+	for _, loc := range ipv4Pattern.FindAllStringIndex(text, -1) {
+		// 跳过 "1.2.3.4.5" 这类版本号
+		if (loc[0] > 0 && text[loc[0]-1] == '.') || (loc[1]+1 < len(text) && text[loc[1]] == '.' && isDigit(text[loc[1]+1])) {
+			continue
+		}
+		if ip := net.ParseIP(text[loc[0]:loc[1]]); ip != nil {
+			add(IndicatorIPv4, ip.String())
+		}
+	}
+	blank(hashPattern, func(m string) {
+		switch len(m) {
+		case 32:
+			add(IndicatorMD5, strings.ToLower(m))
+		case 40:
+			add(IndicatorSHA1, strings.ToLower(m))
+		case 64:
+			add(IndicatorSHA256, strings.ToLower(m))
+		}
+	})
+	for _, m := range ipv6Pattern.FindAllString(text, -1) {
+		if ip := net.ParseIP(m); ip != nil && ip.To4() == nil && !ip.IsUnspecified() {
+			add(IndicatorIPv6, ip.String())
+		}
+	}
+	for _, m := range domainPattern.FindAllString(text, -1) {
+		add(IndicatorDomain, normalizeDomain(m))
+	}
+
+	return indicators
+}
+
+func ipType(ip net.IP) string {
+	if ip.To4() != nil {
+		return IndicatorIPv4
+	}
+	return IndicatorIPv6
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
@@ -0,0 +1,78 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRefang(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"hxxp://evil[.]com/a", "http://evil.com/a"},
+		{"hXXps[://]bad(.)org", "https://bad.org"},
+		{"admin[at]corp[dot]cn", "admin@corp.cn"},
+		{"10[.]0[.]0[.]1", "10.0.0.1"},
+		{"plain text", "plain text"},
+	}
+	for _, tt := range tests {
+		if got := Refang(tt.in); got != tt.want {
+			t.Errorf("Refang(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExtractIndicators(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []Indicator
+	}{
+		{"C2: hxxp://Evil[.]com/gate.php", []Indicator{
+			{IndicatorURL, "http://evil.com/gate.php"},
+			{IndicatorDomain, "evil.com"},
+		}},
+		{"回连 192.168.1.10，版本 1.2.3.4.5", []Indicator{{IndicatorIPv4, "192.168.1.10"}}},
+		{"网段 10.0.0.0/8 和 2001:db8::1", []Indicator{
+			{IndicatorCIDR, "10.0.0.0/8"},
+			{IndicatorIPv6, "2001:db8::1"},
+		}},
+		{"mail: Admin@Corp.CN", []Indicator{{IndicatorEmail, "admin@corp.cn"}}},
+		{"d41d8cd98f00b204e9800998ecf8427E", []Indicator{{IndicatorMD5, "d41d8cd98f00b204e9800998ecf8427e"}}},
+		{"drops payload.exe to C:\\Users\\Public\\payload.exe", []Indicator{
+			{IndicatorFilePath, `c:\users\public\payload.exe`},
+		}},
+		{"persistence HKCU\\Software\\Microsoft\\Windows\\CurrentVersion\\Run\\", []Indicator{
+			{IndicatorRegistry, `HKEY_CURRENT_USER\software\microsoft\windows\currentversion\run`},
+		}},
+		{"writes /tmp/.x and a.com a.com", []Indicator{
+			{IndicatorFilePath, "/tmp/.x"},
+			{IndicatorDomain, "a.com"},
+		}},
+		{"nothing here", nil},
+	}
+	for _, tt := range tests {
+		if got := ExtractIndicators(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExtractIndicators(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeIndicator(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Indicator
+		ok   bool
+	}{
+		{" Evil[.]COM ", Indicator{IndicatorDomain, "evil.com"}, true},
+		{"hxxps://evil[.]com/x", Indicator{IndicatorURL, "https://evil.com/x"}, true},
+		{"8.8.8.8", Indicator{IndicatorIPv4, "8.8.8.8"}, true},
+		{"payload.exe", Indicator{}, false},
+		{"", Indicator{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := NormalizeIndicator(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("NormalizeIndicator(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
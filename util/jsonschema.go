@@ -0,0 +1,534 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Schema 编译后的 JSON Schema，只支持知识条目校验用到的子集：
+// type、required、properties、additionalProperties、enum、const、
+// minLength/maxLength/pattern/format、minimum/maximum/exclusiveMinimum/exclusiveMaximum、
+// items/minItems/maxItems/uniqueItems、allOf/anyOf/oneOf/not、if/then/else
+type Schema struct {
+	types      []string
+	required   []string
+	properties map[string]*Schema
+	additional *Schema // additionalProperties 为 false 时是 schemaFalse
+	enum       []interface{}
+	constant   interface{}
+	hasConst   bool
+	minLength  *int
+	maxLength  *int
+	pattern    *regexp.Regexp
+	format     string
+	minimum    *float64
+	maximum    *float64
+	exclMin    *float64
+	exclMax    *float64
+	items      *Schema
+	minItems   *int
+	maxItems   *int
+	unique     bool
+	allOf      []*Schema
+	anyOf      []*Schema
+	oneOf      []*Schema
+	not        *Schema
+	ifSchema   *Schema
+	thenSchema *Schema
+	elseSchema *Schema
+	never      bool // 布尔 schema false
+}
+
+var schemaFalse = &Schema{never: true}
+
+// 只作说明、不参与校验的关键字
+var schemaAnnotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true, "description": true,
+	"default": true, "examples": true, "deprecated": true, "readOnly": true, "writeOnly": true,
+}
+
+var schemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true, "integer": true, "boolean": true, "null": true,
+}
+
+// schemaFormats 支持的 format，cve、cwe、attack-technique、cvss 为知识库扩展
+var schemaFormats = map[string]func(string) bool{
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	},
+	"date": func(s string) bool {
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	},
+	"email": func(s string) bool {
+		_, err := mail.ParseAddress(s)
+		return err == nil && !strings.ContainsAny(s, "<> ")
+	},
+	"uri": func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	},
+	"ipv4": func(s string) bool {
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+	},
+	"ipv6": func(s string) bool {
+		ip := net.ParseIP(s)
+		return ip != nil && strings.Contains(s, ":")
+	},
+	"regex": func(s string) bool {
+		_, err := regexp.Compile(s)
+		return err == nil
+	},
+	"cve":              regexp.MustCompile(`^CVE-\d{4}-\d{4,}$`).MatchString,
+	"cwe":              regexp.MustCompile(`^CWE-\d+$`).MatchString,
+	"attack-technique": regexp.MustCompile(`^T\d{4}(\.\d{3})?$`).MatchString,
+	"cvss": func(s string) bool {
+		_, err := ParseCVSS(s)
+		return err == nil
+	},
+}
+
+// CompileSchema 解析 JSON Schema 文本，使用不支持的关键字时报错，避免写错的约束被静默忽略
+func CompileSchema(data []byte) (*Schema, error) {
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	return compileSchema(v, "")
+}
+
+func compileSchema(v interface{}, path string) (*Schema, error) {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return &Schema{}, nil
+		}
+		return schemaFalse, nil
+	case map[string]interface{}:
+	default:
+		return nil, fmt.Errorf("%s: schema must be an object or boolean", schemaPath(path))
+	}
+
+	m := v.(map[string]interface{})
+	s := &Schema{}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := m[key]
+		at := path + "/" + key
+		var err error
+		switch key {
+		case "type":
+			s.types, err = schemaStrings(value, at)
+			for _, t := range s.types {
+				if !schemaTypes[t] {
+					err = fmt.Errorf("%s: unknown type %q", at, t)
+				}
+			}
+		case "required":
+			s.required, err = schemaStrings(value, at)
+		case "properties":
+			props, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: must be an object", at)
+			}
+			s.properties = map[string]*Schema{}
+			for name, sub := range props {
+				if s.properties[name], err = compileSchema(sub, at+"/"+name); err != nil {
+					return nil, err
+				}
+			}
+		case "additionalProperties":
+			s.additional, err = compileSchema(value, at)
+		case "enum":
+			values, ok := value.([]interface{})
+			if !ok || len(values) == 0 {
+				return nil, fmt.Errorf("%s: must be a non-empty array", at)
+			}
+			s.enum = values
+		case "const":
+			s.constant, s.hasConst = value, true
+		case "minLength":
+			s.minLength, err = schemaInt(value, at)
+		case "maxLength":
+			s.maxLength, err = schemaInt(value, at)
+		case "minItems":
+			s.minItems, err = schemaInt(value, at)
+		case "maxItems":
+			s.maxItems, err = schemaInt(value, at)
+		case "uniqueItems":
+			b, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("%s: must be a boolean", at)
+			}
+			s.unique = b
+		case "pattern":
+			str, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: must be a string", at)
+			}
+			if s.pattern, err = regexp.Compile(str); err != nil {
+				err = fmt.Errorf("%s: %v", at, err)
+			}
+		case "format":
+			str, ok := value.(string)
+			if !ok || schemaFormats[str] == nil {
+				return nil, fmt.Errorf("%s: unsupported format %v", at, value)
+			}
+			s.format = str
+		case "minimum":
+			s.minimum, err = schemaNumber(value, at)
+		case "maximum":
+			s.maximum, err = schemaNumber(value, at)
+		case "exclusiveMinimum":
+			s.exclMin, err = schemaNumber(value, at)
+		case "exclusiveMaximum":
+			s.exclMax, err = schemaNumber(value, at)
+		case "items":
+			s.items, err = compileSchema(value, at)
+		case "allOf":
+			s.allOf, err = schemaList(value, at)
+		case "anyOf":
+			s.anyOf, err = schemaList(value, at)
+		case "oneOf":
+			s.oneOf, err = schemaList(value, at)
+		case "not":
+			s.not, err = compileSchema(value, at)
+		case "if":
+			s.ifSchema, err = compileSchema(value, at)
+		case "then":
+			s.thenSchema, err = compileSchema(value, at)
+		case "else":
+			s.elseSchema, err = compileSchema(value, at)
+		default:
+			if !schemaAnnotations[key] {
+				return nil, fmt.Errorf("%s: unsupported keyword", at)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func schemaPath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+func schemaStrings(v interface{}, path string) ([]string, error) {
+	if s, ok := v.(string); ok {
+		return []string{s}, nil
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: must be a string or an array of strings", path)
+	}
+	var out []string
+	for _, item := range list {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: must be an array of strings", path)
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func schemaNumber(v interface{}, path string) (*float64, error) {
+	f, ok := jsonNumber(v)
+	if !ok {
+		return nil, fmt.Errorf("%s: must be a number", path)
+	}
+	return &f, nil
+}
+
+func schemaInt(v interface{}, path string) (*int, error) {
+	f, ok := jsonNumber(v)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return nil, fmt.Errorf("%s: must be a non-negative integer", path)
+	}
+	n := int(f)
+	return &n, nil
+}
+
+func schemaList(v interface{}, path string) ([]*Schema, error) {
+	list, ok := v.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("%s: must be a non-empty array", path)
+	}
+	var out []*Schema
+	for i, item := range list {
+		s, err := compileSchema(item, path+"/"+strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func jsonNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		if f, ok := jsonNumber(v); ok {
+			if f == math.Trunc(f) {
+				return "integer"
+			}
+			return "number"
+		}
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// jsonEqual 比较 JSON 值，数字按数值比较
+func jsonEqual(a, b interface{}) bool {
+	fa, okA := jsonNumber(a)
+	fb, okB := jsonNumber(b)
+	if okA || okB {
+		return okA && okB && fa == fb
+	}
+	switch a := a.(type) {
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !jsonEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			if w, ok := b[k]; !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Validate 校验 JSON 值(json.Unmarshal 得到的 map、slice 等)，返回全部问题，格式为 "路径: 说明"
+func (s *Schema) Validate(v interface{}) []string {
+	var errs []string
+	s.validate(v, "", &errs)
+	return errs
+}
+
+func (s *Schema) valid(v interface{}) bool {
+	var errs []string
+	s.validate(v, "", &errs)
+	return len(errs) == 0
+}
+
+func (s *Schema) validate(v interface{}, path string, errs *[]string) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, schemaPath(path)+": "+fmt.Sprintf(format, args...))
+	}
+	if s.never {
+		fail("not allowed")
+		return
+	}
+
+	if len(s.types) > 0 {
+		actual := jsonType(v)
+		ok := false
+		for _, t := range s.types {
+			if t == actual || (t == "number" && actual == "integer") {
+				ok = true
+			}
+		}
+		if !ok {
+			fail("expected %s, got %s", strings.Join(s.types, " or "), actual)
+			return
+		}
+	}
+	if len(s.enum) > 0 {
+		ok := false
+		for _, e := range s.enum {
+			if jsonEqual(v, e) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			fail("must be one of %s", schemaValues(s.enum))
+		}
+	}
+	if s.hasConst && !jsonEqual(v, s.constant) {
+		fail("must be %s", schemaValues([]interface{}{s.constant}))
+	}
+
+	switch v := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.minLength != nil && n < *s.minLength {
+			fail("must be at least %d characters", *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			fail("must be at most %d characters, got %d", *s.maxLength, n)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("must match %s", s.pattern)
+		}
+		if s.format != "" && !schemaFormats[s.format](v) {
+			fail("must be a valid %s", s.format)
+		}
+	case []interface{}:
+		if s.minItems != nil && len(v) < *s.minItems {
+			fail("must have at least %d items", *s.minItems)
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			fail("must have at most %d items", *s.maxItems)
+		}
+		if s.unique {
+			for i := range v {
+				for j := 0; j < i; j++ {
+					if jsonEqual(v[i], v[j]) {
+						fail("items %d and %d are equal", j, i)
+					}
+				}
+			}
+		}
+		if s.items != nil {
+			for i, item := range v {
+				s.items.validate(item, path+"/"+strconv.Itoa(i), errs)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, path+"/"+name+": is required")
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if sub, ok := s.properties[name]; ok {
+				sub.validate(v[name], path+"/"+name, errs)
+			} else if s.additional != nil {
+				s.additional.validate(v[name], path+"/"+name, errs)
+			}
+		}
+	default:
+		if f, ok := jsonNumber(v); ok {
+			if s.minimum != nil && f < *s.minimum {
+				fail("must be >= %v", *s.minimum)
+			}
+			if s.maximum != nil && f > *s.maximum {
+				fail("must be <= %v", *s.maximum)
+			}
+			if s.exclMin != nil && f <= *s.exclMin {
+				fail("must be > %v", *s.exclMin)
+			}
+			if s.exclMax != nil && f >= *s.exclMax {
+				fail("must be < %v", *s.exclMax)
+			}
+		}
+	}
+
+	for _, sub := range s.allOf {
+		sub.validate(v, path, errs)
+	}
+	if len(s.anyOf) > 0 {
+		ok := false
+		for _, sub := range s.anyOf {
+			if sub.valid(v) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			fail("does not match any of the allowed schemas")
+		}
+	}
+	if len(s.oneOf) > 0 {
+		matched := 0
+		for _, sub := range s.oneOf {
+			if sub.valid(v) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			fail("must match exactly one schema, matched %d", matched)
+		}
+	}
+	if s.not != nil && s.not.valid(v) {
+		fail("must not match the schema")
+	}
+	if s.ifSchema != nil {
+		if s.ifSchema.valid(v) {
+			if s.thenSchema != nil {
+				s.thenSchema.validate(v, path, errs)
+			}
+		} else if s.elseSchema != nil {
+			s.elseSchema.validate(v, path, errs)
+		}
+	}
+}
+
+func schemaValues(values []interface{}) string {
+	var parts []string
+	for _, v := range values {
+		b, _ := json.Marshal(v)
+		parts = append(parts, string(b))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,78 @@
+package util
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["title", "cve"],
+	"additionalProperties": false,
+	"properties": {
+		"title": {"type": "string", "minLength": 2, "maxLength": 10},
+		"cve": {"type": "string", "format": "cve"},
+		"score": {"type": "number", "minimum": 0, "maximum": 10},
+		"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "maxItems": 3},
+		"severity": {"enum": ["low", "medium", "high"]},
+		"ttp": {"type": "string", "pattern": "^T\\d{4}$"}
+	},
+	"if": {"properties": {"severity": {"const": "high"}}, "required": ["severity"]},
+	"then": {"required": ["score"]}
+}`
+
+func TestSchemaValidate(t *testing.T) {
+	schema, err := CompileSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("CompileSchema error: %v", err)
+	}
+	tests := []struct {
+		doc   string
+		paths []string // 期望出错的路径，nil 表示校验通过
+	}{
+		{`{"title": "Log4Shell", "cve": "CVE-2021-44228"}`, nil},
+		{`{"title": "Log4Shell", "cve": "CVE-2021-44228", "severity": "high", "score": 10, "tags": ["rce", "java"]}`, nil},
+		{`{"cve": "CVE-2021-44228"}`, []string{"/title"}},
+		{`{"title": "x", "cve": "CVE-21-1"}`, []string{"/cve", "/title"}},
+		{`{"title": "Log4Shell", "cve": "CVE-2021-44228", "score": 11}`, []string{"/score"}},
+		{`{"title": "Log4Shell", "cve": "CVE-2021-44228", "tags": ["a", "a"]}`, []string{"/tags"}},
+		{`{"title": "Log4Shell", "cve": "CVE-2021-44228", "tags": ["a", 1]}`, []string{"/tags/1"}},
+		{`{"title": "Log4Shell", "cve": "CVE-2021-44228", "severity": "urgent"}`, []string{"/severity"}},
+		{`{"title": "Log4Shell", "cve": "CVE-2021-44228", "severity": "high"}`, []string{"/score"}},
+		{`{"title": "Log4Shell", "cve": "CVE-2021-44228", "ttp": "T1059.001"}`, []string{"/ttp"}},
+		{`{"title": "Log4Shell", "cve": "CVE-2021-44228", "extra": true}`, []string{"/extra"}},
+	}
+	for _, tt := range tests {
+		var doc interface{}
+		if err := json.Unmarshal([]byte(tt.doc), &doc); err != nil {
+			t.Fatalf("bad test document %s: %v", tt.doc, err)
+		}
+		errs := schema.Validate(doc)
+		if len(errs) != len(tt.paths) {
+			t.Errorf("Validate(%s) = %q, want errors at %q", tt.doc, errs, tt.paths)
+			continue
+		}
+		for i, path := range tt.paths {
+			if !strings.HasPrefix(errs[i], path+":") {
+				t.Errorf("Validate(%s) error %d = %q, want path %s", tt.doc, i, errs[i], path)
+			}
+		}
+	}
+}
+
+func TestCompileSchemaErrors(t *testing.T) {
+	tests := []string{
+		`{"type": "object"`,
+		`{"type": "text"}`,
+		`{"properties": {"a": {"minLength": -1}}}`,
+		`{"pattern": "("}`,
+		`{"format": "phone"}`,
+		`{"$ref": "#/definitions/a"}`,
+	}
+	for _, schema := range tests {
+		if _, err := CompileSchema([]byte(schema)); err == nil {
+			t.Errorf("CompileSchema(%s) succeeded, want error", schema)
+		}
+	}
+}
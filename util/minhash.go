@@ -0,0 +1,119 @@
+package util
+
+import (
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+// MinHashSize MinHash 签名长度，相似度估计的误差约为 1/sqrt(MinHashSize)
+const MinHashSize = 128
+
+// ShingleSize 按字符切分的片段长度，中文没有空格分词，按字符切分对中英文都适用
+const ShingleSize = 5
+
+// NormalizeText 转为小写、去除零宽字符、标点和多余空白，用于比较文本相似度
+func NormalizeText(text string) string {
+	text = strings.ToLower(CleanString(text))
+	var b strings.Builder
+	space := false
+	for _, r := range text {
+		if unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r) {
+			if !space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		b.WriteRune(r)
+		space = false
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Shingles 返回规范化文本中全部长度为 ShingleSize 的字符片段的哈希，去重
+func Shingles(text string) []uint64 {
+	runes := []rune(NormalizeText(text))
+	if len(runes) < ShingleSize {
+		if len(runes) == 0 {
+			return nil
+		}
+		return []uint64{hashString(string(runes))}
+	}
+
+	seen := make(map[uint64]bool)
+	var shingles []uint64
+	for i := 0; i+ShingleSize <= len(runes); i++ {
+		h := hashString(string(runes[i : i+ShingleSize]))
+		if !seen[h] {
+			seen[h] = true
+			shingles = append(shingles, h)
+		}
+	}
+	return shingles
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// mix splitmix64，用不同的种子模拟 MinHashSize 个相互独立的哈希函数
+func mix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// MinHash 计算片段集合的签名，两个签名中相同位置取值相等的比例是 Jaccard 相似度的估计
+func MinHash(shingles []uint64) []uint64 {
+	if len(shingles) == 0 {
+		return nil
+	}
+	sig := make([]uint64, MinHashSize)
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+	for _, s := range shingles {
+		for i := range sig {
+			if h := mix(s ^ mix(uint64(i))); h < sig[i] {
+				sig[i] = h
+			}
+		}
+	}
+	return sig
+}
+
+// MinHashSimilarity 估计两个签名对应文本的 Jaccard 相似度
+func MinHashSimilarity(a, b []uint64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(len(a))
+}
+
+// MinHashBands 将签名分为 bands 段并对每段取哈希(LSH)，任意一段相同的两个文本才需要比较。
+// 每段 MinHashSize/bands 行，相似度高于约 (1/bands)^(bands/MinHashSize) 的文本大概率落入同一段
+func MinHashBands(sig []uint64, bands int) []uint64 {
+	if len(sig) == 0 || bands <= 0 {
+		return nil
+	}
+	rows := len(sig) / bands
+	keys := make([]uint64, bands)
+	for band := 0; band < bands; band++ {
+		h := mix(uint64(band))
+		for _, v := range sig[band*rows : (band+1)*rows] {
+			h = mix(h ^ v)
+		}
+		keys[band] = h
+	}
+	return keys
+}
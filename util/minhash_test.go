@@ -0,0 +1,86 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Hello,  World!", "hello world"},
+		{"  APT-28 攻击\u200b活动。", "apt 28 攻击活动"},
+		{"...", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeText(tt.in); got != tt.want {
+			t.Errorf("NormalizeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestShingles(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"abc", 1},
+		{"abcde", 1},
+		{"abcdef", 2},
+		{"aaaaaaaa", 1},
+	}
+	for _, tt := range tests {
+		if got := len(Shingles(tt.in)); got != tt.want {
+			t.Errorf("len(Shingles(%q)) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+	if !reflect.DeepEqual(Shingles("Hello, World"), Shingles("hello world")) {
+		t.Error("Shingles should ignore case and punctuation")
+	}
+}
+
+func TestMinHashSimilarity(t *testing.T) {
+	base := "APT 组织利用鱼叉式钓鱼邮件投递恶意文档，释放后门并通过 HTTPS 回连 C2 服务器窃取数据"
+	tests := []struct {
+		name     string
+		a, b     string
+		min, max float64
+	}{
+		{"identical", base, base, 1, 1},
+		{"punctuation only", base, base + "。", 1, 1},
+		{"small edit", base, base + "，随后横向移动", 0.6, 1},
+		{"unrelated", base, "Log4j JNDI lookup remote code execution in versions before 2.15.0", 0, 0.1},
+	}
+	for _, tt := range tests {
+		got := MinHashSimilarity(MinHash(Shingles(tt.a)), MinHash(Shingles(tt.b)))
+		if got < tt.min || got > tt.max {
+			t.Errorf("%s: similarity = %.2f, want in [%.2f, %.2f]", tt.name, got, tt.min, tt.max)
+		}
+	}
+
+	if got := MinHashSimilarity(nil, nil); got != 0 {
+		t.Errorf("MinHashSimilarity(nil, nil) = %v, want 0", got)
+	}
+	if got := MinHash(nil); got != nil {
+		t.Errorf("MinHash(nil) = %v, want nil", got)
+	}
+}
+
+func TestMinHashBands(t *testing.T) {
+	sig := MinHash(Shingles("near duplicate detection"))
+	bands := MinHashBands(sig, 16)
+	if len(bands) != 16 {
+		t.Fatalf("len(MinHashBands) = %d, want 16", len(bands))
+	}
+	if !reflect.DeepEqual(bands, MinHashBands(MinHash(Shingles("Near-duplicate detection!")), 16)) {
+		t.Error("equal signatures should produce equal bands")
+	}
+	if got := MinHashBands(nil, 16); got != nil {
+		t.Errorf("MinHashBands(nil) = %v, want nil", got)
+	}
+	if got := MinHashBands(sig, 0); got != nil {
+		t.Errorf("MinHashBands(sig, 0) = %v, want nil", got)
+	}
+}
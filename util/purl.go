@@ -0,0 +1,55 @@
+package util
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// PURL package URL 中的各个部分：pkg:type/namespace/name@version?qualifiers#subpath
+type PURL struct {
+	Type      string
+	Namespace string
+	Name      string
+	Version   string
+}
+
+// ParsePURL 解析 package URL，忽略 qualifiers 和 subpath
+func ParsePURL(s string) (*PURL, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(strings.ToLower(s), "pkg:") {
+		return nil, fmt.Errorf("invalid purl: %q", s)
+	}
+	s = s[len("pkg:"):]
+	if i := strings.Index(s, "#"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, "?"); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.Trim(s, "/")
+
+	p := &PURL{}
+	if i := strings.LastIndex(s, "@"); i >= 0 {
+		p.Version, s = s[i+1:], s[:i]
+	}
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid purl: missing name in %q", s)
+	}
+	p.Type = strings.ToLower(parts[0])
+	p.Name = parts[len(parts)-1]
+	p.Namespace = strings.Join(parts[1:len(parts)-1], "/")
+
+	unescape := func(v string) string {
+		if decoded, err := url.PathUnescape(v); err == nil {
+			return decoded
+		}
+		return v
+	}
+	p.Namespace, p.Name, p.Version = unescape(p.Namespace), unescape(p.Name), unescape(p.Version)
+	if p.Type == "" || p.Name == "" {
+		return nil, fmt.Errorf("invalid purl: %q", s)
+	}
+	return p, nil
+}
@@ -0,0 +1,34 @@
+package util
+
+import "testing"
+
+func TestParsePURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want PURL
+	}{
+		{"pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1",
+			PURL{"maven", "org.apache.logging.log4j", "log4j-core", "2.14.1"}},
+		{"pkg:npm/%40babel/core@7.0.0", PURL{"npm", "@babel", "core", "7.0.0"}},
+		{"pkg:pypi/django@3.2?os=linux#src", PURL{"pypi", "", "django", "3.2"}},
+		{"pkg:golang/github.com/gin-gonic/gin@v1.9.1",
+			PURL{"golang", "github.com/gin-gonic", "gin", "v1.9.1"}},
+		{"PKG:Deb/debian/openssl", PURL{"deb", "debian", "openssl", ""}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePURL(tt.in)
+		if err != nil {
+			t.Errorf("ParsePURL(%q) error: %v", tt.in, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("ParsePURL(%q) = %+v, want %+v", tt.in, *got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "maven/log4j", "pkg:npm", "pkg:npm/@1.0"} {
+		if got, err := ParsePURL(in); err == nil {
+			t.Errorf("ParsePURL(%q) = %+v, want error", in, got)
+		}
+	}
+}
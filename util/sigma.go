@@ -0,0 +1,696 @@
+package util
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SigmaRule 编译后的 Sigma 规则，可以对单条事件求值
+type SigmaRule struct {
+	Title      string
+	searches   map[string]sigmaSearch
+	names      []string
+	conditions []sigmaExpr
+}
+
+// CompileSigma 解析并编译一条 Sigma 规则，不支持的修饰符和聚合条件会返回错误
+func CompileSigma(text string) (*SigmaRule, error) {
+	var doc struct {
+		Title     string               `yaml:"title"`
+		Detection map[string]yaml.Node `yaml:"detection"`
+	}
+	dec := yaml.NewDecoder(strings.NewReader(text))
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("yaml: %v", err)
+	}
+	var extra interface{}
+	if dec.Decode(&extra) == nil {
+		return nil, errors.New("multi-document rules are not supported")
+	}
+
+	rule := &SigmaRule{Title: doc.Title, searches: make(map[string]sigmaSearch)}
+	var conditions []string
+	for name, node := range doc.Detection {
+		switch name {
+		case "condition":
+			if err := node.Decode(&conditions); err != nil {
+				var condition string
+				if err := node.Decode(&condition); err != nil {
+					return nil, errors.New("detection.condition must be a string or list")
+				}
+				conditions = []string{condition}
+			}
+		case "timeframe":
+		default:
+			var value interface{}
+			if err := node.Decode(&value); err != nil {
+				return nil, fmt.Errorf("detection.%s: %v", name, err)
+			}
+			search, err := compileSigmaSearch(value)
+			if err != nil {
+				return nil, fmt.Errorf("detection.%s: %v", name, err)
+			}
+			rule.searches[name] = search
+			rule.names = append(rule.names, name)
+		}
+	}
+	sort.Strings(rule.names)
+	if len(conditions) == 0 {
+		return nil, errors.New("detection.condition is required")
+	}
+
+	for _, condition := range conditions {
+		if strings.Contains(condition, "|") {
+			return nil, errors.New("aggregation conditions are not supported")
+		}
+		expr, err := parseSigmaCondition(condition, rule)
+		if err != nil {
+			return nil, fmt.Errorf("condition %q: %v", condition, err)
+		}
+		rule.conditions = append(rule.conditions, expr)
+	}
+	return rule, nil
+}
+
+// Match 判断事件是否命中规则，有多个 condition 时任意一个成立即命中
+func (r *SigmaRule) Match(event map[string]interface{}) bool {
+	for _, condition := range r.conditions {
+		if condition.eval(r, event) {
+			return true
+		}
+	}
+	return false
+}
+
+// 检索标识
+
+// sigmaSearch 检索标识的定义：多个 map 之间为或，map 内多个字段为与
+type sigmaSearch struct {
+	groups   [][]*sigmaField
+	keywords []*regexp.Regexp
+}
+
+func (s sigmaSearch) match(event map[string]interface{}) bool {
+	for _, group := range s.groups {
+		matched := true
+		for _, field := range group {
+			if !field.match(event) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	if len(s.keywords) > 0 {
+		values := eventStrings(event, nil)
+		for _, keyword := range s.keywords {
+			for _, v := range values {
+				if keyword.MatchString(v) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func compileSigmaSearch(value interface{}) (sigmaSearch, error) {
+	var search sigmaSearch
+	switch v := value.(type) {
+	case map[string]interface{}:
+		group, err := compileSigmaGroup(v)
+		if err != nil {
+			return search, err
+		}
+		search.groups = append(search.groups, group)
+	case []interface{}:
+		for _, item := range v {
+			switch item := item.(type) {
+			case map[string]interface{}:
+				group, err := compileSigmaGroup(item)
+				if err != nil {
+					return search, err
+				}
+				search.groups = append(search.groups, group)
+			case nil, map[interface{}]interface{}, []interface{}:
+				return search, errors.New("list items must be maps or keywords")
+			default:
+				re, err := sigmaPattern(sigmaString(item), "contains", false)
+				if err != nil {
+					return search, err
+				}
+				search.keywords = append(search.keywords, re)
+			}
+		}
+	case string:
+		re, err := sigmaPattern(v, "contains", false)
+		if err != nil {
+			return search, err
+		}
+		search.keywords = append(search.keywords, re)
+	default:
+		return search, errors.New("search must be a map, a list of maps or keywords")
+	}
+	return search, nil
+}
+
+func compileSigmaGroup(m map[string]interface{}) ([]*sigmaField, error) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var group []*sigmaField
+	for _, key := range keys {
+		field, err := compileSigmaField(key, m[key])
+		if err != nil {
+			return nil, err
+		}
+		group = append(group, field)
+	}
+	return group, nil
+}
+
+// 字段匹配
+
+type sigmaField struct {
+	name    string
+	op      string // equals contains startswith endswith re cidr lt lte gt gte exists
+	all     bool
+	null    bool // 值为 null，字段不存在或为空时成立
+	exists  bool
+	numbers []float64
+	values  [][]*regexp.Regexp // 每个值经修饰符变换后的全部写法，命中任意一种即可
+	nets    []*net.IPNet
+}
+
+var sigmaOps = map[string]bool{
+	"contains": true, "startswith": true, "endswith": true, "re": true, "cidr": true,
+	"lt": true, "lte": true, "gt": true, "gte": true, "exists": true,
+}
+
+func compileSigmaField(key string, value interface{}) (*sigmaField, error) {
+	parts := strings.Split(key, "|")
+	field := &sigmaField{name: parts[0], op: "equals"}
+
+	var transforms []string
+	cased := false
+	reFlags := ""
+	for _, modifier := range parts[1:] {
+		switch modifier {
+		case "all":
+			field.all = true
+		case "cased":
+			cased = true
+		case "base64", "base64offset", "wide", "utf16le", "utf16", "windash":
+			transforms = append(transforms, modifier)
+		case "i", "m", "s":
+			reFlags += modifier
+		default:
+			if !sigmaOps[modifier] {
+				return nil, fmt.Errorf("unsupported modifier %q", modifier)
+			}
+			field.op = modifier
+		}
+	}
+
+	var values []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		values = v
+	default:
+		values = []interface{}{v}
+	}
+
+	for _, v := range values {
+		if v == nil {
+			field.null = true
+			continue
+		}
+		s := sigmaString(v)
+		switch field.op {
+		case "exists":
+			b, ok := v.(bool)
+			if !ok {
+				return nil, fmt.Errorf("%s: exists requires true or false", key)
+			}
+			field.exists = b
+		case "lt", "lte", "gt", "gte":
+			n, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %q is not a number", key, s)
+			}
+			field.numbers = append(field.numbers, n)
+		case "cidr":
+			_, network, err := net.ParseCIDR(s)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+			field.nets = append(field.nets, network)
+		case "re":
+			flags := reFlags
+			if flags != "" {
+				flags = "(?" + flags + ")"
+			}
+			re, err := regexp.Compile(flags + s)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+			field.values = append(field.values, []*regexp.Regexp{re})
+		default:
+			var variants []*regexp.Regexp
+			for _, variant := range sigmaTransform(s, transforms) {
+				re, err := sigmaPattern(variant, field.op, cased)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %v", key, err)
+				}
+				variants = append(variants, re)
+			}
+			field.values = append(field.values, variants)
+		}
+	}
+	return field, nil
+}
+
+// sigmaTransform 按 wide、base64、windash 等修饰符生成需要匹配的值
+func sigmaTransform(s string, transforms []string) []string {
+	variants := []string{s}
+	for _, t := range transforms {
+		var next []string
+		for _, v := range variants {
+			switch t {
+			case "wide", "utf16le", "utf16":
+				var b strings.Builder
+				for _, u := range utf16.Encode([]rune(v)) {
+					b.WriteByte(byte(u))
+					b.WriteByte(byte(u >> 8))
+				}
+				next = append(next, b.String())
+			case "base64":
+				next = append(next, base64.StdEncoding.EncodeToString([]byte(v)))
+			case "base64offset":
+				// 值在原文中的偏移不同，编码结果也不同；去掉受前后内容影响的字符
+				for offset := 0; offset < 3; offset++ {
+					encoded := base64.StdEncoding.EncodeToString(append(make([]byte, offset), v...))
+					start := []int{0, 2, 3}[offset]
+					end := len(encoded) - []int{0, 3, 2}[(len(v)+offset)%3]
+					if start < end {
+						next = append(next, encoded[start:end])
+					}
+				}
+			case "windash":
+				// Windows 命令行参数前缀 - 和 / 等价
+				next = append(next, v)
+				for _, dash := range []string{"/", "–", "—", "―"} {
+					if replaced := strings.ReplaceAll(v, "-", dash); replaced != v {
+						next = append(next, replaced)
+					}
+				}
+			}
+		}
+		variants = next
+	}
+	return variants
+}
+
+// sigmaPattern 将 Sigma 值转换为正则：* 匹配任意字符，? 匹配单个字符，\ 转义；默认不区分大小写
+func sigmaPattern(s, op string, cased bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	if !cased {
+		b.WriteString("(?i)")
+	}
+	b.WriteString("(?s)")
+	if op != "contains" && op != "endswith" {
+		b.WriteString("^")
+	} else {
+		b.WriteString(".*")
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '*' || s[i+1] == '?' || s[i+1] == '\\'):
+			b.WriteString(regexp.QuoteMeta(string(s[i+1])))
+			i++
+		case c == '*':
+			b.WriteString(".*")
+		case c == '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if op != "contains" && op != "startswith" {
+		b.WriteString("$")
+	}
+	return regexp.Compile(b.String())
+}
+
+func sigmaString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// lookupField 按字段名取值，找不到时把 . 当作嵌套路径
+func lookupField(event map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := event[name]; ok {
+		return v, true
+	}
+	var current interface{} = event
+	for _, part := range strings.Split(name, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// fieldValues 将字段值展开为字符串列表，数组中任意元素都可以命中
+func fieldValues(v interface{}) []string {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			values = append(values, fieldValues(item)...)
+		}
+		return values
+	case map[string]interface{}:
+		return nil
+	default:
+		return []string{sigmaString(v)}
+	}
+}
+
+// eventStrings 收集事件中全部字段的值，用于关键字检索
+func eventStrings(v interface{}, values []string) []string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, item := range v {
+			values = eventStrings(item, values)
+		}
+	case []interface{}:
+		for _, item := range v {
+			values = eventStrings(item, values)
+		}
+	case nil:
+	default:
+		values = append(values, sigmaString(v))
+	}
+	return values
+}
+
+func (f *sigmaField) match(event map[string]interface{}) bool {
+	raw, found := lookupField(event, f.name)
+	values := fieldValues(raw)
+	empty := !found || raw == nil || (len(values) == 1 && values[0] == "")
+
+	if f.op == "exists" {
+		return found == f.exists
+	}
+	if f.null && empty {
+		return true
+	}
+
+	var checks []func(string) bool
+	for _, variants := range f.values {
+		variants := variants
+		checks = append(checks, func(s string) bool {
+			for _, re := range variants {
+				if re.MatchString(s) {
+					return true
+				}
+			}
+			return false
+		})
+	}
+	for _, network := range f.nets {
+		network := network
+		checks = append(checks, func(s string) bool {
+			ip := net.ParseIP(s)
+			return ip != nil && network.Contains(ip)
+		})
+	}
+	for _, n := range f.numbers {
+		n := n
+		checks = append(checks, func(s string) bool {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return false
+			}
+			switch f.op {
+			case "lt":
+				return v < n
+			case "lte":
+				return v <= n
+			case "gt":
+				return v > n
+			default:
+				return v >= n
+			}
+		})
+	}
+	if len(checks) == 0 {
+		return false
+	}
+
+	matches := func(check func(string) bool) bool {
+		for _, v := range values {
+			if check(v) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, check := range checks {
+		if matches(check) != f.all {
+			// 非 all 时任意一个值命中即成立；all 时任意一个值不命中即不成立
+			return !f.all
+		}
+	}
+	return f.all
+}
+
+// 条件表达式
+
+type sigmaExpr interface {
+	eval(r *SigmaRule, event map[string]interface{}) bool
+}
+
+type sigmaIdentExpr string
+type sigmaNotExpr struct{ expr sigmaExpr }
+type sigmaAndExpr []sigmaExpr
+type sigmaOrExpr []sigmaExpr
+
+// sigmaOfExpr "1 of selection_*"、"all of them" 这类表达式，count 为 0 表示 all
+type sigmaOfExpr struct {
+	count int
+	names []string
+}
+
+func (e sigmaIdentExpr) eval(r *SigmaRule, event map[string]interface{}) bool {
+	return r.searches[string(e)].match(event)
+}
+
+func (e sigmaNotExpr) eval(r *SigmaRule, event map[string]interface{}) bool {
+	return !e.expr.eval(r, event)
+}
+
+func (e sigmaAndExpr) eval(r *SigmaRule, event map[string]interface{}) bool {
+	for _, expr := range e {
+		if !expr.eval(r, event) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e sigmaOrExpr) eval(r *SigmaRule, event map[string]interface{}) bool {
+	for _, expr := range e {
+		if expr.eval(r, event) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e sigmaOfExpr) eval(r *SigmaRule, event map[string]interface{}) bool {
+	matched := 0
+	for _, name := range e.names {
+		if r.searches[name].match(event) {
+			matched++
+		} else if e.count == 0 {
+			return false
+		}
+	}
+	if e.count == 0 {
+		return true
+	}
+	return matched >= e.count
+}
+
+type sigmaParser struct {
+	tokens []string
+	pos    int
+	rule   *SigmaRule
+}
+
+func parseSigmaCondition(condition string, rule *SigmaRule) (sigmaExpr, error) {
+	p := &sigmaParser{
+		tokens: strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(condition)),
+		rule:   rule,
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+func (p *sigmaParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return strings.ToLower(p.tokens[p.pos])
+	}
+	return ""
+}
+
+func (p *sigmaParser) parseOr() (sigmaExpr, error) {
+	exprs := sigmaOrExpr{}
+	for {
+		expr, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		if p.peek() != "or" {
+			break
+		}
+		p.pos++
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return exprs, nil
+}
+
+func (p *sigmaParser) parseAnd() (sigmaExpr, error) {
+	exprs := sigmaAndExpr{}
+	for {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		if p.peek() != "and" {
+			break
+		}
+		p.pos++
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return exprs, nil
+}
+
+func (p *sigmaParser) parseNot() (sigmaExpr, error) {
+	if p.peek() == "not" {
+		p.pos++
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return sigmaNotExpr{expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *sigmaParser) parsePrimary() (sigmaExpr, error) {
+	token := p.peek()
+	switch {
+	case token == "":
+		return nil, errors.New("unexpected end of condition")
+	case token == "(":
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.New("missing )")
+		}
+		p.pos++
+		return expr, nil
+	case token == "all" || token == "any" || isNumber(token):
+		p.pos++
+		if p.peek() != "of" {
+			return nil, fmt.Errorf("expected 'of' after %q", token)
+		}
+		p.pos++
+		target := p.peek()
+		if target == "" {
+			return nil, errors.New("expected search identifier after 'of'")
+		}
+		p.pos++
+
+		expr := sigmaOfExpr{count: 1}
+		if token == "all" {
+			expr.count = 0
+		} else if token != "any" {
+			expr.count, _ = strconv.Atoi(token)
+		}
+		if target == "them" {
+			// them 不包括以下划线开头的检索标识
+			for _, name := range p.rule.names {
+				if !strings.HasPrefix(name, "_") {
+					expr.names = append(expr.names, name)
+				}
+			}
+		} else {
+			re := regexp.MustCompile("^" + strings.ReplaceAll(regexp.QuoteMeta(p.tokens[p.pos-1]), `\*`, ".*") + "$")
+			for _, name := range p.rule.names {
+				if re.MatchString(name) {
+					expr.names = append(expr.names, name)
+				}
+			}
+		}
+		if len(expr.names) == 0 {
+			return nil, fmt.Errorf("no search identifiers match %q", p.tokens[p.pos-1])
+		}
+		return expr, nil
+	default:
+		name := p.tokens[p.pos]
+		if _, ok := p.rule.searches[name]; !ok {
+			return nil, fmt.Errorf("undefined search %q", name)
+		}
+		p.pos++
+		return sigmaIdentExpr(name), nil
+	}
+}
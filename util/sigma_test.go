@@ -0,0 +1,101 @@
+package util
+
+import "testing"
+
+func TestSigmaRuleMatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		event map[string]interface{}
+		want  bool
+	}{
+		{"equals is case-insensitive", `
+detection:
+  selection:
+    Image: 'C:\Windows\System32\cmd.exe'
+  condition: selection`, map[string]interface{}{"Image": `c:\windows\system32\CMD.EXE`}, true},
+		{"contains all", `
+detection:
+  selection:
+    CommandLine|contains|all:
+      - '-enc'
+      - 'bypass'
+  condition: selection`, map[string]interface{}{"CommandLine": "powershell -ep bypass -enc AAA"}, true},
+		{"contains all misses one", `
+detection:
+  selection:
+    CommandLine|contains|all:
+      - '-enc'
+      - 'bypass'
+  condition: selection`, map[string]interface{}{"CommandLine": "powershell -enc AAA"}, false},
+		{"list values are or", `
+detection:
+  selection:
+    Image|endswith:
+      - '\rundll32.exe'
+      - '\regsvr32.exe'
+  condition: selection`, map[string]interface{}{"Image": `C:\Windows\regsvr32.exe`}, true},
+		{"and not filter", `
+detection:
+  selection:
+    EventID: 4688
+  filter:
+    User|startswith: 'SYSTEM'
+  condition: selection and not filter`, map[string]interface{}{"EventID": 4688, "User": "SYSTEM"}, false},
+		{"1 of selection_*", `
+detection:
+  selection_a:
+    Image|endswith: '\mshta.exe'
+  selection_b:
+    Image|endswith: '\wscript.exe'
+  condition: 1 of selection_*`, map[string]interface{}{"Image": `C:\wscript.exe`}, true},
+		{"cidr", `
+detection:
+  selection:
+    DestinationIp|cidr: '10.0.0.0/8'
+  condition: selection`, map[string]interface{}{"DestinationIp": "10.1.2.3"}, true},
+		{"numeric comparison", `
+detection:
+  selection:
+    Port|gte: 1024
+  condition: selection`, map[string]interface{}{"Port": 80}, false},
+		{"keywords", `
+detection:
+  keywords:
+    - 'mimikatz'
+  condition: keywords`, map[string]interface{}{"Message": "Invoke-Mimikatz detected"}, true},
+		{"null means missing", `
+detection:
+  selection:
+    ParentImage: null
+  condition: selection`, map[string]interface{}{"Image": "a.exe"}, true},
+	}
+	for _, tt := range tests {
+		rule, err := CompileSigma(tt.rule)
+		if err != nil {
+			t.Errorf("%s: CompileSigma error: %v", tt.name, err)
+			continue
+		}
+		if got := rule.Match(tt.event); got != tt.want {
+			t.Errorf("%s: Match(%v) = %v, want %v", tt.name, tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestCompileSigmaErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+	}{
+		{"missing condition", "detection:\n  selection:\n    a: b\n"},
+		{"aggregation", "detection:\n  selection:\n    a: b\n  condition: selection | count() > 5\n"},
+		{"unknown modifier", "detection:\n  selection:\n    a|fuzzy: b\n  condition: selection\n"},
+		{"unknown search", "detection:\n  selection:\n    a: b\n  condition: other\n"},
+		{"invalid yaml", "detection: [\n"},
+	}
+	for _, tt := range tests {
+		if _, err := CompileSigma(tt.rule); err == nil {
+			t.Errorf("%s: CompileSigma succeeded, want error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package util
+
+import "strings"
+
+// TLP 等级，按限制程度从低到高排列
+const (
+	TLPClear       = "TLP:CLEAR"
+	TLPGreen       = "TLP:GREEN"
+	TLPAmber       = "TLP:AMBER"
+	TLPAmberStrict = "TLP:AMBER+STRICT"
+	TLPRed         = "TLP:RED"
+)
+
+var tlpLevels = map[string]int{
+	TLPClear:       0,
+	TLPGreen:       1,
+	TLPAmber:       2,
+	TLPAmberStrict: 3,
+	TLPRed:         4,
+}
+
+// confidentialityTLP 常见的中英文密级写法对应的 TLP 等级
+var confidentialityTLP = map[string]string{
+	"white": TLPClear, "clear": TLPClear, "public": TLPClear, "公开": TLPClear, "非密": TLPClear,
+	"green": TLPGreen, "internal": TLPGreen, "内部": TLPGreen, "社区": TLPGreen,
+	"amber": TLPAmber, "restricted": TLPAmber, "受限": TLPAmber, "秘密": TLPAmber,
+	"amber+strict": TLPAmberStrict, "strict": TLPAmberStrict,
+	"red": TLPRed, "confidential": TLPRed, "secret": TLPRed, "机密": TLPRed, "绝密": TLPRed,
+}
+
+// NormalizeTLP 将 confidentiality 字段或 TLP 标签(tlp:amber、TLP:WHITE 等)转换为 TLP 等级，无法识别时返回空字符串
+func NormalizeTLP(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "tlp:"), "tlp")
+	s = strings.TrimSpace(strings.Trim(s, ":-_ "))
+	return confidentialityTLP[s]
+}
+
+// TLPLevel 返回 TLP 等级的限制程度，数值越大越严格；无法识别时返回 -1
+func TLPLevel(tlp string) int {
+	if level, ok := tlpLevels[NormalizeTLP(tlp)]; ok {
+		return level
+	}
+	return -1
+}
+
+// TLPAllows 判断 clearance 等级的接收方能否获取 tlp 等级的数据。未标注等级的数据视为 TLP:CLEAR，
+// 标注了但无法识别的按 TLP:RED 处理，避免泄露
+func TLPAllows(clearance, tlp string) bool {
+	level := 0
+	if strings.TrimSpace(tlp) != "" {
+		if level = TLPLevel(tlp); level < 0 {
+			level = tlpLevels[TLPRed]
+		}
+	}
+	return TLPLevel(clearance) >= level
+}
@@ -0,0 +1,64 @@
+package util
+
+import "testing"
+
+func TestNormalizeTLP(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"tlp:amber", TLPAmber},
+		{"TLP:WHITE", TLPClear},
+		{"TLP-RED", TLPRed},
+		{"tlp:amber+strict", TLPAmberStrict},
+		{"内部", TLPGreen},
+		{"机密", TLPRed},
+		{" Public ", TLPClear},
+		{"unknown", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeTLP(tt.in); got != tt.want {
+			t.Errorf("NormalizeTLP(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTLPLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"TLP:CLEAR", 0},
+		{"green", 1},
+		{"TLP:AMBER", 2},
+		{"TLP:AMBER+STRICT", 3},
+		{"red", 4},
+		{"unknown", -1},
+		{"", -1},
+	}
+	for _, tt := range tests {
+		if got := TLPLevel(tt.in); got != tt.want {
+			t.Errorf("TLPLevel(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTLPAllows(t *testing.T) {
+	tests := []struct {
+		clearance, tlp string
+		want           bool
+	}{
+		{"TLP:AMBER", "TLP:GREEN", true},
+		{"TLP:AMBER", "TLP:AMBER", true},
+		{"TLP:AMBER", "TLP:RED", false},
+		{"TLP:CLEAR", "", true},
+		{"TLP:AMBER", "绝对保密", false},
+		{"TLP:RED", "绝对保密", true},
+		{"", "TLP:CLEAR", false},
+	}
+	for _, tt := range tests {
+		if got := TLPAllows(tt.clearance, tt.tlp); got != tt.want {
+			t.Errorf("TLPAllows(%q, %q) = %v, want %v", tt.clearance, tt.tlp, got, tt.want)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package util
 
+import "strings"
+
 // IsListField 检查字段是否是一个列表类型的字段
 func IsListField(fieldName string) bool {
 	listFields := []string{"tags", "knowledgeType", "tacticsId", "techniquesId", "subTechniquesId"}
@@ -10,3 +12,39 @@ func IsListField(fieldName string) bool {
 	}
 	return false
 }
+
+// SplitList 将自由文本字段(如 usedTools、targetedIndustry)按常见分隔符拆分为去重后的列表
+func SplitList(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		switch r {
+		case ',', ';', '|', '\n', '\r', '\t', '，', '；', '、', '/':
+			return true
+		}
+		return false
+	})
+
+	seen := make(map[string]bool)
+	var result []string
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key := strings.ToLower(field)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, field)
+	}
+	return result
+}
+
+// CleanString 去除所有不可见字符(零宽空格等)
+func CleanString(input string) string {
+	cleaned := strings.ReplaceAll(input, "\u200B", "")
+	cleaned = strings.ReplaceAll(cleaned, "\u200C", "")
+	cleaned = strings.ReplaceAll(cleaned, "\u200D", "")
+	cleaned = strings.ReplaceAll(cleaned, "\uFEFF", "")
+	return cleaned
+}
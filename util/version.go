@@ -0,0 +1,253 @@
+package util
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// preReleaseWords 紧跟在数字后面也表示预发布的字母段，如 "1.0rc"、"2.0beta"
+var preReleaseWords = map[string]bool{
+	"alpha": true, "beta": true, "rc": true, "pre": true, "preview": true, "dev": true, "snapshot": true,
+}
+
+// versionTokens 将版本号拆分为数字段和字母段，例如 "2.0-beta9" -> [2 0 beta 9]。
+// 紧跟在最后一个数字段后面的字母段是 OpenSSL 式的补丁版本(1.1.1 < 1.1.1a < 1.1.1k)，加 "+" 前缀标记
+func versionTokens(v string) []string {
+	var tokens []string
+	var b strings.Builder
+	kind := 0         // 1 数字，2 字母
+	attached := false // 当前字母段紧跟在数字段后面
+	flush := func() {
+		if b.Len() > 0 {
+			tokens = append(tokens, b.String())
+			b.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(strings.TrimSpace(v)) {
+		var k int
+		switch {
+		case unicode.IsDigit(r):
+			k = 1
+		case unicode.IsLetter(r):
+			k = 2
+		default:
+			flush()
+			kind = 0
+			continue
+		}
+		if k != kind {
+			attached = kind == 1 && k == 2
+			flush()
+			kind = k
+		}
+		b.WriteRune(r)
+	}
+	flush()
+	if n := len(tokens); kind == 2 && attached && !preReleaseWords[tokens[n-1]] {
+		tokens[n-1] = "+" + tokens[n-1]
+	}
+	return tokens
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// versionRank 段的类别：预发布的字母段 < 补丁版本的字母段 < 数字段
+func versionRank(token string) int {
+	switch {
+	case isNumber(token):
+		return 2
+	case strings.HasPrefix(token, "+"):
+		return 1
+	}
+	return 0
+}
+
+// CompareVersions 比较两个版本号，返回 -1、0、1。数字段按数值比较；
+// 较短的版本后面如果跟的是字母段(如 rc、beta)，则视为预发布版本，小于正式版本；
+// 紧跟在最后一个数字后面的字母(如 1.1.1k)视为补丁版本，大于正式版本
+func CompareVersions(a, b string) int {
+	ta, tb := versionTokens(a), versionTokens(b)
+	for i := 0; i < len(ta) || i < len(tb); i++ {
+		switch {
+		case i >= len(ta):
+			if c := compareMissing(tb[i]); c != 0 {
+				return -c
+			}
+			continue
+		case i >= len(tb):
+			if c := compareMissing(ta[i]); c != 0 {
+				return c
+			}
+			continue
+		}
+
+		x, y := ta[i], tb[i]
+		if rx, ry := versionRank(x), versionRank(y); rx != ry {
+			if rx < ry {
+				return -1
+			}
+			return 1
+		}
+		if versionRank(x) == 2 {
+			nx, _ := strconv.Atoi(x)
+			ny, _ := strconv.Atoi(y)
+			if nx != ny {
+				if nx < ny {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+		if c := strings.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareMissing 比较另一个版本在此处多出来的段与没有这一段：0 不影响，补丁版本更大，预发布更小
+func compareMissing(token string) int {
+	switch versionRank(token) {
+	case 2:
+		if n, _ := strconv.Atoi(token); n == 0 {
+			return 0
+		}
+		return 1
+	case 1:
+		return 1
+	}
+	return -1
+}
+
+// VersionRange 受影响的版本范围，Version 为 "*" 或空表示不限版本
+type VersionRange struct {
+	Version        string
+	StartIncluding string
+	StartExcluding string
+	EndIncluding   string
+	EndExcluding   string
+}
+
+// HasBounds 是否声明了上下界
+func (r VersionRange) HasBounds() bool {
+	return r.StartIncluding != "" || r.StartExcluding != "" || r.EndIncluding != "" || r.EndExcluding != ""
+}
+
+// Contains 判断版本是否落在范围内
+func (r VersionRange) Contains(version string) bool {
+	if r.HasBounds() {
+		if r.StartIncluding != "" && CompareVersions(version, r.StartIncluding) < 0 {
+			return false
+		}
+		if r.StartExcluding != "" && CompareVersions(version, r.StartExcluding) <= 0 {
+			return false
+		}
+		if r.EndIncluding != "" && CompareVersions(version, r.EndIncluding) > 0 {
+			return false
+		}
+		if r.EndExcluding != "" && CompareVersions(version, r.EndExcluding) >= 0 {
+			return false
+		}
+		return true
+	}
+	switch r.Version {
+	case "", "*", "-":
+		return true
+	}
+	return CompareVersions(version, r.Version) == 0
+}
+
+var (
+	versionPattern  = `v?(\d[\w.\-+]*?)`
+	opRangePattern  = regexp.MustCompile(`^(<=|<|>=|>|=)\s*` + versionPattern + `$`)
+	spanPattern     = regexp.MustCompile(`(?i)^` + versionPattern + `\s*(?:-|~|to|through|至|到)\s*` + versionPattern + `$`)
+	beforePattern   = regexp.MustCompile(`(?i)^(?:before|prior to|below|earlier than|低于)\s*` + versionPattern + `$`)
+	beforeCNPattern = regexp.MustCompile(`(?i)^` + versionPattern + `\s*(?:之前|以前)(?:的)?(?:版本)?$`)
+	upToPattern     = regexp.MustCompile(`(?i)^(?:up to(?: and including)?|through)\s*` + versionPattern + `$`)
+	earlierPattern  = regexp.MustCompile(`(?i)^` + versionPattern + `\s*(?:and earlier|and prior|or earlier|or prior|and below|及之前|及以前|及以下|以下|及更早)(?:的)?(?:版本)?$`)
+	laterPattern    = regexp.MustCompile(`(?i)^` + versionPattern + `\s*(?:and later|or later|and above|or above|\+|及以上|及之后|以上|及更高)(?:的)?(?:版本)?$`)
+	exactPattern    = regexp.MustCompile(`^` + versionPattern + `$`)
+)
+
+// ParseVersionExpr 解析自由文本中的版本描述，如 "< 1.20.1"、"2.0-2.14.1"、"1.18.0 及之前版本"。
+// 多个描述可以用逗号、分号分隔；无法识别的部分通过第二个返回值返回
+func ParseVersionExpr(text string) ([]VersionRange, []string) {
+	var ranges []VersionRange
+	var unparsed []string
+
+	for _, part := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ';' || r == '，' || r == '；' || r == '、' || r == '\n'
+	}) {
+		part = strings.TrimSpace(part)
+		switch strings.ToLower(part) {
+		case "*", "all", "all versions", "全版本", "所有版本", "全部版本":
+			ranges = append(ranges, VersionRange{Version: "*"})
+			continue
+		}
+		part = strings.TrimSuffix(strings.TrimSuffix(part, "版本"), "version")
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		switch {
+		case opRangePattern.MatchString(part):
+			m := opRangePattern.FindStringSubmatch(part)
+			switch m[1] {
+			case "<":
+				ranges = append(ranges, VersionRange{EndExcluding: m[2]})
+			case "<=":
+				ranges = append(ranges, VersionRange{EndIncluding: m[2]})
+			case ">":
+				ranges = append(ranges, VersionRange{StartExcluding: m[2]})
+			case ">=":
+				ranges = append(ranges, VersionRange{StartIncluding: m[2]})
+			default:
+				ranges = append(ranges, VersionRange{Version: m[2]})
+			}
+		case spanPattern.MatchString(part):
+			m := spanPattern.FindStringSubmatch(part)
+			ranges = append(ranges, VersionRange{StartIncluding: m[1], EndIncluding: m[2]})
+		case beforePattern.MatchString(part):
+			ranges = append(ranges, VersionRange{EndExcluding: beforePattern.FindStringSubmatch(part)[1]})
+		case beforeCNPattern.MatchString(part):
+			ranges = append(ranges, VersionRange{EndExcluding: beforeCNPattern.FindStringSubmatch(part)[1]})
+		case upToPattern.MatchString(part):
+			ranges = append(ranges, VersionRange{EndIncluding: upToPattern.FindStringSubmatch(part)[1]})
+		case earlierPattern.MatchString(part):
+			ranges = append(ranges, VersionRange{EndIncluding: earlierPattern.FindStringSubmatch(part)[1]})
+		case laterPattern.MatchString(part):
+			ranges = append(ranges, VersionRange{StartIncluding: laterPattern.FindStringSubmatch(part)[1]})
+		case exactPattern.MatchString(part):
+			ranges = append(ranges, VersionRange{Version: exactPattern.FindStringSubmatch(part)[1]})
+		default:
+			unparsed = append(unparsed, part)
+		}
+	}
+
+	return mergeBounds(ranges), unparsed
+}
+
+// mergeBounds 将相邻的只有下界和只有上界的描述合并为一个范围，例如 ">=2.0, <2.15.0"
+func mergeBounds(ranges []VersionRange) []VersionRange {
+	var merged []VersionRange
+	for i := 0; i < len(ranges); i++ {
+		r := ranges[i]
+		lowerOnly := (r.StartIncluding != "" || r.StartExcluding != "") && r.EndIncluding == "" && r.EndExcluding == ""
+		if lowerOnly && i+1 < len(ranges) {
+			next := ranges[i+1]
+			if next.StartIncluding == "" && next.StartExcluding == "" && (next.EndIncluding != "" || next.EndExcluding != "") {
+				r.EndIncluding, r.EndExcluding = next.EndIncluding, next.EndExcluding
+				i++
+			}
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
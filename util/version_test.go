@@ -0,0 +1,88 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "1.0.0", 0},
+		{"1.9", "1.10", -1},
+		{"2.14.1", "2.15.0", -1},
+		{"1.2.3", "1.2", 1},
+		// 预发布版本小于正式版本
+		{"2.0-beta9", "2.0", -1},
+		{"2.0.0-rc1", "2.0.0", -1},
+		{"1.0rc1", "1.0", -1},
+		{"1.0rc", "1.0", -1},
+		{"2.0-alpha", "2.0-beta", -1},
+		{"2.0-beta9", "2.0-beta10", -1},
+		// OpenSSL 式补丁版本大于正式版本
+		{"1.1.1k", "1.1.1", 1},
+		{"1.1.1", "1.1.1a", -1},
+		{"1.1.1a", "1.1.1k", -1},
+		{"1.1.1k", "1.1.2", -1},
+		{"0.9.8z", "0.9.8za", -1},
+		{"1.1.1k", "1.1.1-rc1", 1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := CompareVersions(tt.b, tt.a); got != -tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestVersionRangeContains(t *testing.T) {
+	tests := []struct {
+		r       VersionRange
+		version string
+		want    bool
+	}{
+		{VersionRange{EndExcluding: "1.1.1k"}, "1.1.1j", true},
+		{VersionRange{EndExcluding: "1.1.1k"}, "1.1.1", true},
+		{VersionRange{EndExcluding: "1.1.1k"}, "1.1.1k", false},
+		{VersionRange{StartIncluding: "2.0", EndExcluding: "2.15.0"}, "2.14.1", true},
+		{VersionRange{StartIncluding: "2.0", EndExcluding: "2.15.0"}, "2.0-beta9", false},
+		{VersionRange{StartExcluding: "1.0", EndIncluding: "1.2"}, "1.0", false},
+		{VersionRange{StartExcluding: "1.0", EndIncluding: "1.2"}, "1.2", true},
+		{VersionRange{Version: "*"}, "9.9", true},
+		{VersionRange{Version: "1.0"}, "1.0.0", true},
+		{VersionRange{Version: "1.0"}, "1.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := tt.r.Contains(tt.version); got != tt.want {
+			t.Errorf("%+v.Contains(%q) = %v, want %v", tt.r, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestParseVersionExpr(t *testing.T) {
+	tests := []struct {
+		text     string
+		ranges   []VersionRange
+		unparsed []string
+	}{
+		{"< 1.20.1", []VersionRange{{EndExcluding: "1.20.1"}}, nil},
+		{"2.0-2.14.1", []VersionRange{{StartIncluding: "2.0", EndIncluding: "2.14.1"}}, nil},
+		{"1.18.0 及之前版本", []VersionRange{{EndIncluding: "1.18.0"}}, nil},
+		{"before 3.1", []VersionRange{{EndExcluding: "3.1"}}, nil},
+		{">=2.0, <2.15.0", []VersionRange{{StartIncluding: "2.0", EndExcluding: "2.15.0"}}, nil},
+		{"5.0 and later", []VersionRange{{StartIncluding: "5.0"}}, nil},
+		{"全版本", []VersionRange{{Version: "*"}}, nil},
+		{"1.2.3; unknown build", []VersionRange{{Version: "1.2.3"}}, []string{"unknown build"}},
+	}
+	for _, tt := range tests {
+		ranges, unparsed := ParseVersionExpr(tt.text)
+		if !reflect.DeepEqual(ranges, tt.ranges) || !reflect.DeepEqual(unparsed, tt.unparsed) {
+			t.Errorf("ParseVersionExpr(%q) = %+v, %q; want %+v, %q", tt.text, ranges, unparsed, tt.ranges, tt.unparsed)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"mongdbs/resolvers"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// curl -X GET "http://localhost:8085/api/knowledge/weakness?id=5" 返回知识条目的 CWE、CAPEC 和 ATT&CK 关联
+func knowledgeWeaknessHandler(c *gin.Context) {
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be provided"})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	report, err := resolver.Query().KnowledgeWeakness(ctx, id)
+	if err != nil {
+		kindError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// curl -X GET "http://localhost:8085/api/cwe/knowledge?cwe=CWE-79&nums=10" 查找同一 CWE 下的全部漏洞
+// 也可以传 id，查找与该知识条目共享 CWE 的其他条目
+func searchByCWEHandler(c *gin.Context) {
+	cwe := c.QueryArray("cwe")
+	id := c.Query("id")
+	numsStr := c.DefaultQuery("nums", "0")
+	nums, err := strconv.Atoi(numsStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid nums parameter"})
+		return
+	}
+
+	ctx := context.Background()
+	resolver := resolvers.Resolver{}
+	if len(cwe) == 0 && id != "" {
+		report, err := resolver.Query().KnowledgeWeakness(ctx, id)
+		if err != nil {
+			kindError(c, err)
+			return
+		}
+		for _, w := range report.Weaknesses {
+			cwe = append(cwe, w.ID)
+		}
+		cwe = append(cwe, report.Missing...)
+	}
+	if len(cwe) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cwe or id must be provided"})
+		return
+	}
+
+	results, err := resolver.Query().SearchByCWE(ctx, cwe, id, nums)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}